@@ -45,6 +45,7 @@ const socketIO = require('socket.io');
 const path = require('path');
 const multer = require('multer');
 const fs = require('fs');
+const crypto = require('crypto');
 
 // Browser opening guard - prevents duplicate browser opens
 let browserOpened = false;
@@ -1220,6 +1221,29 @@ app.get('/api/init-state', (req, res) => {
     res.json(initState.getState());
 });
 
+// ========== SHUTDOWN ROUTE ==========
+// Die Launcher beenden den Server mit SIGINT bzw. CTRL_BREAK. Ein Launcher
+// ohne Konsole (Windows-GUI) kann kein CTRL_BREAK senden und fragt hier an.
+// Erlaubt nur lokal und mit dem Token, den der Launcher in
+// LTTH_SHUTDOWN_TOKEN übergeben hat.
+app.post('/api/shutdown', (req, res) => {
+    const token = process.env.LTTH_SHUTDOWN_TOKEN || '';
+    const given = req.get('X-Shutdown-Token') || '';
+    const remote = req.socket.remoteAddress;
+    const isLocal = remote === '127.0.0.1' || remote === '::1' || remote === '::ffff:127.0.0.1';
+    const valid = token !== '' && given.length === token.length &&
+        crypto.timingSafeEqual(Buffer.from(given), Buffer.from(token));
+
+    if (!isLocal || !valid) {
+        logger.warn(`Shutdown request from ${remote} rejected`);
+        return res.status(403).json({ success: false, error: 'Forbidden' });
+    }
+
+    logger.info('Shutdown requested by the launcher');
+    res.status(202).json({ success: true });
+    setImmediate(gracefulShutdown);
+});
+
 // ========== I18N ROUTES ==========
 
 // Get available locales
@@ -3317,7 +3341,12 @@ const PORT = process.env.PORT || 3000;
 })(); // Schließe async IIFE
 
 // Graceful Shutdown
-process.on('SIGINT', async () => {
+let shuttingDown = false;
+async function gracefulShutdown() {
+    if (shuttingDown) {
+        return;
+    }
+    shuttingDown = true;
     logger.info('\n\n🛑 Shutting down gracefully...');
 
     // TikTok-Verbindung trennen
@@ -3345,7 +3374,11 @@ process.on('SIGINT', async () => {
         logger.info('✅ Server closed');
         process.exit(0);
     });
-});
+}
+
+process.on('SIGINT', gracefulShutdown);
+// CTRL_BREAK von den Windows-Launchern
+process.on('SIGBREAK', gracefulShutdown);
 
 // Error Handling
 process.on('uncaughtException', (error) => {
@@ -0,0 +1,178 @@
+// Development version of the GUI launcher (dev_launcher.exe).
+// Build WITHOUT -H windowsgui flag to show terminal window for debugging.
+// Build command: go build -o dev_launcher.exe ./cmd/dev-launcher
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/launcher"
+	"github.com/pkg/browser"
+)
+
+func waitForEnter() {
+	fmt.Println("\n👉 Drücke Enter zum Beenden...")
+	bufio.NewReader(os.Stdin).ReadBytes('\n')
+}
+
+// flushOutput gives buffered server output time to reach the terminal
+// before crash messages are printed.
+func flushOutput() {
+	time.Sleep(500 * time.Millisecond)
+	os.Stdout.Sync()
+	os.Stderr.Sync()
+}
+
+func main() {
+	exeDir, appDir, err := launcher.Dirs()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// DEV MODE: Force unbuffered output from Node.js
+	env := []string{"NODE_NO_WARNINGS=1"} // Reduce noise
+	if runtime.GOOS == "windows" {
+		// On Windows, ensure console output is not buffered
+		env = append(env, "PYTHONUNBUFFERED=1")
+	}
+
+	settings, settingsErr := launcher.LoadSettings(exeDir)
+	if settingsErr != nil {
+		fmt.Printf("WARNUNG: %v - verwende Standardeinstellungen\n", settingsErr)
+	}
+
+	splash := launcher.NewSplash(filepath.Join(appDir, "launcherbg.jpg"))
+
+	// DEV MODE: Logger writes to file only, but server output goes to both file and console
+	// This ensures launcher progress is logged while server errors are visible in terminal
+	l := launcher.New(launcher.Config{
+		AppDir:       appDir,
+		LogTitle:     "TikTok Stream Tool - DEV Launcher Log",
+		Console:      os.Stdout,
+		Stdin:        os.Stdin,
+		Env:          env,
+		ChooseRemedy: splash.ChooseRemedy,
+		Settings:     settings,
+	}, splash)
+
+	if err := l.SetupLogging(); err != nil {
+		fmt.Printf("WARNUNG: Log-Datei konnte nicht erstellt werden: %v\n", err)
+	}
+
+	l.Logf("Launcher started successfully")
+	l.Logf("Executable directory: %s", exeDir)
+	l.Logf("App directory: %s", appDir)
+	l.HandleSignals()
+
+	go func() {
+		if err := splash.ListenAndServe("127.0.0.1:58734"); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+	browser.OpenURL("http://127.0.0.1:58734")
+	time.Sleep(1 * time.Second) // Give browser time to load
+
+	fmt.Println("\n================================================")
+	fmt.Println("  DEV MODE: Server output will be visible below")
+	fmt.Println("  Output wird in Echtzeit angezeigt (unbuffered)")
+	fmt.Println("================================================")
+	fmt.Println()
+
+	_, err = l.Run()
+	if err != nil {
+		flushOutput()
+
+		fmt.Println("\n================================================")
+		fmt.Println("  ❌ SERVER START FEHLGESCHLAGEN")
+		fmt.Println("================================================")
+		fmt.Printf("\n%v\n", err)
+		var lerr *launcher.Error
+		if errors.As(err, &lerr) {
+			for _, hint := range lerr.Hints {
+				fmt.Println(hint)
+			}
+		}
+		fmt.Println("\nFehlerdetails siehe oben.")
+		fmt.Println("Log-Datei: app/logs/launcher_*.log")
+
+		// DEV MODE: Wait for user input instead of auto-closing
+		waitForEnter()
+		l.Close()
+		os.Exit(1)
+	}
+
+	// DEV MODE: Keep launcher running to monitor server and catch crashes
+	fmt.Println("\n================================================")
+	fmt.Println("  DEV MODE: Launcher bleibt aktiv")
+	fmt.Println("  Server-Prozess wird überwacht")
+	fmt.Printf("  Neustart-Richtlinie: %s\n", settings.Restart.Policy)
+	fmt.Println("  Bei Crash bleibt Terminal offen für Logs")
+	fmt.Println("================================================")
+	fmt.Println()
+	l.Logf("[DEV MODE] Launcher staying active to monitor server process")
+
+	// Wait until the supervisor stops restarting the server
+	err = l.Supervise()
+
+	// CRITICAL: Give time for buffered output to flush before showing crash message
+	// This ensures we can see the actual error that caused the crash
+	flushOutput()
+
+	// A clean exit the restart policy did not restart is no crash
+	if err == nil {
+		l.Logf("--- Node.js Server Output End ---")
+		l.Logf("[INFO] Server stopped normally (exit code 0)")
+		fmt.Println()
+		fmt.Println("================================================")
+		fmt.Println("  Server wurde beendet (Exit-Code 0)")
+		fmt.Println("================================================")
+		waitForEnter()
+		l.Close()
+		os.Exit(0)
+	}
+
+	// Print prominent crash message to console
+	fmt.Println()
+	fmt.Println("████████████████████████████████████████████████")
+	fmt.Println("██                                            ██")
+	fmt.Println("██        ❌ SERVER CRASH DETECTED! ❌         ██")
+	fmt.Println("██                                            ██")
+	fmt.Println("████████████████████████████████████████████████")
+	fmt.Println()
+
+	l.Logf("--- Node.js Server Output End ---")
+	l.Logf("[ERROR] ===========================================")
+	l.Logf("[ERROR] Server crashed after successful startup!")
+	l.Logf("[ERROR] Exit status: %v", err)
+	l.Logf("[ERROR] Check the server output above for error details")
+	l.Logf("[ERROR] ===========================================")
+
+	fmt.Println("❌ Der Server ist abgestürzt!")
+	fmt.Printf("   Exit-Status: %v\n", err)
+	fmt.Println()
+	fmt.Println("📋 LETZTE AUSGABE VOR DEM CRASH:")
+	fmt.Println("   Sieh dir die Zeilen DIREKT ÜBER dieser Meldung an!")
+	fmt.Println()
+	fmt.Println("💾 Vollständige Logs in: app/logs/launcher_*.log")
+	fmt.Println()
+	fmt.Println("⚠️  HÄUFIGE CRASH-URSACHEN:")
+	fmt.Println("   - Ungültige TikTok Username")
+	fmt.Println("   - Netzwerkprobleme")
+	fmt.Println("   - TikTok API Änderungen")
+	fmt.Println("   - Fehlende Permissions")
+
+	// Wait for user to press Enter before closing
+	waitForEnter()
+
+	l.Close()
+	os.Exit(1)
+}
@@ -0,0 +1,156 @@
+// Backup launcher with detailed logging (launcher-backup.exe). Writes
+// launcher-debug.log next to the executable for troubleshooting.
+// Build command: go build -o launcher-backup.exe ./cmd/launcher-backup
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/launcher"
+)
+
+func printHeader() {
+	fmt.Println("================================================")
+	fmt.Println("  TikTok Stream Tool - Backup Launcher")
+	fmt.Println("  Mit detailliertem Logging")
+	fmt.Println("================================================")
+	fmt.Println()
+}
+
+func pause() {
+	fmt.Println()
+	fmt.Print("Druecke Enter zum Beenden...")
+	fmt.Scanln()
+}
+
+// confirmNodeVersion explains why the Node.js version is a problem and asks
+// whether to continue anyway.
+func confirmNodeVersion(c launcher.NodeCompat) bool {
+	fmt.Println()
+	fmt.Println("===============================================")
+	fmt.Println("  WARNUNG: Node.js Version Inkompatibilitaet!")
+	fmt.Println("===============================================")
+	fmt.Println()
+	fmt.Println(c.Reason)
+	fmt.Println()
+	fmt.Printf("Dieses Tool benoetigt Node.js %s.\n", c.Range)
+	fmt.Println()
+	fmt.Println("Neuere Node.js Versionen erfordern Visual Studio 2019 oder")
+	fmt.Println("neuer mit 'Desktop development with C++' Workload fuer")
+	fmt.Println("die Kompilierung nativer Module (better-sqlite3).")
+	fmt.Println()
+	fmt.Println("EMPFOHLENE LOESUNG:")
+	fmt.Printf("1. Deinstalliere Node.js %s\n", c.Version)
+	fmt.Println("2. Installiere Node.js LTS in einer passenden Version von:")
+	fmt.Println("   https://nodejs.org/en/download/")
+	fmt.Println()
+	fmt.Println("ALTERNATIVE (Erweitert):")
+	fmt.Println("1. Installiere Visual Studio Build Tools 2019+")
+	fmt.Println("2. Waehle 'Desktop development with C++' Workload")
+	fmt.Println("3. Download: https://visualstudio.microsoft.com/downloads/")
+	fmt.Println()
+	fmt.Print("Moechtest Du trotzdem fortfahren? (j/n): ")
+
+	var response string
+	fmt.Scanln(&response)
+	return response == "j" || response == "J"
+}
+
+func main() {
+	doctor := flag.Bool("doctor", false, "Umgebung prüfen und einen Diagnosebericht ausgeben, ohne das Tool zu starten")
+	flag.Parse()
+	printHeader()
+
+	// Get executable directory first
+	exeDir, appDir, err := launcher.Dirs()
+	if err != nil {
+		fmt.Printf("KRITISCHER FEHLER: %v\n", err)
+		pause()
+		os.Exit(1)
+	}
+
+	fmt.Printf("Programmverzeichnis: %s\n", exeDir)
+	fmt.Printf("Betriebssystem: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Println()
+
+	settings, settingsErr := launcher.LoadSettings(exeDir)
+	if settingsErr != nil {
+		fmt.Printf("WARNUNG: %v - verwende Standardeinstellungen\n", settingsErr)
+	}
+
+	logPath := filepath.Join(exeDir, "launcher-debug.log")
+	l := launcher.New(launcher.Config{
+		AppDir:             appDir,
+		LogTitle:           "Launcher Backup - Neuer Start",
+		LogPath:            logPath,
+		Console:            os.Stdout,
+		Stdin:              os.Stdin,
+		ConfirmNodeVersion: confirmNodeVersion,
+		ChooseRemedy:       launcher.PromptRemedy(os.Stdin, os.Stdout),
+		Settings:           settings,
+	}, &launcher.ConsoleSink{Out: os.Stdout})
+
+	if *doctor {
+		ok := l.Doctor(os.Stdout)
+		pause()
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Initialize logging
+	if err := l.SetupLogging(); err != nil {
+		fmt.Printf("WARNUNG: Logging konnte nicht initialisiert werden: %v\n", err)
+		fmt.Println("Fahre ohne Logging fort...")
+		fmt.Println()
+	} else {
+		fmt.Printf("Logging aktiviert: %s\n", logPath)
+	}
+	l.Logf("Executable directory: %s", exeDir)
+	l.Logf("App directory: %s", appDir)
+	l.HandleSignals()
+
+	_, err = l.Run()
+	if errors.Is(err, launcher.ErrAborted) {
+		l.Close()
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Println()
+		fmt.Println("===============================================")
+		fmt.Printf("  FEHLER: %v\n", err)
+		fmt.Println("===============================================")
+		var lerr *launcher.Error
+		if errors.As(err, &lerr) {
+			fmt.Println()
+			for _, hint := range lerr.Hints {
+				fmt.Println(hint)
+			}
+		}
+		fmt.Println()
+		fmt.Printf("Details: %s\n", logPath)
+		pause()
+		l.Close()
+		os.Exit(1)
+	}
+
+	// Run until the server exits and is not restarted
+	if err := l.Supervise(); err != nil {
+		l.Logf("[ERROR] Server exited: %v", err)
+		fmt.Printf("Fehler beim Ausfuehren: %v\n", err)
+	} else {
+		l.Logf("[SUCCESS] Server exited normally")
+	}
+
+	// Close log file before exit
+	l.Close()
+
+	// Pause before exit
+	pause()
+}
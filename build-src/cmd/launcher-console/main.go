@@ -0,0 +1,90 @@
+// Console launcher (launcher-console.exe). Shows progress and server output
+// in the terminal.
+// Build command: go build -o launcher-console.exe ./cmd/launcher-console
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/launcher"
+)
+
+func printHeader() {
+	fmt.Println("================================================")
+	fmt.Println("  TikTok Stream Tool - Launcher")
+	fmt.Println("================================================")
+	fmt.Println()
+}
+
+func pause() {
+	fmt.Println()
+	fmt.Print("Druecke Enter zum Beenden...")
+	fmt.Scanln()
+}
+
+func main() {
+	doctor := flag.Bool("doctor", false, "Umgebung prüfen und einen Diagnosebericht ausgeben, ohne das Tool zu starten")
+	flag.Parse()
+	printHeader()
+
+	exeDir, appDir, err := launcher.Dirs()
+	if err != nil {
+		fmt.Printf("Fehler: %v\n", err)
+		pause()
+		os.Exit(1)
+	}
+
+	settings, err := launcher.LoadSettings(exeDir)
+	if err != nil {
+		fmt.Printf("Warnung: %v - verwende Standardeinstellungen\n", err)
+	}
+
+	l := launcher.New(launcher.Config{
+		AppDir:       appDir,
+		Console:      os.Stdout,
+		Stdin:        os.Stdin,
+		ChooseRemedy: launcher.PromptRemedy(os.Stdin, os.Stdout),
+		Settings:     settings,
+	}, &launcher.ConsoleSink{Out: os.Stdout})
+	if *doctor {
+		ok := l.Doctor(os.Stdout)
+		pause()
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	l.SetupLogging()
+	defer l.Close()
+	l.HandleSignals()
+
+	_, err = l.Run()
+	if err != nil {
+		fmt.Println()
+		fmt.Println("===============================================")
+		fmt.Printf("  FEHLER: %v\n", err)
+		fmt.Println("===============================================")
+		var lerr *launcher.Error
+		if errors.As(err, &lerr) {
+			fmt.Println()
+			for _, hint := range lerr.Hints {
+				fmt.Println(hint)
+			}
+		}
+		pause()
+		l.Close()
+		os.Exit(1)
+	}
+
+	// Run until the server exits and is not restarted
+	if err := l.Supervise(); err != nil {
+		fmt.Printf("Fehler beim Ausfuehren: %v\n", err)
+	}
+
+	// Pause before exit
+	pause()
+}
@@ -0,0 +1,68 @@
+// GUI launcher (launcher.exe). Shows progress in the browser and has no
+// console window.
+// Build command: go build -o launcher.exe -ldflags "-H windowsgui" ./cmd/launcher
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/launcher"
+	"github.com/pkg/browser"
+)
+
+func main() {
+	exeDir, appDir, err := launcher.Dirs()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	settings, settingsErr := launcher.LoadSettings(exeDir)
+
+	splash := launcher.NewSplash(filepath.Join(appDir, "launcherbg.jpg"))
+	l := launcher.New(launcher.Config{AppDir: appDir, Settings: settings, ChooseRemedy: splash.ChooseRemedy}, splash)
+
+	// Setup logging immediately. If it fails the launcher logs nowhere
+	// (stdout doesn't exist in GUI mode)
+	l.SetupLogging()
+
+	l.Logf("Launcher started successfully")
+	l.Logf("Executable directory: %s", exeDir)
+	l.Logf("App directory: %s", appDir)
+	if settingsErr != nil {
+		l.Logf("[WARNING] Using default settings: %v", settingsErr)
+	}
+	l.HandleSignals()
+
+	// Start HTTP server
+	go func() {
+		if err := splash.ListenAndServe("127.0.0.1:58734"); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	// Give server time to start
+	time.Sleep(500 * time.Millisecond)
+
+	// Open browser
+	browser.OpenURL("http://127.0.0.1:58734")
+	time.Sleep(1 * time.Second) // Give browser time to load
+
+	if _, err := l.Run(); err != nil {
+		l.ShowHints(err)
+		splash.Progress(100, "❌ Launcher wird in 15 Sekunden geschlossen...")
+		time.Sleep(15 * time.Second)
+		l.Close()
+		os.Exit(1)
+	}
+
+	// Keep owning the server process and restart it after crashes
+	if err := l.Supervise(); err != nil {
+		l.Close()
+		os.Exit(1)
+	}
+	l.Close()
+	os.Exit(0)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Limits for the repository archive. They stop a zip bomb long before the
+// disk is full; the real archive stays far below them.
+const (
+	maxArchiveFiles = 50000
+	maxArchiveBytes = 2 << 30 // uncompressed
+	maxLinkTarget   = 4096
+)
+
+const (
+	// stagingPrefix names the directories the archive is extracted into,
+	// next to the installation so that its entries can be renamed into
+	// place.
+	stagingPrefix = ".ltth-staging-"
+	// previousDir keeps the files the last update replaced or removed.
+	previousDir = ".ltth-previous"
+)
+
+// archivePath turns the name of a zip entry into a path relative to the
+// installation: the root folder GitHub puts around the repository (e.g.
+// "pupcidslittletiktokhelper-main/") is stripped. Absolute names and ".."
+// segments are rejected, and so are names with ":", which Windows reads as
+// a drive ("C:foo") or an alternate data stream ("file:stream"). "" stands
+// for the root folder itself.
+func archivePath(name string) (string, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(name, "/") || (len(name) > 1 && name[1] == ':') {
+		return "", fmt.Errorf("absoluter Pfad im Archiv: %s", name)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." || strings.Contains(segment, ":") {
+			return "", fmt.Errorf("ungültiger Pfad im Archiv: %s", name)
+		}
+	}
+	_, rest, _ := strings.Cut(name, "/")
+	rest = path.Clean(rest)
+	if rest == "." {
+		return "", nil
+	}
+	return rest, nil
+}
+
+// within reports whether target is dir or lies below it.
+func within(dir, target string) bool {
+	rel, err := filepath.Rel(dir, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// symlinkInPath reports whether a directory between dir and target is a
+// symbolic link. Writing through one could end up anywhere, so entries
+// below a link are refused.
+func symlinkInPath(dir, target string) bool {
+	for p := filepath.Dir(target); p != dir && within(dir, p); p = filepath.Dir(p) {
+		if info, err := os.Lstat(p); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// extractZip extracts the repository archive into destDir, which should be
+// an empty staging directory. Every entry must stay inside destDir, symbolic
+// links may only point inside it, and the archive must stay within
+// maxArchiveFiles and maxArchiveBytes.
+func (cl *CloudLauncher) extractZip(zipPath, destDir string) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if len(r.File) > maxArchiveFiles {
+		return fmt.Errorf("Archiv enthält zu viele Dateien (%d, erlaubt: %d)", len(r.File), maxArchiveFiles)
+	}
+	var remaining int64 = maxArchiveBytes
+
+	for _, f := range r.File {
+		rel, err := archivePath(f.Name)
+		if err != nil {
+			return err
+		}
+		if rel == "" {
+			continue
+		}
+		target := filepath.Join(destDir, filepath.FromSlash(rel))
+		if !within(destDir, target) || symlinkInPath(destDir, target) {
+			return fmt.Errorf("ungültiger Pfad im Archiv: %s", f.Name)
+		}
+
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			err = os.MkdirAll(target, 0755)
+		case mode&os.ModeSymlink != 0:
+			err = cl.extractSymlink(f, destDir, target, rel)
+		default:
+			remaining, err = extractFile(f, target, remaining)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractSymlink creates the link f describes; its target is stored as the
+// entry's content and must resolve inside destDir.
+func (cl *CloudLauncher) extractSymlink(f *zip.File, destDir, target, rel string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, maxLinkTarget+1))
+	rc.Close()
+	if err != nil {
+		return err
+	}
+	link := string(data)
+	if len(data) > maxLinkTarget || path.IsAbs(link) || filepath.IsAbs(link) || strings.Contains(link, `\`) {
+		return fmt.Errorf("ungültiger Link im Archiv: %s", f.Name)
+	}
+	resolved := filepath.Join(destDir, filepath.FromSlash(path.Join(path.Dir(rel), link)))
+	if !within(destDir, resolved) {
+		return fmt.Errorf("Link zeigt aus dem Programmverzeichnis heraus: %s -> %s", f.Name, link)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.Symlink(link, target); err != nil {
+		// Windows only allows links with developer mode or as admin
+		cl.logger.Printf("Skipping symlink %s -> %s: %v\n", rel, link, err)
+	}
+	return nil
+}
+
+// extractFile writes a regular file and returns how many of the archive's
+// remaining bytes are left. The size in the zip header is not trusted.
+func extractFile(f *zip.File, target string, remaining int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return remaining, err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return remaining, err
+	}
+	defer rc.Close()
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, f.Mode().Perm()|0600)
+	if err != nil {
+		return remaining, err
+	}
+	n, err := io.Copy(out, io.LimitReader(rc, remaining+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return remaining, err
+	}
+	if n > remaining {
+		return remaining, fmt.Errorf("Archiv ist entpackt größer als %d MB", maxArchiveBytes>>20)
+	}
+	return remaining - n, nil
+}
+
+// removeStaleStaging deletes staging directories left behind by an update
+// that was interrupted before installRelease.
+func (cl *CloudLauncher) removeStaleStaging() {
+	stale, _ := filepath.Glob(filepath.Join(cl.baseDir, stagingPrefix+"*"))
+	for _, dir := range stale {
+		cl.logger.Printf("Removing interrupted download %s\n", dir)
+		os.RemoveAll(dir)
+	}
+}
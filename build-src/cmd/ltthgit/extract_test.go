@@ -0,0 +1,241 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testLauncher(t *testing.T) *CloudLauncher {
+	t.Helper()
+	return &CloudLauncher{baseDir: t.TempDir(), logger: log.New(io.Discard, "", 0)}
+}
+
+func TestArchivePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "root/app/launch.js", want: "app/launch.js"},
+		{name: "root/", want: ""},
+		{name: "root", want: ""},
+		{name: "root/./app//x.js", want: "app/x.js"},
+		{name: `root\app\x.js`, want: "app/x.js"},
+		{name: "../x", wantErr: true},
+		{name: "root/../x", wantErr: true},
+		{name: "root/app/../../x", wantErr: true},
+		{name: `root\..\..\x`, wantErr: true},
+		{name: "/etc/passwd", wantErr: true},
+		{name: `\Windows\x`, wantErr: true},
+		{name: "C:/Windows/x", wantErr: true},
+		{name: `C:\Windows\x`, wantErr: true},
+		{name: "C:x", wantErr: true},
+		{name: "root/C:foo", wantErr: true},
+		{name: "root/app/file.js:stream", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := archivePath(tt.name)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("archivePath(%q) = %q, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("archivePath(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+// zipEntry is one entry of a test archive.
+type zipEntry struct {
+	name string
+	body string
+	mode os.FileMode
+	// size, if set, is written into the header instead of the real size
+	size uint64
+}
+
+func writeZip(t *testing.T, entries []zipEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, e := range entries {
+		if e.size != 0 {
+			// A header that lies about the size of its data
+			var data bytes.Buffer
+			fw, _ := flate.NewWriter(&data, flate.BestCompression)
+			fw.Write([]byte(e.body))
+			fw.Close()
+			hdr := &zip.FileHeader{
+				Name:               e.name,
+				Method:             zip.Deflate,
+				CRC32:              crc32.ChecksumIEEE([]byte(e.body)),
+				CompressedSize64:   uint64(data.Len()),
+				UncompressedSize64: e.size,
+			}
+			out, err := w.CreateRaw(hdr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out.Write(data.Bytes())
+			continue
+		}
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		mode := e.mode
+		if mode == 0 {
+			mode = 0644
+		}
+		hdr.SetMode(mode)
+		out, err := w.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(out, e.body)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "archive.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractZip(t *testing.T) {
+	link := os.ModeSymlink | 0777
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		wantErr string
+		// want maps paths below the staging directory to their content,
+		// or to "-> target" for links
+		want map[string]string
+	}{
+		{
+			name: "valid",
+			entries: []zipEntry{
+				{name: "root/", mode: os.ModeDir | 0755},
+				{name: "root/app/launch.js", body: "run()"},
+				{name: `root\app\win.js`, body: "win"},
+				{name: "root/app/current", body: "launch.js", mode: link},
+				{name: "root/docs", body: "app", mode: link},
+			},
+			want: map[string]string{
+				"app/launch.js": "run()",
+				"app/win.js":    "win",
+				"app/current":   "-> launch.js",
+				"docs":          "-> app",
+			},
+		},
+		{name: "parent segment", entries: []zipEntry{{name: "root/../evil.txt", body: "x"}}, wantErr: "ungültiger Pfad"},
+		{name: "leading parent", entries: []zipEntry{{name: "../evil.txt", body: "x"}}, wantErr: "ungültiger Pfad"},
+		{name: "absolute", entries: []zipEntry{{name: "/tmp/evil.txt", body: "x"}}, wantErr: "absoluter Pfad"},
+		{name: "drive letter", entries: []zipEntry{{name: `C:\evil.txt`, body: "x"}}, wantErr: "absoluter Pfad"},
+		{name: "drive relative", entries: []zipEntry{{name: "root/C:evil.txt", body: "x"}}, wantErr: "ungültiger Pfad"},
+		{name: "backslash parent", entries: []zipEntry{{name: `root\..\..\evil.txt`, body: "x"}}, wantErr: "ungültiger Pfad"},
+		{name: "link outside", entries: []zipEntry{{name: "root/app/out", body: "../../..", mode: link}}, wantErr: "Link zeigt"},
+		{name: "absolute link", entries: []zipEntry{{name: "root/app/out", body: "/etc", mode: link}}, wantErr: "ungültiger Link"},
+		{name: "backslash link", entries: []zipEntry{{name: "root/app/out", body: `..\..\x`, mode: link}}, wantErr: "ungültiger Link"},
+		{
+			name: "write through link",
+			entries: []zipEntry{
+				{name: "root/app/data", body: "../app", mode: link},
+				{name: "root/app/data/evil.txt", body: "x"},
+			},
+			wantErr: "ungültiger Pfad",
+		},
+		{
+			// archive/zip refuses data past the size the header claims;
+			// TestExtractFileLimit covers honest headers
+			name:    "lying header size",
+			entries: []zipEntry{{name: "root/bomb.bin", body: strings.Repeat("0", 1<<20), size: 10}},
+			wantErr: "zip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := testLauncher(t)
+			dest := filepath.Join(cl.baseDir, "staging")
+			if err := os.Mkdir(dest, 0755); err != nil {
+				t.Fatal(err)
+			}
+			err := cl.extractZip(writeZip(t, tt.entries), dest)
+
+			// Nothing may appear next to the staging directory
+			if names, _ := os.ReadDir(cl.baseDir); len(names) != 1 {
+				t.Errorf("extractZip wrote outside the staging directory: %v", names)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for rel, want := range tt.want {
+				p := filepath.Join(dest, filepath.FromSlash(rel))
+				var got string
+				if target, err := os.Readlink(p); err == nil {
+					got = "-> " + target
+				} else {
+					data, err := os.ReadFile(p)
+					if err != nil {
+						t.Fatal(err)
+					}
+					got = string(data)
+				}
+				if got != want {
+					t.Errorf("%s = %q, want %q", rel, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractZipTooManyFiles(t *testing.T) {
+	entries := make([]zipEntry, maxArchiveFiles+1)
+	for i := range entries {
+		entries[i] = zipEntry{name: fmt.Sprintf("root/f%d", i)}
+	}
+	cl := testLauncher(t)
+	err := cl.extractZip(writeZip(t, entries), t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "zu viele Dateien") {
+		t.Fatalf("err = %v, want too many files", err)
+	}
+}
+
+func TestExtractFileLimit(t *testing.T) {
+	path := writeZip(t, []zipEntry{
+		{name: "root/a.bin", body: strings.Repeat("a", 600)},
+		{name: "root/b.bin", body: strings.Repeat("b", 600)},
+	})
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	dir := t.TempDir()
+
+	// The limit counts the bytes actually written, across files
+	remaining, err := extractFile(r.File[0], filepath.Join(dir, "a.bin"), 1000)
+	if err != nil || remaining != 400 {
+		t.Fatalf("first file: remaining = %d, err = %v", remaining, err)
+	}
+	_, err = extractFile(r.File[1], filepath.Join(dir, "b.bin"), remaining)
+	if err == nil || !strings.Contains(err.Error(), "größer als") {
+		t.Fatalf("err = %v, want the size limit", err)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// updateJournalFile lists the steps of the update in progress, one JSON
+// object per line. Every step is written before it is carried out, so that
+// an update interrupted by a crash or a closed console can be finished or
+// undone on the next start.
+const updateJournalFile = ".ltth-update.journal"
+
+// Journal operations.
+const (
+	opBegin  = "begin"  // first line: the release being installed
+	opMove   = "move"   // From was moved to To, in the backup
+	opCreate = "create" // To was created
+	opRmdir  = "rmdir"  // the empty directory To was removed
+)
+
+// journalEntry is one line of updateJournalFile. Paths are slash-separated
+// and relative to the installation.
+type journalEntry struct {
+	Op   string `json:"op"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Tag and Installed identify the release record that completes the
+	// update; only set for opBegin.
+	Tag       string    `json:"tag,omitempty"`
+	Installed time.Time `json:"installed,omitzero"`
+}
+
+// updateJournal is an open updateJournalFile.
+type updateJournal struct {
+	f       *os.File
+	baseDir string
+	begin   journalEntry
+	steps   []journalEntry
+}
+
+// beginJournal starts the journal of the update that installs r.
+func beginJournal(baseDir string, r *releaseRecord) (*updateJournal, error) {
+	f, err := os.OpenFile(filepath.Join(baseDir, updateJournalFile), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j := &updateJournal{f: f, baseDir: baseDir, begin: journalEntry{Op: opBegin, Tag: r.Tag, Installed: r.Installed}}
+	if err := j.write(j.begin); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *updateJournal) write(e journalEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	// Without a sync per step: the journal has to survive the launcher
+	// being killed, which leaves the written data with the OS
+	_, err = j.f.Write(append(data, '\n'))
+	return err
+}
+
+// log records the step op on the absolute paths from and to (either may be
+// "") before it is carried out.
+func (j *updateJournal) log(op, from, to string) error {
+	e := journalEntry{Op: op, From: j.rel(from), To: j.rel(to)}
+	if err := j.write(e); err != nil {
+		return fmt.Errorf("%s: %v", updateJournalFile, err)
+	}
+	j.steps = append(j.steps, e)
+	return nil
+}
+
+func (j *updateJournal) rel(p string) string {
+	if p == "" {
+		return ""
+	}
+	rel, _ := filepath.Rel(j.baseDir, p)
+	return filepath.ToSlash(rel)
+}
+
+// undo reverts the logged steps and removes the journal. Steps it could
+// not revert stay in the journal for the next start.
+func (j *updateJournal) undo() error {
+	j.f.Close()
+	return undoJournal(j.baseDir, j.begin, j.steps)
+}
+
+// finish removes the journal of a complete update.
+func (j *updateJournal) finish() error {
+	j.f.Close()
+	return os.Remove(filepath.Join(j.baseDir, updateJournalFile))
+}
+
+// readJournal reads the journal of an interrupted update. A line cut off by
+// the interruption is ignored; the step it describes was never started.
+func readJournal(baseDir string) (begin journalEntry, steps []journalEntry, err error) {
+	f, err := os.Open(filepath.Join(baseDir, updateJournalFile))
+	if err != nil {
+		return begin, nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e journalEntry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		if e.Op == opBegin {
+			begin = e
+		} else {
+			steps = append(steps, e)
+		}
+	}
+	return begin, steps, scanner.Err()
+}
+
+// undoJournal reverts steps from the last to the first. Reverting a step
+// that was logged but never carried out does nothing. It stops at the
+// first step it cannot revert and rewrites the journal with the steps
+// still to do, so that a retry never reverts a step twice; once all are
+// reverted, the journal is removed.
+func undoJournal(baseDir string, begin journalEntry, steps []journalEntry) error {
+	abs := func(rel string) string { return filepath.Join(baseDir, filepath.FromSlash(rel)) }
+	for i := len(steps) - 1; i >= 0; i-- {
+		var err error
+		switch s := steps[i]; s.Op {
+		case opMove:
+			if _, serr := os.Lstat(abs(s.To)); serr == nil {
+				if err = os.MkdirAll(filepath.Dir(abs(s.From)), 0755); err == nil {
+					err = os.Rename(abs(s.To), abs(s.From))
+				}
+			}
+		case opCreate:
+			if err = os.Remove(abs(s.To)); os.IsNotExist(err) {
+				err = nil
+			}
+		case opRmdir:
+			err = os.MkdirAll(abs(s.To), 0755)
+		}
+		if err != nil {
+			if werr := writeJournal(baseDir, begin, steps[:i+1]); werr != nil {
+				return fmt.Errorf("%v (%s: %v)", err, updateJournalFile, werr)
+			}
+			return err
+		}
+	}
+	return os.Remove(filepath.Join(baseDir, updateJournalFile))
+}
+
+// writeJournal replaces the journal with begin and steps.
+func writeJournal(baseDir string, begin journalEntry, steps []journalEntry) error {
+	tmp := filepath.Join(baseDir, updateJournalFile+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	j := &updateJournal{f: f}
+	for _, e := range append([]journalEntry{begin}, steps...) {
+		if err := j.write(e); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(baseDir, updateJournalFile))
+}
+
+// recoverUpdate finishes or undoes an update that was interrupted. If its
+// release record was written, the update is complete and only the backup
+// is kept; otherwise every step in the journal is undone and the
+// installation is left as it was before.
+func (cl *CloudLauncher) recoverUpdate() error {
+	begin, steps, err := readJournal(cl.baseDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", updateJournalFile, err)
+	}
+
+	backup := filepath.Join(cl.baseDir, previousDir+".new")
+	if r := cl.readReleaseRecord(); r != nil && begin.Tag != "" && r.Tag == begin.Tag && r.Installed.Equal(begin.Installed) {
+		cl.logger.Printf("Completing the interrupted update to %s\n", begin.Tag)
+		cl.keepBackup(backup)
+		return os.Remove(filepath.Join(cl.baseDir, updateJournalFile))
+	}
+
+	cl.logger.Printf("Undoing the interrupted update to %s (%d steps)\n", begin.Tag, len(steps))
+	if err := undoJournal(cl.baseDir, begin, steps); err != nil {
+		return fmt.Errorf("Unterbrochenes Update auf %s kann nicht rückgängig gemacht werden: %v", begin.Tag, err)
+	}
+	os.RemoveAll(backup)
+	return nil
+}
+
+// keepBackup makes backup, the files an update replaced or removed, the
+// new previousDir. Running it again after an interruption is safe.
+func (cl *CloudLauncher) keepBackup(backup string) {
+	if _, err := os.Stat(backup); err != nil {
+		return
+	}
+	kept := filepath.Join(cl.baseDir, previousDir)
+	os.RemoveAll(kept)
+	if err := os.Rename(backup, kept); err != nil {
+		// The update itself is complete
+		cl.logger.Printf("Could not keep replaced files: %v\n", err)
+	}
+}
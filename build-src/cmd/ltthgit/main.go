@@ -0,0 +1,597 @@
+package main
+
+import (
+	"crypto/sha256"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/launcher"
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/manifest"
+	"github.com/pkg/browser"
+)
+
+//go:embed assets/*
+var assets embed.FS
+
+const (
+	repoOwner = "Loggableim"
+	repoName  = "pupcidslittletiktokhelper"
+	// nightlyTag is the rolling prerelease that CI rebuilds from main
+	nightlyTag = "nightly"
+)
+
+type CloudLauncher struct {
+	baseDir string
+	events  *events.Stream
+	logger  *log.Logger
+	update  launcher.UpdateSettings
+	// settings holds the rest of launcher.json, e.g. the Node.js runtime,
+	// install profile and snapshots for the launcher phases
+	settings launcher.Settings
+	// channel and version are set by the command line and override
+	// launcher.json
+	channel string
+	version string
+	// rollbackCmd is set by "ltthgit rollback [tag]"
+	rollbackCmd bool
+	rollbackTo  string
+	// retryFailed installs versions that failed to start again
+	retryFailed bool
+	versions    *versionState
+
+	appMu sync.Mutex  // guards app
+	app   *appProcess // the running application, stopped on a signal
+}
+
+// appProcess is the started application.
+type appProcess struct {
+	// l started the server and shuts it down
+	l      *launcher.Launcher
+	port   int
+	exited chan struct{}
+	err    error
+}
+
+// stop shuts the application down like the local launchers do and waits
+// until it has exited.
+func (app *appProcess) stop() {
+	app.l.Shutdown()
+	<-app.exited
+	app.l.Close()
+}
+
+func NewCloudLauncher() *CloudLauncher {
+	return &CloudLauncher{
+		events: events.NewStream(events.DefaultReplaySize, "Initialisiere Cloud Launcher..."),
+		logger: log.New(os.Stdout, "[LTTH Cloud] ", log.LstdFlags),
+	}
+}
+
+func (cl *CloudLauncher) updateProgress(value int, status string) {
+	cl.logger.Printf("[%d%%] %s\n", value, status)
+	cl.events.Progress(value, status)
+}
+
+func (cl *CloudLauncher) sendError(err error) {
+	msg := events.Message{Message: err.Error()}
+	var ierr *installError
+	var lerr *launcher.Error
+	switch {
+	case errors.As(err, &ierr):
+		msg.Code = ierr.Code
+		msg.Hints = ierr.Hints
+	case errors.As(err, &lerr):
+		msg = events.Message{Message: lerr.Status, Hints: lerr.Hints, Code: lerr.Code, Details: lerr.Details}
+	}
+	cl.events.Publish(events.TypeError, msg)
+	cl.events.Publish(events.TypeDone, events.Done{OK: false})
+}
+
+// Serve the splash screen
+func (cl *CloudLauncher) serveSplash(w http.ResponseWriter, r *http.Request) {
+	tmplContent, err := assets.ReadFile("assets/splash.html")
+	if err != nil {
+		http.Error(w, "Failed to load splash screen", http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := template.New("splash").Parse(string(tmplContent))
+	if err != nil {
+		http.Error(w, "Failed to parse template", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Title   string
+		Version string
+	}{
+		Title:   "LTTH Cloud Launcher",
+		Version: "1.0.0",
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	tmpl.Execute(w, data)
+}
+
+// Load the update settings from launcher.json and the command line
+func (cl *CloudLauncher) loadUpdateSettings() error {
+	settings, err := launcher.LoadSettings(cl.baseDir)
+	if err != nil {
+		cl.logger.Printf("%v - using defaults\n", err)
+		cl.events.Publish(events.TypeWarning, events.Message{Message: err.Error()})
+	}
+	cl.settings = settings
+	cl.update = settings.Update
+	if cl.channel != "" {
+		cl.update.Channel = launcher.UpdateChannel(cl.channel)
+	}
+	if cl.version != "" {
+		cl.update.Version = cl.version
+	}
+	return cl.update.Validate()
+}
+
+// Download the release archive from GitHub
+func (cl *CloudLauncher) downloadRepository(r *release) error {
+	cl.updateProgress(10, fmt.Sprintf("Lade %s von GitHub herunter...", r.TagName))
+
+	// Releases are only installed with a valid signature; nightly builds
+	// are not signed and only checked against their SHA256SUMS
+	var m *manifest.Manifest
+	var zipURL string
+	if r.nightly {
+		cl.logger.Printf("Nightly build %s is not signed, checking %s against %s\n", r.TagName, r.archive.Name, checksumsName)
+		cl.events.Publish(events.TypeWarning, events.Message{Message: "Nightly-Versionen sind nicht signiert und werden nur per Prüfsumme geprüft"})
+		zipURL = r.archive.BrowserDownloadURL
+	} else {
+		keys, err := trustedKeys()
+		if err != nil {
+			return fmt.Errorf("Signaturschlüssel nicht lesbar: %v", err)
+		}
+		var archive *asset
+		m, archive, err = cl.verifiedManifest(r, keys)
+		if err != nil {
+			return err
+		}
+		zipURL = archive.BrowserDownloadURL
+	}
+
+	cl.logger.Printf("Downloading from: %s\n", zipURL)
+
+	// Download the ZIP file
+	resp, err := downloadClient.Get(zipURL)
+	if err != nil {
+		return fmt.Errorf("Download fehlgeschlagen: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("Download fehlgeschlagen: HTTP %d", resp.StatusCode)
+	}
+
+	cl.updateProgress(30, "Speichere heruntergeladene Dateien...")
+
+	// Create temp file for ZIP
+	tempZip, err := os.CreateTemp("", "ltth-repo-*.zip")
+	if err != nil {
+		return fmt.Errorf("Kann temporäre Datei nicht erstellen: %v", err)
+	}
+	defer os.Remove(tempZip.Name())
+	defer tempZip.Close()
+
+	// Copy downloaded data to temp file. An archive of known size is read
+	// up to one byte past it, so that a wrong size shows up in the check
+	// without filling the disk first.
+	limit := int64(maxArchiveBytes)
+	if m != nil {
+		limit = m.Archive.Size
+	} else if r.nightly && r.archive.Size > 0 {
+		limit = r.archive.Size
+	}
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tempZip, h), io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return fmt.Errorf("Speichern fehlgeschlagen: %v", err)
+	}
+	if m == nil && size > maxArchiveBytes {
+		return fmt.Errorf("Archiv ist größer als %d MB", maxArchiveBytes>>20)
+	}
+
+	// Check the archive before anything is extracted
+	if m != nil {
+		err = checkArchive(m, size, h.Sum(nil))
+	} else {
+		err = checkNightly(r, size, h.Sum(nil))
+	}
+	if err != nil {
+		return err
+	}
+	cl.logger.Printf("Archive checksum verified\n")
+
+	cl.updateProgress(50, "Extrahiere Dateien...")
+
+	// Extract next to the installation and only move the complete tree
+	// into place
+	cl.removeStaleStaging()
+	staging, err := os.MkdirTemp(cl.baseDir, stagingPrefix)
+	if err != nil {
+		return fmt.Errorf("Kann Arbeitsverzeichnis nicht erstellen: %v", err)
+	}
+	defer os.RemoveAll(staging)
+
+	err = cl.extractZip(tempZip.Name(), staging)
+	if err != nil {
+		return fmt.Errorf("Extraktion fehlgeschlagen: %v", err)
+	}
+
+	// Keep the release next to the others; activate installs it
+	if err := cl.storeVersion(staging, r.TagName); err != nil {
+		return fmt.Errorf("Kann Version nicht speichern: %v", err)
+	}
+
+	cl.updateProgress(60, "Repository erfolgreich heruntergeladen")
+	return nil
+}
+
+// checkNodeJS chooses the Node.js for the app in appDir the way the local
+// launchers do: node.path from launcher.json if it exists, otherwise the
+// newest installation in the known locations, including the provisioned
+// runtime, that satisfies the app's engines range.
+func (cl *CloudLauncher) checkNodeJS(appDir string) (string, error) {
+	cl.updateProgress(75, "Prüfe Node.js Installation...")
+
+	configured := cl.settings.Node.Path
+	nodePath, c, err := launcher.FindNode(appDir, cl.settings.Node)
+	if configured != "" && nodePath != configured {
+		cl.logger.Printf("Node.js from %s not found: %s\n", launcher.SettingsFile, configured)
+		cl.events.Publish(events.TypeWarning, events.Message{
+			Message: fmt.Sprintf("Node.js aus %s nicht gefunden: %s", launcher.SettingsFile, configured),
+		})
+	}
+	if err != nil {
+		return "", &installError{
+			Message: err.Error(),
+			Code:    "NODE_NOT_FOUND",
+			Hints:   []string{"Bitte installiere Node.js von https://nodejs.org", c.Recommendation()},
+		}
+	}
+	cl.logger.Printf("Using Node.js %s at %s: %v (%s)\n", c.Version, nodePath, c.Support, c.Reason)
+
+	switch c.Support {
+	case launcher.NodeTooOld:
+		return "", &installError{
+			Message: c.Reason,
+			Code:    "NODE_TOO_OLD",
+			Hints:   []string{"Bitte installiere eine passende Node.js LTS Version von https://nodejs.org", c.Recommendation()},
+		}
+	case launcher.NodeTooNew, launcher.NodeUntested:
+		cl.events.Publish(events.TypeWarning, events.Message{Message: c.Reason})
+	}
+	return nodePath, nil
+}
+
+// installDependencies runs the dependency phases of the local launchers:
+// node_modules is only touched if its fingerprint is out of date, restored
+// from a snapshot where possible, and npm's progress and classified
+// failures are shown on the splash.
+func (cl *CloudLauncher) installDependencies(nodePath, appDir string) error {
+	cl.updateProgress(80, "Prüfe Abhängigkeiten...")
+
+	l := cl.newLauncher(appDir, nodePath, "Abhängigkeiten", 80, 90)
+	defer l.Close()
+	if err := l.PrepareDependencies(); err != nil {
+		if path := l.LogPath(); path != "" {
+			cl.logger.Printf("Dependency installation failed, see %s\n", path)
+		}
+		return err
+	}
+	return nil
+}
+
+// provisionNode downloads the Node.js release pinned in launcher.json when
+// none is configured or installed that satisfies the app, the way the
+// local launchers' runtime phase does.
+func (cl *CloudLauncher) provisionNode(appDir string) error {
+	l := cl.newLauncher(appDir, "", "Node.js", 70, 75)
+	defer l.Close()
+	if err := l.ProvisionNode(); err != nil {
+		if path := l.LogPath(); path != "" {
+			cl.logger.Printf("Node.js provisioning failed, see %s\n", path)
+		}
+		return err
+	}
+	return nil
+}
+
+// newLauncher returns the shared launcher engine for the app in appDir. It
+// logs to the app's logs folder and shows its progress between from and to
+// on the splash. The caller closes it.
+func (cl *CloudLauncher) newLauncher(appDir, nodePath, title string, from, to int) *launcher.Launcher {
+	l := launcher.New(launcher.Config{
+		AppDir:   appDir,
+		LogTitle: "LTTH Cloud Launcher - " + title,
+		Console:  os.Stdout,
+		Stdin:    os.Stdin,
+		Settings: cl.settings,
+		NodePath: nodePath,
+	}, &phaseSink{cl: cl, from: from, to: to})
+	if err := l.SetupLogging(); err != nil {
+		cl.logger.Printf("Cannot create the launcher log: %v\n", err)
+	}
+	return l
+}
+
+// phaseSink shows the progress of launcher phases between from and to on
+// the splash and forwards their events to it.
+type phaseSink struct {
+	cl       *CloudLauncher
+	from, to int
+}
+
+func (s *phaseSink) Progress(value int, status string) {
+	s.cl.updateProgress(s.from+(s.to-s.from)*value/100, status)
+}
+
+func (s *phaseSink) Redirect(string) {}
+
+func (s *phaseSink) Event(typ events.Type, data interface{}) {
+	s.cl.events.Publish(typ, data)
+}
+
+// appPort picks the port like the local launcher: PORT from app/.env
+// (default 3000), or the next free port if that one is taken.
+func (cl *CloudLauncher) appPort(appDir string) (int, error) {
+	preferred, err := launcher.ConfiguredPort(appDir)
+	if err != nil {
+		cl.logger.Printf("Ignoring %v\n", err)
+	}
+	if launcher.PortAvailable(preferred) {
+		return preferred, nil
+	}
+	port := launcher.FreePort(preferred)
+	if port == 0 {
+		return 0, fmt.Errorf("Port %d und die folgenden Ports sind belegt. Beende das Programm, das ihn nutzt, oder setze PORT in app/.env", preferred)
+	}
+	cl.logger.Printf("Port %d is in use, starting on port %d instead\n", preferred, port)
+	cl.events.Publish(events.TypeWarning, events.Message{
+		Message: fmt.Sprintf("Port %d belegt - Server nutzt Port %d", preferred, port),
+	})
+	return port, nil
+}
+
+// Start the application and wait until it is ready
+func (cl *CloudLauncher) startApplication(nodePath, appDir string) (*appProcess, error) {
+	cl.updateProgress(90, "Starte Anwendung...")
+
+	port, err := cl.appPort(appDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The engine starts launch.js as the local launchers do: with the
+	// Node.js checked above first on PATH, PORT and the token that lets
+	// Shutdown stop it
+	l := cl.newLauncher(appDir, nodePath, "Start", 90, 99)
+	cl.logger.Printf("Starting application: %s %s\n", nodePath, filepath.Join(appDir, "launch.js"))
+	srv, err := l.StartServer(port)
+	if err != nil {
+		l.Close()
+		return nil, fmt.Errorf("Start fehlgeschlagen: %v", err)
+	}
+	app := &appProcess{l: l, port: port, exited: make(chan struct{})}
+	go func() {
+		app.err = <-srv.Done
+		close(app.exited)
+	}()
+	cl.setApp(app)
+
+	// A version counts as working once it is usable; one that listens but
+	// is slow to initialise is kept as well
+	timeout := time.Duration(cl.update.ReadySeconds) * time.Second
+	last := -1
+	err = launcher.WaitReady(port, timeout, app.exited, func(s launcher.InitState) {
+		if value := 90 + int(9*s.Fraction()); value != last {
+			last = value
+			cl.updateProgress(value, "Anwendung initialisiert...")
+		}
+	})
+	if err != nil && cl.startedAnyway(app) {
+		cl.logger.Printf("Application on port %d is running but not fully initialised: %v\n", port, err)
+		cl.events.Publish(events.TypeWarning, events.Message{
+			Message: "Anwendung läuft, ist aber noch nicht vollständig initialisiert",
+		})
+		return app, nil
+	}
+	if err != nil {
+		app.stop()
+		cl.setApp(nil)
+		return nil, &startError{err}
+	}
+	cl.logger.Printf("Application is ready on port %d\n", port)
+	return app, nil
+}
+
+func (cl *CloudLauncher) setApp(app *appProcess) {
+	cl.appMu.Lock()
+	defer cl.appMu.Unlock()
+	cl.app = app
+}
+
+// handleSignals shuts the running application down and exits when ltthgit
+// receives SIGINT or SIGTERM (Ctrl+C or closing the console window). The
+// application runs in a process group of its own and would not get them.
+func (cl *CloudLauncher) handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		cl.logger.Printf("Received %v\n", sig)
+		cl.updateProgress(100, "Launcher wird beendet...")
+		cl.appMu.Lock()
+		app := cl.app
+		cl.appMu.Unlock()
+		if app != nil {
+			app.stop()
+		}
+		// An update in progress is finished or undone on the next start
+		os.Exit(130)
+	}()
+}
+
+// startedAnyway reports whether app, which did not become ready in time,
+// still runs and listens. Such a version is slow, not broken, and must not
+// be rolled back.
+func (cl *CloudLauncher) startedAnyway(app *appProcess) bool {
+	select {
+	case <-app.exited:
+		return false
+	default:
+	}
+	return launcher.ServerStarted(app.port)
+}
+
+// Prepare and start the installed version
+func (cl *CloudLauncher) launch(appDir string) (*appProcess, error) {
+	if err := cl.provisionNode(appDir); err != nil {
+		return nil, err
+	}
+	nodePath, err := cl.checkNodeJS(appDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := cl.installDependencies(nodePath, appDir); err != nil {
+		return nil, err
+	}
+	return cl.startApplication(nodePath, appDir)
+}
+
+func (cl *CloudLauncher) run() error {
+	// Get executable directory
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("Kann Programmverzeichnis nicht ermitteln: %v", err)
+	}
+
+	cl.baseDir = filepath.Dir(exePath)
+	cl.logger.Printf("Base directory: %s\n", cl.baseDir)
+	cl.handleSignals()
+
+	// Start HTTP server in background
+	http.HandleFunc("/", cl.serveSplash)
+	http.Handle("/events", cl.events)
+
+	go func() {
+		cl.logger.Println("Starting web server on :8765")
+		if err := http.ListenAndServe(":8765", nil); err != nil {
+			cl.logger.Printf("HTTP server error: %v\n", err)
+		}
+	}()
+
+	// Wait a moment for server to start
+	time.Sleep(500 * time.Millisecond)
+
+	// Open browser to splash screen
+	err = browser.OpenURL("http://localhost:8765")
+	if err != nil {
+		cl.logger.Printf("Failed to open browser: %v\n", err)
+	}
+
+	// Pick the version to install
+	if err := cl.loadUpdateSettings(); err != nil {
+		cl.sendError(err)
+		return err
+	}
+	cl.loadVersions()
+	// An interrupted update is finished or undone before anything looks
+	// at the installation
+	if err := cl.recoverUpdate(); err != nil {
+		cl.sendError(err)
+		return err
+	}
+
+	if cl.rollbackCmd {
+		err = cl.manualRollback()
+	} else {
+		err = cl.installLatest()
+	}
+	if err != nil {
+		cl.sendError(err)
+		return err
+	}
+
+	// Start application; a new version that does not come up is
+	// replaced by the last one that did
+	appDir := filepath.Join(cl.baseDir, "app")
+	app, err := cl.launch(appDir)
+	if err != nil {
+		app, err = cl.recoverStart(appDir, err)
+	}
+	if err != nil {
+		cl.sendError(err)
+		return err
+	}
+	cl.markGood()
+
+	cl.updateProgress(100, "Anwendung gestartet!")
+	cl.events.Publish(events.TypeDone, events.Done{OK: true})
+
+	// Open browser to the app
+	browser.OpenURL(fmt.Sprintf("http://localhost:%d", app.port))
+
+	// Wait for the application to finish
+	<-app.exited
+	app.l.Close()
+	return app.err
+}
+
+func main() {
+	channel := flag.String("channel", "", "Update-Kanal: stable, beta oder nightly (überschreibt launcher.json)")
+	version := flag.String("version", "", "Bestimmte Version installieren, z.B. v1.2.0")
+	retryFailed := flag.Bool("retry-failed", false, "Versionen, die nicht gestartet sind, erneut versuchen")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Verwendung: ltthgit [Optionen] [rollback [version]]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	fmt.Println("================================================")
+	fmt.Println("  LTTH Cloud Launcher")
+	fmt.Println("  https://github.com/Loggableim/pupcidslittletiktokhelper")
+	fmt.Println("================================================")
+	fmt.Println()
+
+	cl := NewCloudLauncher()
+	cl.channel = *channel
+	cl.version = *version
+	cl.retryFailed = *retryFailed
+	switch flag.Arg(0) {
+	case "":
+	case "rollback":
+		cl.rollbackCmd = true
+		cl.rollbackTo = flag.Arg(1)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := cl.run(); err != nil {
+		fmt.Fprintf(os.Stderr, "\nERROR: %v\n", err)
+		fmt.Println("\nPress Enter to exit...")
+		fmt.Scanln()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+)
+
+func TestStartApplicationStopsUnreadyApp(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake node is a shell script")
+	}
+	// Never listens, and records its environment and the interrupt
+	script := `#!/bin/sh
+dir=$(dirname "$1")
+trap 'echo interrupted >> "$dir/env.txt"; exit 0' INT
+echo "token=$LTTH_SHUTDOWN_TOKEN port=$PORT" > "$dir/env.txt"
+while :; do sleep 0.1; done
+`
+	node := filepath.Join(t.TempDir(), "node")
+	if err := os.WriteFile(node, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	appDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(appDir, ".env"), []byte(fmt.Sprintf("PORT=%d\n", port)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cl := testLauncher(t)
+	cl.events = events.NewStream(events.DefaultReplaySize, "")
+	cl.update.ReadySeconds = 1
+	app, err := cl.startApplication(node, appDir)
+	var failed *startError
+	if !errors.As(err, &failed) {
+		t.Fatalf("startApplication = %v, %v; want a startError", app, err)
+	}
+	if cl.app != nil {
+		t.Error("the stopped app is still the one a signal would stop")
+	}
+
+	// The app got the token and was interrupted, not killed
+	data, err := os.ReadFile(filepath.Join(appDir, "env.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || lines[1] != "interrupted" {
+		t.Errorf("app wrote %q, want its environment and \"interrupted\"", data)
+	}
+	if want := fmt.Sprintf(" port=%d", port); !strings.HasPrefix(lines[0], "token=") || len(lines[0]) == len("token=")+len(want) || !strings.HasSuffix(lines[0], want) {
+		t.Errorf("app environment %q, want a shutdown token and PORT=%d", lines[0], port)
+	}
+}
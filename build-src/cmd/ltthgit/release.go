@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/launcher"
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/semver"
+)
+
+// release is a release in the format of the GitHub REST API.
+type release struct {
+	TagName     string  `json:"tag_name"`
+	Name        string  `json:"name"`
+	Body        string  `json:"body"`
+	Draft       bool    `json:"draft"`
+	Prerelease  bool    `json:"prerelease"`
+	PublishedAt string  `json:"published_at"`
+	HTMLURL     string  `json:"html_url"`
+	Assets      []asset `json:"assets"`
+	// nightly releases are built from the branch head and not signed;
+	// archive is checked against sha256 from the release's SHA256SUMS
+	nightly bool
+	archive *asset
+	sha256  string
+}
+
+// asset is a file attached to a release.
+type asset struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// asset returns the asset called name, or nil.
+func (r *release) asset(name string) *asset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+var errNotFound = errors.New("nicht gefunden")
+
+var apiClient = &http.Client{Timeout: 30 * time.Second}
+
+// downloadClient fetches release archives; its timeout covers the whole
+// download on a slow connection.
+var downloadClient = &http.Client{Timeout: 30 * time.Minute}
+
+// apiURL returns the URL of path below the configured API.
+func (cl *CloudLauncher) apiURL(path string) string {
+	return strings.TrimRight(cl.update.APIBaseURL, "/") + path
+}
+
+// apiGet decodes the JSON at path below the API into v.
+func (cl *CloudLauncher) apiGet(path string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, cl.apiURL(path), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "ltthgit")
+
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(v)
+	case http.StatusNotFound:
+		return errNotFound
+	}
+	return fmt.Errorf("%s: HTTP %d", req.URL, resp.StatusCode)
+}
+
+// resolveRelease picks the release to install: the pinned version if
+// there is one, otherwise the newest release of the channel that is not on
+// the skip list.
+func (cl *CloudLauncher) resolveRelease() (*release, error) {
+	u := cl.update
+	repo := fmt.Sprintf("/repos/%s/%s", repoOwner, repoName)
+
+	if u.Version != "" {
+		if u.Skipped(u.Version) {
+			return nil, fmt.Errorf("Version %s ist angeheftet, steht aber auch auf der Skip-Liste", u.Version)
+		}
+		var r release
+		if err := cl.apiGet(repo+"/releases/tags/"+url.PathEscape(u.Version), &r); err != nil {
+			return nil, fmt.Errorf("Version %s: %v", u.Version, err)
+		}
+		return &r, nil
+	}
+
+	if u.Channel == launcher.ChannelNightly {
+		var r release
+		if err := cl.apiGet(repo+"/releases/tags/"+nightlyTag, &r); err != nil {
+			return nil, fmt.Errorf("Nightly-Release: %v", err)
+		}
+		if err := nightlyArchive(&r); err != nil {
+			return nil, err
+		}
+		if u.Skipped(r.TagName) {
+			return nil, fmt.Errorf("Die aktuelle Nightly-Version (%s) steht auf der Skip-Liste", r.TagName)
+		}
+		return &r, nil
+	}
+
+	var releases []release
+	if err := cl.apiGet(repo+"/releases?per_page=100", &releases); err != nil {
+		return nil, fmt.Errorf("Releases: %v", err)
+	}
+	return cl.pickRelease(releases)
+}
+
+// pickRelease returns the release with the highest version among those the
+// channel allows. Tags that are not semantic versions are ignored.
+func (cl *CloudLauncher) pickRelease(releases []release) (*release, error) {
+	var best *release
+	var bestVersion semver.Version
+	for i := range releases {
+		r := &releases[i]
+		if r.Draft || (r.Prerelease && cl.update.Channel != launcher.ChannelBeta) {
+			continue
+		}
+		if cl.update.Skipped(r.TagName) {
+			cl.logger.Printf("Skipping release %s (skip list)\n", r.TagName)
+			continue
+		}
+		v, err := semver.Parse(r.TagName)
+		if err != nil {
+			cl.logger.Printf("Ignoring release %s: %v\n", r.TagName, err)
+			continue
+		}
+		if best == nil || bestVersion.Less(v) {
+			best, bestVersion = r, v
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("Keine passende Version im Kanal %s gefunden", cl.update.Channel)
+	}
+	return best, nil
+}
+
+// announceRelease shows the version about to be installed and its release
+// notes on the splash.
+func (cl *CloudLauncher) announceRelease(r *release) {
+	channel := string(cl.update.Channel)
+	if cl.update.Version != "" {
+		channel = "pinned"
+	}
+	cl.logger.Printf("Installing %s (%s)\n", r.TagName, channel)
+	cl.events.Publish(events.TypeRelease, events.Release{
+		Tag:       r.TagName,
+		Name:      r.Name,
+		Channel:   channel,
+		Published: r.PublishedAt,
+		Notes:     strings.TrimSpace(r.Body),
+		URL:       r.HTMLURL,
+	})
+}
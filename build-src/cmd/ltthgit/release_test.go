@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/launcher"
+)
+
+func TestPickRelease(t *testing.T) {
+	// In the order GitHub lists them: by creation date, not by version
+	releases := []release{
+		{TagName: "v1.3.0-beta.2", Prerelease: true},
+		{TagName: "v1.2.1"},
+		{TagName: "v1.10.0", Draft: true},
+		{TagName: "nightly", Prerelease: true},
+		{TagName: "v1.2.10"},
+		{TagName: "v1.3.0-beta.10", Prerelease: true},
+		{TagName: "v1.2.9"},
+		{TagName: "release-2024"},
+	}
+
+	tests := []struct {
+		name    string
+		channel launcher.UpdateChannel
+		skip    []string
+		want    string
+	}{
+		{"stable ignores prereleases and drafts", launcher.ChannelStable, nil, "v1.2.10"},
+		{"semver order, not string order", launcher.ChannelStable, []string{"v1.2.10"}, "v1.2.9"},
+		{"beta includes prereleases", launcher.ChannelBeta, nil, "v1.3.0-beta.10"},
+		{"beta skip", launcher.ChannelBeta, []string{"v1.3.0-beta.10"}, "v1.3.0-beta.2"},
+		{"skip all prereleases", launcher.ChannelBeta, []string{"v1.3.0-beta.10", "v1.3.0-beta.2"}, "v1.2.10"},
+		{"skip everything", launcher.ChannelStable, []string{"v1.2.10", "v1.2.9", "v1.2.1"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := testLauncher(t)
+			cl.update = launcher.UpdateSettings{Channel: tt.channel, Skip: tt.skip}
+			r, err := cl.pickRelease(releases)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("pickRelease = %s, want an error", r.TagName)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.TagName != tt.want {
+				t.Errorf("pickRelease = %s, want %s", r.TagName, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/launcher"
+)
+
+const (
+	// releaseRecordFile lists the files of the installed release, so that
+	// the next update can tell files removed upstream and local edits
+	// apart.
+	releaseRecordFile = ".ltth-release.json"
+	// newSuffix is appended to the new version of a file that was edited
+	// locally; the edited file itself is kept.
+	newSuffix = ".ltth-new"
+)
+
+// defaultProtected are left alone by every update, in addition to the
+// app's updatePolicy. They are relative to the installation.
+var defaultProtected = []string{
+	".ltth-*",
+	"launcher.json",
+	"runtime/",
+	"cache/",
+	versionsDir + "/",
+}
+
+// releaseRecord is the content of releaseRecordFile.
+type releaseRecord struct {
+	Tag       string    `json:"tag"`
+	Installed time.Time `json:"installed"`
+	// Files maps the slash-separated paths the release shipped to their
+	// SHA-256, or to "link:<target>" for symbolic links.
+	Files map[string]string `json:"files"`
+}
+
+// readReleaseRecord returns the record of the installed release, or nil if
+// there is none, e.g. before the first install.
+func (cl *CloudLauncher) readReleaseRecord() *releaseRecord {
+	data, err := os.ReadFile(filepath.Join(cl.baseDir, releaseRecordFile))
+	if err != nil {
+		return nil
+	}
+	var r releaseRecord
+	if err := json.Unmarshal(data, &r); err != nil || r.Files == nil {
+		cl.logger.Printf("Ignoring invalid %s: %v\n", releaseRecordFile, err)
+		return nil
+	}
+	return &r
+}
+
+func (r *releaseRecord) write(baseDir string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(baseDir, releaseRecordFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(baseDir, releaseRecordFile))
+}
+
+// errDirectory is returned by hashFile for a directory.
+var errDirectory = errors.New("ist ein Verzeichnis")
+
+// hashFile returns the SHA-256 of a file, "link:<target>" for a symbolic
+// link, or "" if p does not exist.
+func hashFile(p string) (string, error) {
+	info, err := os.Lstat(p)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(p)
+		return "link:" + filepath.ToSlash(target), err
+	case info.IsDir():
+		return "", fmt.Errorf("%s: %w", p, errDirectory)
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashTree hashes every file below dir.
+func hashTree(dir string) (map[string]string, error) {
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		sum, err := hashFile(p)
+		files[filepath.ToSlash(rel)] = sum
+		return err
+	})
+	return files, err
+}
+
+// protection matches paths against protected patterns.
+type protection []string
+
+func (p protection) matches(rel string) bool {
+	for _, pattern := range p {
+		if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+			if rel == dir || strings.HasPrefix(rel, dir+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		// A pattern for a name also covers what is below it
+		if first, _, found := strings.Cut(rel, "/"); found {
+			if ok, _ := path.Match(pattern, first); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// protectedPaths combines defaultProtected, the running executable and the
+// updatePolicy of both the installed and the new app.
+func (cl *CloudLauncher) protectedPaths(release string) protection {
+	p := protection(append([]string(nil), defaultProtected...))
+	if exe, err := os.Executable(); err == nil && filepath.Dir(exe) == cl.baseDir {
+		p = append(p, filepath.Base(exe))
+	}
+	for _, root := range []string{cl.baseDir, release} {
+		paths, err := launcher.ProtectedPaths(filepath.Join(root, "app"))
+		if err != nil {
+			continue
+		}
+		for _, rel := range paths {
+			p = append(p, "app/"+strings.TrimPrefix(rel, "/"))
+		}
+	}
+	return p
+}
+
+// keptFile is a file an update did not touch.
+type keptFile struct {
+	Path   string
+	Reason string
+}
+
+// updateSummary is what installRelease did.
+type updateSummary struct {
+	Added, Changed, Removed []string
+	Kept                    []keptFile
+}
+
+func (s updateSummary) String() string {
+	return fmt.Sprintf("%d neu, %d geändert, %d entfernt, %d beibehalten", len(s.Added), len(s.Changed), len(s.Removed), len(s.Kept))
+}
+
+// logSummary prints the summary and every kept file.
+func (cl *CloudLauncher) logSummary(tag string, s updateSummary) {
+	cl.logger.Printf("Update to %s: %d added, %d changed, %d removed, %d kept\n", tag, len(s.Added), len(s.Changed), len(s.Removed), len(s.Kept))
+	for _, k := range s.Kept {
+		cl.logger.Printf("  kept %s (%s)\n", k.Path, k.Reason)
+	}
+}
+
+// copyFile copies the file or symbolic link src to dst, which must not
+// exist.
+func copyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// installRelease installs the release tree in dir (a version below
+// versionsDir) over cl.baseDir, file by file:
+//   - protected paths (user data, secrets, node_modules) are never
+//     overwritten or removed
+//   - files edited since the last update are kept; the new version is put
+//     next to them with newSuffix, as it is next to a directory in the way
+//   - files the last release shipped but this one does not are removed,
+//     unless they were edited; this happens first, so that a directory
+//     can become a file and the other way round
+//
+// Everything it replaces or removes goes to previousDir. Every step is
+// logged in updateJournalFile first: if a step fails, or the launcher is
+// interrupted and recoverUpdate runs on the next start, all changes are
+// undone and the installation is left as it was. Writing the release record
+// completes the update. dir itself is not modified.
+func (cl *CloudLauncher) installRelease(dir, tag string) (summary updateSummary, err error) {
+	if err := cl.recoverUpdate(); err != nil {
+		return summary, err
+	}
+	files, err := hashTree(dir)
+	if err != nil {
+		return summary, err
+	}
+	last := cl.readReleaseRecord()
+	protected := cl.protectedPaths(dir)
+
+	// The last backup is only replaced once the update is complete. One
+	// without a journal is left over from an older launcher.
+	backup := filepath.Join(cl.baseDir, previousDir+".new")
+	os.RemoveAll(backup)
+
+	record := &releaseRecord{Tag: tag, Installed: time.Now().UTC(), Files: files}
+	j, err := beginJournal(cl.baseDir, record)
+	if err != nil {
+		return summary, fmt.Errorf("%s: %v", updateJournalFile, err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if uerr := j.undo(); uerr != nil {
+			cl.logger.Printf("Undoing the update failed, trying again on the next start: %v\n", uerr)
+			return
+		}
+		os.RemoveAll(backup)
+	}()
+
+	move := func(from, rel string) error {
+		to := filepath.Join(backup, filepath.FromSlash(rel))
+		if err := j.log(opMove, from, to); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			return err
+		}
+		return os.Rename(from, to)
+	}
+	copyIn := func(src, dst string) error {
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := j.log(opCreate, "", dst); err != nil {
+			return err
+		}
+		return copyFile(src, dst)
+	}
+	// beside puts the new version next to dst; one from an earlier update
+	// goes to the backup
+	beside := func(src, dst, rel string) error {
+		if _, err := os.Lstat(dst + newSuffix); err == nil {
+			if err := move(dst+newSuffix, rel+newSuffix); err != nil {
+				return err
+			}
+		}
+		return copyIn(src, dst+newSuffix)
+	}
+
+	if last != nil {
+		var gone []string
+		for rel := range last.Files {
+			if _, ok := files[rel]; !ok && !protected.matches(rel) {
+				gone = append(gone, rel)
+			}
+		}
+		sort.Strings(gone)
+		var emptied []string
+		for _, rel := range gone {
+			dst := filepath.Join(cl.baseDir, filepath.FromSlash(rel))
+			live, err := hashFile(dst)
+			switch {
+			case errors.Is(err, errDirectory):
+				summary.Kept = append(summary.Kept, keptFile{rel, "lokal durch ein Verzeichnis ersetzt"})
+				continue
+			case err != nil:
+				return summary, err
+			case live == "":
+				continue
+			case live != last.Files[rel]:
+				summary.Kept = append(summary.Kept, keptFile{rel, "lokal geändert, im neuen Release entfernt"})
+				continue
+			}
+			if err := move(dst, rel); err != nil {
+				return summary, err
+			}
+			summary.Removed = append(summary.Removed, rel)
+			emptied = append(emptied, filepath.Dir(dst))
+		}
+
+		// Directories that only held removed files
+		for _, parent := range emptied {
+			for ; parent != cl.baseDir && within(cl.baseDir, parent); parent = filepath.Dir(parent) {
+				if entries, err := os.ReadDir(parent); err != nil || len(entries) > 0 {
+					break
+				}
+				if err := j.log(opRmdir, "", parent); err != nil {
+					return summary, err
+				}
+				if os.Remove(parent) != nil {
+					break
+				}
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for rel := range files {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+
+	for _, rel := range paths {
+		if first, _, _ := strings.Cut(rel, "/"); strings.HasPrefix(first, ".ltth-") {
+			cl.logger.Printf("Ignoring reserved name %s in archive\n", rel)
+			delete(files, rel)
+			continue
+		}
+		src := filepath.Join(dir, filepath.FromSlash(rel))
+		dst := filepath.Join(cl.baseDir, filepath.FromSlash(rel))
+		if blocker := fileInPath(cl.baseDir, dst); blocker != "" {
+			// A local file where the release has a directory
+			name, _ := filepath.Rel(cl.baseDir, blocker)
+			summary.Kept = append(summary.Kept, keptFile{rel, "nicht installiert, " + filepath.ToSlash(name) + " ist eine Datei"})
+			continue
+		}
+		live, err := hashFile(dst)
+		inTheWay := errors.Is(err, errDirectory)
+		if err != nil && !inTheWay {
+			return summary, err
+		}
+
+		switch {
+		case inTheWay && protected.matches(rel):
+			summary.Kept = append(summary.Kept, keptFile{rel, "geschützt"})
+		case inTheWay:
+			err = beside(src, dst, rel)
+			summary.Kept = append(summary.Kept, keptFile{rel, "Verzeichnis im Weg, neue Version als " + path.Base(rel) + newSuffix})
+		case live == "":
+			err = copyIn(src, dst)
+			summary.Added = append(summary.Added, rel)
+		case live == files[rel]:
+			// Unchanged
+		case protected.matches(rel):
+			summary.Kept = append(summary.Kept, keptFile{rel, "geschützt"})
+		case last == nil || last.Files[rel] == live:
+			if err = move(dst, rel); err == nil {
+				err = copyIn(src, dst)
+			}
+			summary.Changed = append(summary.Changed, rel)
+		default:
+			// Edited locally, or created by the user before the release
+			// shipped it
+			err = beside(src, dst, rel)
+			summary.Kept = append(summary.Kept, keptFile{rel, "lokal geändert, neue Version als " + path.Base(rel) + newSuffix})
+		}
+		if err != nil {
+			return summary, err
+		}
+	}
+
+	if err := record.write(cl.baseDir); err != nil {
+		return summary, fmt.Errorf("%s: %v", releaseRecordFile, err)
+	}
+
+	// The update is complete
+	cl.keepBackup(backup)
+	if err := j.finish(); err != nil {
+		cl.logger.Printf("Could not remove %s: %v\n", updateJournalFile, err)
+	}
+	return summary, nil
+}
+
+// fileInPath returns the first directory between dir and target that is a
+// file, or "" if there is none.
+func fileInPath(dir, target string) string {
+	var parents []string
+	for p := filepath.Dir(target); p != dir && within(dir, p); p = filepath.Dir(p) {
+		parents = append(parents, p)
+	}
+	for i := len(parents) - 1; i >= 0; i-- {
+		info, err := os.Stat(parents[i])
+		if err != nil {
+			return ""
+		}
+		if !info.IsDir() {
+			return parents[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+const testPackageJSON = `{"name": "app", "updatePolicy": {"protected": [".env", "user_data/"]}}`
+
+// writeTree creates the files in files below dir.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, dir, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return "<missing>"
+	}
+	return string(data)
+}
+
+func keptPaths(kept []keptFile) []string {
+	var paths []string
+	for _, k := range kept {
+		paths = append(paths, k.Path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// installV1 installs a first release and edits it like a user would.
+func installV1(t *testing.T, cl *CloudLauncher) {
+	t.Helper()
+	v1 := t.TempDir()
+	writeTree(t, v1, map[string]string{
+		"app/package.json":     testPackageJSON,
+		"app/.env":             "PORT=3000",
+		"app/a.js":             "a1",
+		"app/edit.js":          "e1",
+		"app/gone.js":          "g1",
+		"app/gone-edited.js":   "ge1",
+		"app/dir/only.js":      "o1",
+		"app/user_data/db.txt": "shipped",
+	})
+	summary, err := cl.installRelease(v1, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Added) != 8 || len(summary.Changed)+len(summary.Removed)+len(summary.Kept) != 0 {
+		t.Fatalf("first install: %+v", summary)
+	}
+
+	writeTree(t, cl.baseDir, map[string]string{
+		"app/.env":             "PORT=4000",
+		"app/edit.js":          "mine",
+		"app/gone-edited.js":   "mine too",
+		"app/user_data/db.txt": "user data",
+		"app/notes.txt":        "never shipped",
+	})
+}
+
+func v2Release(t *testing.T) string {
+	t.Helper()
+	v2 := t.TempDir()
+	writeTree(t, v2, map[string]string{
+		"app/package.json":     testPackageJSON,
+		"app/.env":             "PORT=3001",
+		"app/a.js":             "a2",
+		"app/edit.js":          "e2",
+		"app/new.js":           "n2",
+		"app/user_data/db.txt": "shipped again",
+	})
+	return v2
+}
+
+func TestInstallRelease(t *testing.T) {
+	cl := testLauncher(t)
+	base := cl.baseDir
+	installV1(t, cl)
+
+	summary, err := cl.installRelease(v2Release(t), "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"app/new.js"}; !reflect.DeepEqual(summary.Added, want) {
+		t.Errorf("Added = %v, want %v", summary.Added, want)
+	}
+	if want := []string{"app/a.js"}; !reflect.DeepEqual(summary.Changed, want) {
+		t.Errorf("Changed = %v, want %v", summary.Changed, want)
+	}
+	if want := []string{"app/dir/only.js", "app/gone.js"}; !reflect.DeepEqual(summary.Removed, want) {
+		t.Errorf("Removed = %v, want %v", summary.Removed, want)
+	}
+	if want := []string{"app/.env", "app/edit.js", "app/gone-edited.js", "app/user_data/db.txt"}; !reflect.DeepEqual(keptPaths(summary.Kept), want) {
+		t.Errorf("Kept = %v, want %v", keptPaths(summary.Kept), want)
+	}
+
+	for rel, want := range map[string]string{
+		"app/a.js":                "a2",
+		"app/new.js":              "n2",
+		"app/.env":                "PORT=4000",
+		"app/user_data/db.txt":    "user data",
+		"app/edit.js":             "mine",
+		"app/edit.js" + newSuffix: "e2",
+		"app/gone-edited.js":      "mine too",
+		"app/notes.txt":           "never shipped",
+		"app/gone.js":             "<missing>",
+		"app/dir/only.js":         "<missing>",
+		// Protected files get no .ltth-new copy
+		"app/.env" + newSuffix: "<missing>",
+		// Replaced and removed files are kept as backup
+		previousDir + "/app/a.js":    "a1",
+		previousDir + "/app/gone.js": "g1",
+	} {
+		if got := readFile(t, base, rel); got != want {
+			t.Errorf("%s = %q, want %q", rel, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(base, "app", "dir")); !os.IsNotExist(err) {
+		t.Errorf("emptied directory app/dir was not removed: %v", err)
+	}
+
+	record := cl.readReleaseRecord()
+	if record == nil || record.Tag != "v2" || len(record.Files) != 6 {
+		t.Fatalf("release record = %+v", record)
+	}
+	if _, ok := record.Files["app/gone.js"]; ok {
+		t.Error("record still lists a removed file")
+	}
+}
+
+func TestInstallReleaseUndo(t *testing.T) {
+	cl := testLauncher(t)
+	base := cl.baseDir
+	installV1(t, cl)
+	before, err := hashTree(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The release record is written after every file was changed; a
+	// directory in the way of its temp file makes that last step fail
+	if err := os.Mkdir(filepath.Join(base, releaseRecordFile+".tmp"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.installRelease(v2Release(t), "v2"); err == nil {
+		t.Fatal("installRelease succeeded, want an error")
+	}
+
+	after, err := hashTree(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("installation changed by a failed update:\nbefore %v\nafter  %v", before, after)
+	}
+	for _, name := range []string{previousDir + ".new", updateJournalFile} {
+		if _, err := os.Stat(filepath.Join(base, name)); !os.IsNotExist(err) {
+			t.Errorf("%s of the failed update left behind: %v", name, err)
+		}
+	}
+}
+
+// interruptUpdate leaves the installation the way an update to v2 killed
+// halfway through does: a.js replaced and the creation of new.js logged
+// but not carried out, with the last journal line cut off.
+func interruptUpdate(t *testing.T, cl *CloudLauncher) {
+	t.Helper()
+	base := cl.baseDir
+	j, err := beginJournal(base, &releaseRecord{Tag: "v2", Installed: time.Now().UTC()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := filepath.Join(base, "app", "a.js")
+	saved := filepath.Join(base, previousDir+".new", "app", "a.js")
+	if err := j.log(opMove, a, saved); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(saved), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(a, saved); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.log(opCreate, "", a); err != nil {
+		t.Fatal(err)
+	}
+	writeTree(t, base, map[string]string{"app/a.js": "a2"})
+	if err := j.log(opCreate, "", filepath.Join(base, "app", "new.js")); err != nil {
+		t.Fatal(err)
+	}
+	j.f.WriteString(`{"op":"move","from":"app/edit.js","to":`)
+	j.f.Close()
+}
+
+func TestRecoverInterruptedUpdate(t *testing.T) {
+	cl := testLauncher(t)
+	base := cl.baseDir
+	installV1(t, cl)
+	// The backup of the update before must survive the interrupted one
+	writeTree(t, base, map[string]string{previousDir + "/app/a.js": "a0"})
+	before, err := hashTree(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interruptUpdate(t, cl)
+	if err := cl.recoverUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	after, err := hashTree(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("interrupted update not undone:\nbefore %v\nafter  %v", before, after)
+	}
+
+	// The next update starts from the recovered installation
+	interruptUpdate(t, cl)
+	if _, err := cl.installRelease(v2Release(t), "v2"); err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range map[string]string{
+		"app/a.js":                "a2",
+		"app/new.js":              "n2",
+		previousDir + "/app/a.js": "a1",
+		updateJournalFile:         "<missing>",
+	} {
+		if got := readFile(t, base, rel); got != want {
+			t.Errorf("%s = %q, want %q", rel, got, want)
+		}
+	}
+}
+
+func TestRecoverCompletedUpdate(t *testing.T) {
+	cl := testLauncher(t)
+	base := cl.baseDir
+	installV1(t, cl)
+
+	// Killed after the release record was written: the update is complete
+	// and only the backup has to be kept
+	record := &releaseRecord{Tag: "v2", Installed: time.Now().UTC(), Files: map[string]string{}}
+	j, err := beginJournal(base, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.f.Close()
+	if err := record.write(base); err != nil {
+		t.Fatal(err)
+	}
+	writeTree(t, base, map[string]string{
+		previousDir + "/app/a.js":        "a0",
+		previousDir + ".new/app/a.js":    "a1",
+		previousDir + ".new/app/gone.js": "g1",
+	})
+
+	if err := cl.recoverUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range map[string]string{
+		previousDir + "/app/a.js":    "a1",
+		previousDir + "/app/gone.js": "g1",
+		"app/a.js":                   "a1",
+		updateJournalFile:            "<missing>",
+	} {
+		if got := readFile(t, base, rel); got != want {
+			t.Errorf("%s = %q, want %q", rel, got, want)
+		}
+	}
+	if r := cl.readReleaseRecord(); r == nil || r.Tag != "v2" {
+		t.Errorf("release record = %+v, want v2", r)
+	}
+}
+
+func TestInstallReleaseCollisions(t *testing.T) {
+	cl := testLauncher(t)
+	base := cl.baseDir
+	v1 := t.TempDir()
+	writeTree(t, v1, map[string]string{
+		"app/package.json": testPackageJSON,
+		"app/tool/x.js":    "x1",
+		"app/lib.js":       "l1",
+	})
+	if _, err := cl.installRelease(v1, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	// The user put a directory and a file where v2 has the other
+	writeTree(t, base, map[string]string{
+		"app/conf.json/mine.txt": "mine",
+		"app/plugins":            "my file",
+	})
+
+	// v2 turns a directory into a file and a file into a directory
+	v2 := t.TempDir()
+	writeTree(t, v2, map[string]string{
+		"app/package.json":      testPackageJSON,
+		"app/tool":              "tool2",
+		"app/lib.js/index.js":   "l2",
+		"app/conf.json":         "c2",
+		"app/plugins/plugin.js": "p2",
+	})
+	summary, err := cl.installRelease(v2, "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"app/lib.js", "app/tool/x.js"}; !reflect.DeepEqual(summary.Removed, want) {
+		t.Errorf("Removed = %v, want %v", summary.Removed, want)
+	}
+	if want := []string{"app/conf.json", "app/plugins/plugin.js"}; !reflect.DeepEqual(keptPaths(summary.Kept), want) {
+		t.Errorf("Kept = %v, want %v", keptPaths(summary.Kept), want)
+	}
+	for rel, want := range map[string]string{
+		"app/tool":                  "tool2",
+		"app/lib.js/index.js":       "l2",
+		"app/conf.json/mine.txt":    "mine",
+		"app/conf.json" + newSuffix: "c2",
+		"app/plugins":               "my file",
+		previousDir + "/app/lib.js": "l1",
+	} {
+		if got := readFile(t, base, rel); got != want {
+			t.Errorf("%s = %q, want %q", rel, got, want)
+		}
+	}
+}
+
+func TestProtectionMatches(t *testing.T) {
+	p := protection{".ltth-*", "runtime/", "app/.env", "app/user_data/", "app/*.log"}
+	for rel, want := range map[string]bool{
+		".ltth-release.json":        true,
+		".ltth-previous/app/a.js":   true,
+		"runtime":                   true,
+		"runtime/node/bin/node":     true,
+		"runtimes/x":                false,
+		"app/.env":                  true,
+		"app/.env.example":          false,
+		"app/user_data/db/x.sqlite": true,
+		"app/server.log":            true,
+		"app/logs/server.log":       false,
+		"app/a.js":                  false,
+	} {
+		if got := p.matches(rel); got != want {
+			t.Errorf("matches(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/manifest"
+)
+
+// maxManifestSize bounds the manifest and signature downloads.
+const maxManifestSize = 1 << 20
+
+// installError is an error shown on the splash with hints.
+type installError struct {
+	Message string
+	Code    string
+	Hints   []string
+}
+
+func (e *installError) Error() string { return e.Message }
+
+// verifyHints are shown with every failed verification.
+var verifyHints = []string{
+	"Es wurde nichts installiert, die bisherige Version bleibt unverändert.",
+	"Die Datei wurde beim Download beschädigt oder verändert. Versuche es später erneut.",
+	"Bleibt der Fehler, melde ihn bitte auf GitHub.",
+}
+
+// trustedKeys returns the public keys built into the launcher from
+// assets/keys.json.
+func trustedKeys() ([]manifest.Key, error) {
+	data, err := assets.ReadFile("assets/keys.json")
+	if err != nil {
+		return nil, err
+	}
+	return manifest.ParseKeys(data)
+}
+
+// fetchSmall downloads a release asset of at most maxManifestSize bytes.
+func fetchSmall(url string) ([]byte, error) {
+	resp, err := apiClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: HTTP %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxManifestSize {
+		return nil, fmt.Errorf("%s ist zu groß", url)
+	}
+	return data, nil
+}
+
+// verifiedManifest downloads the manifest of r and checks its signature
+// against keys, the built-in keys. It returns the manifest and the asset
+// holding the archive it describes. Without keys every release is refused.
+func (cl *CloudLauncher) verifiedManifest(r *release, keys []manifest.Key) (*manifest.Manifest, *asset, error) {
+	if len(keys) == 0 {
+		return nil, nil, &installError{
+			Message: "Dieser Launcher enthält keine Signaturschlüssel und kann keine Releases prüfen",
+			Code:    "NO_SIGNING_KEYS",
+			Hints: []string{
+				"Es wurde nichts installiert, die bisherige Version bleibt unverändert.",
+				"Diese Version des Launchers wurde ohne Schlüssel in cmd/ltthgit/assets/keys.json gebaut. Lade den offiziellen Launcher herunter.",
+				"Zum Testen installiert --channel nightly den aktuellen Stand von main, geprüft nur per Prüfsumme.",
+			},
+		}
+	}
+
+	mAsset, sigAsset := r.asset(manifest.FileName), r.asset(manifest.SignatureName)
+	if mAsset == nil || sigAsset == nil {
+		return nil, nil, &installError{
+			Message: fmt.Sprintf("Version %s ist nicht signiert", r.TagName),
+			Code:    "UNSIGNED",
+			Hints:   []string{"Wähle eine signierte Version oder warte auf die nächste Version."},
+		}
+	}
+	data, err := fetchSmall(mAsset.BrowserDownloadURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Manifest: %v", err)
+	}
+	sigData, err := fetchSmall(sigAsset.BrowserDownloadURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Signatur: %v", err)
+	}
+
+	m, err := manifest.Verify(data, sigData, keys, r.TagName, time.Now())
+	if err != nil {
+		return nil, nil, &installError{Message: fmt.Sprintf("Version %s: %v", r.TagName, err), Code: "SIGNATURE_INVALID", Hints: verifyHints}
+	}
+	archive := r.asset(m.Archive.Name)
+	if archive == nil {
+		return nil, nil, fmt.Errorf("Version %s: %s fehlt im Release", r.TagName, m.Archive.Name)
+	}
+	cl.logger.Printf("Manifest of %s verified: %s (%d bytes, sha256 %s)\n", r.TagName, m.Archive.Name, m.Archive.Size, m.Archive.SHA256)
+	return m, archive, nil
+}
+
+// checksumsName is the release asset listing the SHA-256 of the nightly
+// archive, in the format of sha256sum.
+const checksumsName = "SHA256SUMS"
+
+// nightlyArchive finds the archive of the nightly release r and its
+// SHA-256 in the release's SHA256SUMS, and names r after the checksum so
+// that every build is stored as its own version.
+func nightlyArchive(r *release) error {
+	sums := r.asset(checksumsName)
+	if sums == nil {
+		return &installError{
+			Message: fmt.Sprintf("Nightly-Version %s hat keine %s", r.TagName, checksumsName),
+			Code:    "NO_CHECKSUM",
+			Hints:   []string{"Es wurde nichts installiert. Versuche es später erneut oder wähle den Kanal stable."},
+		}
+	}
+	data, err := fetchSmall(sums.BrowserDownloadURL)
+	if err != nil {
+		return fmt.Errorf("%s: %v", checksumsName, err)
+	}
+	checksums := parseChecksums(data)
+	for i := range r.Assets {
+		a := &r.Assets[i]
+		if sum, ok := checksums[a.Name]; ok && strings.HasSuffix(a.Name, ".zip") {
+			r.nightly, r.archive, r.sha256 = true, a, sum
+			r.TagName = nightlyTag + "@" + sum[:12]
+			if r.Name == "" {
+				r.Name = "Nightly " + sum[:12]
+			}
+			return nil
+		}
+	}
+	return &installError{
+		Message: fmt.Sprintf("Nightly-Version %s: kein Archiv in %s", r.TagName, checksumsName),
+		Code:    "NO_CHECKSUM",
+		Hints:   []string{"Es wurde nichts installiert. Versuche es später erneut oder wähle den Kanal stable."},
+	}
+}
+
+// parseChecksums reads "<sha256>  <name>" lines as written by sha256sum;
+// a "*" before the name marks binary mode. Malformed lines are ignored.
+func parseChecksums(data []byte) map[string]string {
+	sums := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		sum := strings.ToLower(fields[0])
+		if _, err := hex.DecodeString(sum); err != nil || len(sum) != 64 {
+			continue
+		}
+		sums[strings.TrimPrefix(fields[1], "*")] = sum
+	}
+	return sums
+}
+
+// checkNightly compares the downloaded nightly archive with the checksum
+// and size published with it.
+func checkNightly(r *release, size int64, sum []byte) error {
+	got := hex.EncodeToString(sum)
+	if got != r.sha256 || (r.archive.Size > 0 && size != r.archive.Size) {
+		return &installError{
+			Message: fmt.Sprintf("Prüfsumme von %s stimmt nicht: erwartet %s, erhalten %s (%d Bytes)", r.archive.Name, r.sha256, got, size),
+			Code:    "CHECKSUM_MISMATCH",
+			Hints:   verifyHints,
+		}
+	}
+	return nil
+}
+
+// checkArchive compares the downloaded archive with the manifest.
+func checkArchive(m *manifest.Manifest, size int64, sum []byte) error {
+	got := hex.EncodeToString(sum)
+	if size != m.Archive.Size || got != m.Archive.SHA256 {
+		return &installError{
+			Message: fmt.Sprintf("Prüfsumme von %s stimmt nicht: erwartet %s (%d Bytes), erhalten %s (%d Bytes)", m.Archive.Name, m.Archive.SHA256, m.Archive.Size, got, size),
+			Code:    "CHECKSUM_MISMATCH",
+			Hints:   verifyHints,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/launcher"
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/manifest"
+)
+
+func TestParseChecksums(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	data := sum + "  ltth-nightly.zip\n" +
+		strings.ToUpper(sum) + " *binary.zip\r\n" +
+		"not-a-hash  broken.zip\n" +
+		sum[:10] + "  short.zip\n" +
+		"\n" +
+		sum + "  two words.zip\n"
+	got := parseChecksums([]byte(data))
+	want := map[string]string{"ltth-nightly.zip": sum, "binary.zip": sum}
+	if len(got) != len(want) || got["ltth-nightly.zip"] != sum || got["binary.zip"] != sum {
+		t.Errorf("parseChecksums = %v, want %v", got, want)
+	}
+}
+
+// nightlyServer serves a fake GitHub API with a nightly release holding
+// archive and the given SHA256SUMS; sums "" leaves the file out.
+func nightlyServer(t *testing.T, archive []byte, sums string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	assets := []asset{{Name: "ltth-nightly.zip", Size: int64(len(archive)), BrowserDownloadURL: srv.URL + "/download/ltth-nightly.zip"}}
+	if sums != "" {
+		assets = append(assets, asset{Name: checksumsName, Size: int64(len(sums)), BrowserDownloadURL: srv.URL + "/download/" + checksumsName})
+	}
+	mux.HandleFunc("/repos/"+repoOwner+"/"+repoName+"/releases/tags/"+nightlyTag, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tag_name":   nightlyTag,
+			"prerelease": true,
+			"assets":     assets,
+		})
+	})
+	mux.HandleFunc("/download/ltth-nightly.zip", func(w http.ResponseWriter, r *http.Request) { w.Write(archive) })
+	mux.HandleFunc("/download/"+checksumsName, func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(sums)) })
+	return srv
+}
+
+func nightlyLauncher(t *testing.T, srv *httptest.Server) *CloudLauncher {
+	cl := testLauncher(t)
+	cl.events = events.NewStream(events.DefaultReplaySize, "")
+	cl.update = launcher.DefaultSettings().Update
+	cl.update.Channel = launcher.ChannelNightly
+	cl.update.APIBaseURL = srv.URL
+	return cl
+}
+
+func TestResolveNightly(t *testing.T) {
+	archive := []byte("nightly archive")
+	sum := sha256.Sum256(archive)
+	hexSum := hex.EncodeToString(sum[:])
+
+	srv := nightlyServer(t, archive, fmt.Sprintf("%s  ltth-nightly.zip\n", hexSum))
+	cl := nightlyLauncher(t, srv)
+	r, err := cl.resolveRelease()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.nightly || r.archive == nil || r.archive.Name != "ltth-nightly.zip" || r.sha256 != hexSum {
+		t.Fatalf("release = %+v, want the checked nightly archive", r)
+	}
+	if want := "nightly@" + hexSum[:12]; r.TagName != want {
+		t.Errorf("TagName = %q, want %q", r.TagName, want)
+	}
+	if err := checkNightly(r, int64(len(archive)), sum[:]); err != nil {
+		t.Errorf("checkNightly on the published archive: %v", err)
+	}
+
+	tampered := sha256.Sum256([]byte("tampered"))
+	var ierr *installError
+	if err := checkNightly(r, int64(len(archive)), tampered[:]); !errors.As(err, &ierr) || ierr.Code != "CHECKSUM_MISMATCH" {
+		t.Errorf("checkNightly on a tampered archive = %v, want CHECKSUM_MISMATCH", err)
+	}
+	if err := checkNightly(r, int64(len(archive))+1, sum[:]); err == nil {
+		t.Error("checkNightly accepted an archive of the wrong size")
+	}
+
+	cl.update.Skip = []string{r.TagName}
+	if _, err := cl.resolveRelease(); err == nil {
+		t.Error("resolveRelease returned a skipped nightly")
+	}
+}
+
+func TestResolveNightlyWithoutChecksum(t *testing.T) {
+	tests := []struct {
+		name string
+		sums string
+	}{
+		{"no SHA256SUMS", ""},
+		{"archive not listed", strings.Repeat("0", 64) + "  other.zip\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := nightlyLauncher(t, nightlyServer(t, []byte("archive"), tt.sums))
+			_, err := cl.resolveRelease()
+			var ierr *installError
+			if !errors.As(err, &ierr) || ierr.Code != "NO_CHECKSUM" {
+				t.Errorf("resolveRelease = %v, want NO_CHECKSUM", err)
+			}
+		})
+	}
+}
+
+// signedServer serves a release of tag whose archive is signed with priv,
+// as ltthsign writes it.
+func signedServer(t *testing.T, tag string, archive []byte, keyID string, priv ed25519.PrivateKey) *release {
+	t.Helper()
+	sum := sha256.Sum256(archive)
+	m := manifest.Manifest{
+		Tag:     tag,
+		Created: time.Now().UTC().Add(-time.Minute).Truncate(time.Second),
+		Archive: manifest.File{Name: "ltth-" + tag + ".zip", Size: int64(len(archive)), SHA256: hex.EncodeToString(sum[:])},
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigData, err := json.Marshal([]manifest.Signature{manifest.Sign(data, keyID, priv)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{m.Archive.Name: archive, manifest.FileName: data, manifest.SignatureName: sigData}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[strings.TrimPrefix(r.URL.Path, "/download/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	r := &release{TagName: tag}
+	for name, body := range files {
+		r.Assets = append(r.Assets, asset{Name: name, Size: int64(len(body)), BrowserDownloadURL: srv.URL + "/download/" + name})
+	}
+	return r
+}
+
+func TestVerifiedManifest(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []manifest.Key{{ID: "test", PublicKey: pub, NotBefore: time.Now().Add(-time.Hour)}}
+	archive := []byte("release archive")
+
+	tests := []struct {
+		name string
+		keys []manifest.Key
+		priv ed25519.PrivateKey
+		code string
+	}{
+		{"signed", keys, priv, ""},
+		{"no keys built in", nil, priv, "NO_SIGNING_KEYS"},
+		{"unknown key", keys, otherPriv, "SIGNATURE_INVALID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := signedServer(t, "v1.2.0", archive, "test", tt.priv)
+			m, a, err := testLauncher(t).verifiedManifest(r, tt.keys)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				sum := sha256.Sum256(archive)
+				if a.Name != "ltth-v1.2.0.zip" || checkArchive(m, int64(len(archive)), sum[:]) != nil {
+					t.Errorf("manifest %+v does not describe the archive %s", m.Archive, a.Name)
+				}
+				return
+			}
+			var ierr *installError
+			if !errors.As(err, &ierr) || ierr.Code != tt.code {
+				t.Errorf("verifiedManifest = %v, want %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestUnsignedRelease(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []manifest.Key{{ID: "test", PublicKey: pub}}
+	r := &release{TagName: "v1.2.0", Assets: []asset{{Name: "ltth-v1.2.0.zip"}}}
+	_, _, err = testLauncher(t).verifiedManifest(r, keys)
+	var ierr *installError
+	if !errors.As(err, &ierr) || ierr.Code != "UNSIGNED" {
+		t.Errorf("verifiedManifest = %v, want UNSIGNED", err)
+	}
+}
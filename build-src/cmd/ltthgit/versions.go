@@ -0,0 +1,383 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+)
+
+const (
+	// versionsDir holds every downloaded release side by side, as
+	// versions/<tag>/, next to the installation. Switching versions
+	// installs one of them over the installation with installRelease.
+	versionsDir = "versions"
+	// versionStateFile in versionsDir points to the current version and
+	// records which versions started.
+	versionStateFile = "state.json"
+	// keepVersions is how many versions are kept besides the current and
+	// the last known-good one.
+	keepVersions = 3
+	// failedRetryAfter is how long a version that failed to start is
+	// skipped.
+	failedRetryAfter = 7 * 24 * time.Hour
+)
+
+type versionStatus string
+
+const (
+	// versionPending has been installed but not started yet.
+	versionPending versionStatus = "pending"
+	versionGood    versionStatus = "good"
+	// versionFailed did not start; it is not installed again for
+	// failedRetryAfter unless it is pinned or -retry-failed is given.
+	versionFailed versionStatus = "failed"
+)
+
+// versionInfo is what the launcher knows about one version.
+type versionInfo struct {
+	Installed time.Time     `json:"installed"`
+	Status    versionStatus `json:"status"`
+	// Reason tells why a failed version was given up.
+	Reason string    `json:"reason,omitempty"`
+	Failed time.Time `json:"failed,omitzero"`
+}
+
+// versionState is the content of versionStateFile.
+type versionState struct {
+	// Current is the version installed in the base directory.
+	Current string `json:"current"`
+	// KnownGood is the last version that reached readiness.
+	KnownGood string                  `json:"knownGood,omitempty"`
+	Versions  map[string]*versionInfo `json:"versions"`
+}
+
+// startError is a failure of the started app itself: it exited or never
+// started listening. Only such a failure is blamed on the installed
+// version; npm being offline, an unsupported Node.js or a taken port are
+// problems of the computer and are reported as they are.
+type startError struct {
+	err error
+}
+
+func (e *startError) Error() string { return fmt.Sprintf("Start fehlgeschlagen: %v", e.err) }
+
+func (e *startError) Unwrap() error { return e.err }
+
+// versionDir returns the directory of tag below versionsDir. Slashes in
+// the tag become "_"; tags that would not name a directory of their own,
+// such as "", "..", "C:x" on Windows or versionStateFile, are refused,
+// because the directory is removed when the version is stored again or
+// pruned.
+func (cl *CloudLauncher) versionDir(tag string) (string, error) {
+	name := strings.NewReplacer("/", "_", `\`, "_").Replace(tag)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, ":\x00") || strings.HasPrefix(name, versionStateFile) {
+		return "", fmt.Errorf("Ungültige Version %q", tag)
+	}
+	return filepath.Join(cl.baseDir, versionsDir, name), nil
+}
+
+// loadVersions reads the version state; a missing or broken file yields an
+// empty state.
+func (cl *CloudLauncher) loadVersions() {
+	cl.versions = &versionState{Versions: map[string]*versionInfo{}}
+	data, err := os.ReadFile(filepath.Join(cl.baseDir, versionsDir, versionStateFile))
+	if err != nil {
+		return
+	}
+	var s versionState
+	if err := json.Unmarshal(data, &s); err != nil {
+		cl.logger.Printf("Ignoring invalid %s: %v\n", versionStateFile, err)
+		return
+	}
+	if s.Versions == nil {
+		s.Versions = map[string]*versionInfo{}
+	}
+	cl.versions = &s
+}
+
+func (cl *CloudLauncher) saveVersions() error {
+	data, err := json.MarshalIndent(cl.versions, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(cl.baseDir, versionsDir, versionStateFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// stored reports whether tag has been downloaded into versionsDir.
+func (cl *CloudLauncher) stored(tag string) bool {
+	if _, ok := cl.versions.Versions[tag]; !ok {
+		return false
+	}
+	dir, err := cl.versionDir(tag)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+// storeVersion moves a release extracted into staging to its directory
+// below versionsDir.
+func (cl *CloudLauncher) storeVersion(staging, tag string) error {
+	dir, err := cl.versionDir(tag)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	os.RemoveAll(dir)
+	if err := os.Rename(staging, dir); err != nil {
+		return err
+	}
+	cl.versions.Versions[tag] = &versionInfo{Installed: time.Now().UTC(), Status: versionPending}
+	return cl.saveVersions()
+}
+
+// activate installs the stored version tag over the base directory and
+// makes it the current one.
+func (cl *CloudLauncher) activate(tag string) error {
+	cl.updateProgress(65, fmt.Sprintf("Installiere %s...", tag))
+	dir, err := cl.versionDir(tag)
+	if err != nil {
+		return err
+	}
+	summary, err := cl.installRelease(dir, tag)
+	if err != nil {
+		return fmt.Errorf("Installation fehlgeschlagen: %v", err)
+	}
+	cl.logSummary(tag, summary)
+	cl.updateProgress(68, fmt.Sprintf("%s installiert: %s", tag, summary))
+
+	cl.versions.Current = tag
+	return cl.saveVersions()
+}
+
+// markGood records that the current version started and removes old
+// versions.
+func (cl *CloudLauncher) markGood() {
+	tag := cl.versions.Current
+	if info := cl.versions.Versions[tag]; info != nil {
+		info.Status, info.Reason = versionGood, ""
+	}
+	cl.versions.KnownGood = tag
+	cl.pruneVersions()
+	if err := cl.saveVersions(); err != nil {
+		cl.logger.Printf("Could not save %s: %v\n", versionStateFile, err)
+	}
+}
+
+// markFailed records why the current version was given up.
+func (cl *CloudLauncher) markFailed(reason string) {
+	tag := cl.versions.Current
+	info := cl.versions.Versions[tag]
+	if info == nil {
+		info = &versionInfo{}
+		cl.versions.Versions[tag] = info
+	}
+	info.Status, info.Reason, info.Failed = versionFailed, reason, time.Now().UTC()
+	if cl.versions.KnownGood == tag {
+		cl.versions.KnownGood = ""
+	}
+	cl.logger.Printf("Version %s failed: %s\n", tag, reason)
+	if err := cl.saveVersions(); err != nil {
+		cl.logger.Printf("Could not save %s: %v\n", versionStateFile, err)
+	}
+}
+
+// skipFailed adds the versions that failed to start in the last
+// failedRetryAfter to the skip list, unless a version is pinned or
+// -retry-failed is given, and tells why they are skipped.
+func (cl *CloudLauncher) skipFailed() {
+	if cl.update.Version != "" || cl.retryFailed {
+		return
+	}
+	for tag, info := range cl.versions.Versions {
+		if info.Status != versionFailed || cl.update.Skipped(tag) {
+			continue
+		}
+		if time.Since(info.Failed) > failedRetryAfter {
+			cl.logger.Printf("Trying %s again, it failed on %s\n", tag, info.Failed.Local().Format(time.DateTime))
+			continue
+		}
+		cl.update.Skip = append(cl.update.Skip, tag)
+		cl.events.Publish(events.TypeWarning, events.Message{
+			Message: fmt.Sprintf("%s wird übersprungen: am %s nicht gestartet (%s). Mit --retry-failed erneut versuchen.", tag, info.Failed.Local().Format("02.01.2006 15:04"), info.Reason),
+		})
+	}
+}
+
+// installLatest installs the release the update settings choose, unless it
+// is already the current version. If no release can be chosen, e.g. because
+// the newest one failed, the current version is kept.
+func (cl *CloudLauncher) installLatest() error {
+	cl.skipFailed()
+	cl.updateProgress(5, "Suche passende Version...")
+	rel, err := cl.resolveRelease()
+	if err != nil && cl.stored(cl.versions.Current) {
+		cl.logger.Printf("No release to install, keeping %s: %v\n", cl.versions.Current, err)
+		cl.events.Publish(events.TypeWarning, events.Message{
+			Message: fmt.Sprintf("Keine neue Version: %v. %s bleibt installiert.", err, cl.versions.Current),
+		})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Keine Version zum Installieren: %v", err)
+	}
+	cl.announceRelease(rel)
+
+	tag := rel.TagName
+	if tag == cl.versions.Current && cl.stored(tag) {
+		cl.logger.Printf("%s is already installed\n", tag)
+		cl.updateProgress(70, fmt.Sprintf("%s ist bereits installiert", tag))
+		return nil
+	}
+	if !cl.stored(tag) {
+		if err := cl.downloadRepository(rel); err != nil {
+			return err
+		}
+	} else if info := cl.versions.Versions[tag]; info.Status == versionFailed {
+		// Tried again: it has to prove itself like a new version
+		info.Status = versionPending
+	}
+	return cl.activate(tag)
+}
+
+// manualRollback switches to rollbackTo, or to the version rollbackTarget
+// picks. The current version is marked as failed, so that it is not
+// installed again automatically.
+func (cl *CloudLauncher) manualRollback() error {
+	target := cl.rollbackTo
+	if target == "" {
+		target = cl.rollbackTarget()
+	}
+	switch {
+	case target == "":
+		return fmt.Errorf("Keine frühere Version zum Zurückwechseln vorhanden")
+	case target == cl.versions.Current:
+		return fmt.Errorf("%s ist bereits installiert", target)
+	case !cl.stored(target):
+		return fmt.Errorf("Version %s ist nicht vorhanden (vorhanden: %s)", target, strings.Join(cl.storedVersions(), ", "))
+	}
+	return cl.rollback(target, "manuell zurückgesetzt")
+}
+
+// recoverStart handles a version that did not start. A version that was
+// just installed and whose app failed (a startError) is replaced by the
+// last one that worked, which is then started instead; otherwise cause is
+// returned.
+func (cl *CloudLauncher) recoverStart(appDir string, cause error) (*appProcess, error) {
+	var failed *startError
+	if !errors.As(cause, &failed) {
+		return nil, cause
+	}
+	current := cl.versions.Current
+	info := cl.versions.Versions[current]
+	if info == nil || info.Status != versionPending {
+		return nil, cause
+	}
+	target := cl.rollbackTarget()
+	if target == "" {
+		cl.markFailed(cause.Error())
+		return nil, cause
+	}
+
+	cl.events.Publish(events.TypeWarning, events.Message{
+		Message: fmt.Sprintf("%s ist nicht gestartet (%v). Wechsle zurück zu %s.", current, cause, target),
+	})
+	if err := cl.rollback(target, cause.Error()); err != nil {
+		return nil, fmt.Errorf("%v; Zurückwechseln zu %s fehlgeschlagen: %v", cause, target, err)
+	}
+	app, err := cl.launch(appDir)
+	if err != nil {
+		return nil, fmt.Errorf("%s ist nicht gestartet (%v), und auch %s startet nicht: %v", current, cause, target, err)
+	}
+	return app, nil
+}
+
+// storedVersions lists the versions below versionsDir, newest first.
+func (cl *CloudLauncher) storedVersions() []string {
+	var tags []string
+	for tag := range cl.versions.Versions {
+		if cl.stored(tag) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return cl.versions.Versions[tags[i]].Installed.After(cl.versions.Versions[tags[j]].Installed)
+	})
+	return tags
+}
+
+// rollbackTarget returns the version to go back to from the current one:
+// the last known-good version, or else the newest other version that
+// started. It is "" if there is none.
+func (cl *CloudLauncher) rollbackTarget() string {
+	s := cl.versions
+	if s.KnownGood != "" && s.KnownGood != s.Current && cl.stored(s.KnownGood) {
+		return s.KnownGood
+	}
+	target := ""
+	for tag, info := range s.Versions {
+		if tag == s.Current || info.Status != versionGood || !cl.stored(tag) {
+			continue
+		}
+		if target == "" || info.Installed.After(s.Versions[target].Installed) {
+			target = tag
+		}
+	}
+	return target
+}
+
+// rollback marks the current version as failed for reason and installs
+// target instead.
+func (cl *CloudLauncher) rollback(target, reason string) error {
+	from := cl.versions.Current
+	if from != "" {
+		cl.markFailed(reason)
+	}
+	cl.logger.Printf("Rolling back from %s to %s\n", from, target)
+	cl.events.Publish(events.TypeRelease, events.Release{Tag: target, Channel: "rollback", Notes: fmt.Sprintf("Zurück von %s: %s", from, reason)})
+	return cl.activate(target)
+}
+
+// pruneVersions removes stored versions beyond the keepVersions newest,
+// never the current and the known-good one.
+func (cl *CloudLauncher) pruneVersions() {
+	s := cl.versions
+	kept := 0
+	for _, tag := range cl.storedVersions() {
+		if tag == s.Current || tag == s.KnownGood {
+			continue
+		}
+		if kept < keepVersions {
+			kept++
+			continue
+		}
+		// storedVersions only lists tags with a valid directory
+		dir, _ := cl.versionDir(tag)
+		cl.logger.Printf("Removing old version %s\n", tag)
+		if err := os.RemoveAll(dir); err != nil {
+			cl.logger.Printf("Could not remove %s: %v\n", tag, err)
+			continue
+		}
+		// Failed versions stay listed so they are not installed again
+		if s.Versions[tag].Status != versionFailed {
+			delete(s.Versions, tag)
+		}
+	}
+}
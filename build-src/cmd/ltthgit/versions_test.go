@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+)
+
+func TestVersionDir(t *testing.T) {
+	cl := testLauncher(t)
+	versions := filepath.Join(cl.baseDir, versionsDir)
+
+	tests := []struct {
+		tag  string
+		want string // "" for a refused tag
+	}{
+		{"v1.2.0", "v1.2.0"},
+		{"v1.3.0-beta.1", "v1.3.0-beta.1"},
+		{"nightly", "nightly"},
+		{"release/1.0", "release_1.0"},
+		{`release\1.0`, "release_1.0"},
+		{"../..", ".._.."},
+		{"..v1", "..v1"},
+		{"", ""},
+		{".", ""},
+		{"..", ""},
+		{"C:", ""},
+		{"C:x", ""},
+		{"v1:stream", ""},
+		{"v1\x00", ""},
+		{versionStateFile, ""},
+		{versionStateFile + ".tmp", ""},
+	}
+	for _, tt := range tests {
+		dir, err := cl.versionDir(tt.tag)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("versionDir(%q) = %s, want an error", tt.tag, dir)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("versionDir(%q): %v", tt.tag, err)
+			continue
+		}
+		if want := filepath.Join(versions, tt.want); dir != want {
+			t.Errorf("versionDir(%q) = %s, want %s", tt.tag, dir, want)
+		}
+		if filepath.Dir(dir) != versions {
+			t.Errorf("versionDir(%q) = %s is not directly below %s", tt.tag, dir, versions)
+		}
+	}
+}
+
+// storedLauncher returns a launcher whose versionsDir holds a small app
+// for each tag in versions, installed an hour apart in the order given.
+func storedLauncher(t *testing.T, current, knownGood string, versions ...string) *CloudLauncher {
+	t.Helper()
+	cl := testLauncher(t)
+	cl.events = events.NewStream(events.DefaultReplaySize, "")
+	cl.versions = &versionState{Current: current, KnownGood: knownGood, Versions: map[string]*versionInfo{}}
+	installed := time.Now().UTC().Add(-time.Duration(len(versions)) * time.Hour)
+	for _, v := range versions {
+		// "tag:status", e.g. "v1:good"
+		tag, status, _ := strings.Cut(v, ":")
+		cl.versions.Versions[tag] = &versionInfo{Installed: installed, Status: versionStatus(status)}
+		installed = installed.Add(time.Hour)
+		dir, err := cl.versionDir(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTree(t, dir, map[string]string{"app/package.json": `{"name": "app"}`, "app/version.txt": tag})
+	}
+	return cl
+}
+
+func TestRollbackTarget(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   string
+		knownGood string
+		versions  []string
+		// unstored versions are listed in the state but not on disk
+		unstored []string
+		want     string
+	}{
+		{"known good", "v3", "v1", []string{"v1:good", "v2:good", "v3:pending"}, nil, "v1"},
+		{"newest good", "v3", "", []string{"v1:good", "v2:good", "v3:pending"}, nil, "v2"},
+		{"known good is current", "v2", "v2", []string{"v1:good", "v2:good"}, nil, "v1"},
+		{"known good removed", "v3", "v1", []string{"v1:good", "v2:good", "v3:pending"}, []string{"v1"}, "v2"},
+		{"only failed and pending", "v3", "", []string{"v1:failed", "v2:pending", "v3:pending"}, nil, ""},
+		{"good one removed", "v2", "", []string{"v1:good", "v2:pending"}, []string{"v1"}, ""},
+		{"nothing else", "v1", "v1", []string{"v1:good"}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := storedLauncher(t, tt.current, tt.knownGood, tt.versions...)
+			for _, tag := range tt.unstored {
+				dir, _ := cl.versionDir(tag)
+				os.RemoveAll(dir)
+			}
+			if got := cl.rollbackTarget(); got != tt.want {
+				t.Errorf("rollbackTarget() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailedVersionsSkipped(t *testing.T) {
+	tests := []struct {
+		name        string
+		failedAgo   time.Duration
+		pinned      string
+		retryFailed bool
+		wantSkipped bool
+	}{
+		{"just failed", 0, "", false, true},
+		{"failed 6 days ago", 6 * 24 * time.Hour, "", false, true},
+		{"failed 8 days ago", 8 * 24 * time.Hour, "", false, false},
+		{"version pinned", 0, "v1", false, false},
+		{"retry failed", 0, "", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := storedLauncher(t, "v2", "v2", "v1:good", "v2:good")
+			cl.markFailed("exited")
+
+			info := cl.versions.Versions["v2"]
+			if info.Status != versionFailed || info.Reason != "exited" || time.Since(info.Failed) > time.Minute {
+				t.Fatalf("after markFailed: %+v", info)
+			}
+			if cl.versions.KnownGood != "" {
+				t.Errorf("KnownGood = %q, want the failed version cleared", cl.versions.KnownGood)
+			}
+
+			info.Failed = info.Failed.Add(-tt.failedAgo)
+			cl.update.Version = tt.pinned
+			cl.retryFailed = tt.retryFailed
+			cl.skipFailed()
+			if got := cl.update.Skipped("v2"); got != tt.wantSkipped {
+				t.Errorf("v2 skipped = %v, want %v", got, tt.wantSkipped)
+			}
+			if cl.update.Skipped("v1") {
+				t.Error("v1 skipped, but it never failed")
+			}
+		})
+	}
+}
+
+// noNode hides the Node.js installations of this machine, so that
+// starting a version fails before anything runs.
+func noNode(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	for _, key := range []string{"HOME", "NVM_DIR", "FNM_DIR", "VOLTA_HOME", "ASDF_DATA_DIR", "N_PREFIX", "NVM_HOME", "APPDATA", "LOCALAPPDATA", "ProgramFiles"} {
+		t.Setenv(key, home)
+	}
+	t.Setenv("PATH", "")
+}
+
+func TestRecoverStart(t *testing.T) {
+	noNode(t)
+	appFailed := &startError{errors.New("exit status 1")}
+
+	tests := []struct {
+		name     string
+		versions []string
+		cause    error
+		// wantCause is whether cause is returned unchanged
+		wantCause   bool
+		wantCurrent string
+		wantStatus  versionStatus // of v2
+	}{
+		{"not the app's fault", []string{"v1:good", "v2:pending"}, errors.New("npm offline"), true, "v2", versionPending},
+		{"version proven before", []string{"v1:good", "v2:good"}, appFailed, true, "v2", versionGood},
+		{"nothing to go back to", []string{"v1:failed", "v2:pending"}, appFailed, true, "v2", versionFailed},
+		{"rolled back", []string{"v1:good", "v2:pending"}, appFailed, false, "v1", versionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := storedLauncher(t, "v2", "", tt.versions...)
+			appDir := filepath.Join(cl.baseDir, "app")
+
+			_, err := cl.recoverStart(appDir, tt.cause)
+			if err == nil {
+				t.Fatal("recoverStart started a version without Node.js")
+			}
+			if got := err == tt.cause; got != tt.wantCause {
+				t.Errorf("recoverStart() = %v, want the cause returned unchanged: %v", err, tt.wantCause)
+			}
+			if cl.versions.Current != tt.wantCurrent {
+				t.Errorf("Current = %s, want %s", cl.versions.Current, tt.wantCurrent)
+			}
+			if got := cl.versions.Versions["v2"].Status; got != tt.wantStatus {
+				t.Errorf("v2 is %s, want %s", got, tt.wantStatus)
+			}
+			if tt.wantCurrent == "v1" {
+				if got := readFile(t, cl.baseDir, "app/version.txt"); got != "v1" {
+					t.Errorf("installed app is %s, want v1", got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Release signing tool (ltthsign). Creates the signing keys and the signed
+// manifest that ltthgit checks before it installs a release archive.
+// Build command: go build -o ltthsign ./cmd/ltthsign
+//
+//	ltthsign keygen -id 2026-1 -out release-2026-1.key
+//	ltthsign sign -key release-2026-1.key -tag v1.2.0 ltth-v1.2.0.zip
+//	ltthsign verify -keys cmd/ltthgit/assets/keys.json -tag v1.2.0 ltth-v1.2.0.zip
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/manifest"
+)
+
+// keyFile is the private key as written by keygen.
+type keyFile struct {
+	ID         string             `json:"id"`
+	PrivateKey ed25519.PrivateKey `json:"privateKey"`
+}
+
+// keyFlags collects repeated -key flags.
+type keyFlags []string
+
+func (k *keyFlags) String() string     { return strings.Join(*k, ",") }
+func (k *keyFlags) Set(v string) error { *k = append(*k, v); return nil }
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Verwendung:")
+	fmt.Fprintln(os.Stderr, "  ltthsign keygen -id <id> -out <datei> [-not-after 2028-01-01]")
+	fmt.Fprintln(os.Stderr, "  ltthsign sign -key <datei> [-key <datei>...] -tag <tag> <archiv.zip>")
+	fmt.Fprintln(os.Stderr, "  ltthsign verify -keys <keys.json> -tag <tag> <archiv.zip>")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "keygen":
+		err = keygen(os.Args[2:])
+	case "sign":
+		err = sign(os.Args[2:])
+	case "verify":
+		err = verify(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FEHLER: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// keygen writes a new private key and prints the entry for
+// cmd/ltthgit/assets/keys.json.
+func keygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	id := fs.String("id", "", "Name des Schlüssels, z.B. 2026-1")
+	out := fs.String("out", "", "Datei für den privaten Schlüssel")
+	notAfter := fs.String("not-after", "", "Ende der Gültigkeit (JJJJ-MM-TT), leer für unbegrenzt")
+	fs.Parse(args)
+	if *id == "" || *out == "" {
+		usage()
+	}
+
+	key := manifest.Key{ID: *id, NotBefore: time.Now().UTC().Truncate(time.Second)}
+	if *notAfter != "" {
+		t, err := time.Parse("2006-01-02", *notAfter)
+		if err != nil {
+			return err
+		}
+		if !t.After(key.NotBefore) {
+			return fmt.Errorf("-not-after %s liegt in der Vergangenheit", *notAfter)
+		}
+		key.NotAfter = t
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	key.PublicKey = pub
+
+	data, err := json.MarshalIndent(keyFile{ID: *id, PrivateKey: priv}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0600); err != nil {
+		return err
+	}
+	entry, err := json.MarshalIndent(key, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Privater Schlüssel: %s (geheim halten, nicht einchecken)\n", *out)
+	fmt.Println("Eintrag für cmd/ltthgit/assets/keys.json:")
+	fmt.Printf("  %s\n", entry)
+	return nil
+}
+
+// sign writes the manifest and its signatures next to the archive; both
+// are uploaded to the release together with it.
+func sign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	var keys keyFlags
+	fs.Var(&keys, "key", "Privater Schlüssel (mehrfach möglich)")
+	tag := fs.String("tag", "", "Release-Tag, z.B. v1.2.0")
+	fs.Parse(args)
+	if len(keys) == 0 || *tag == "" || fs.NArg() != 1 {
+		usage()
+	}
+	archive := fs.Arg(0)
+
+	size, sum, err := hashFile(archive)
+	if err != nil {
+		return err
+	}
+
+	m := manifest.Manifest{
+		Tag:     *tag,
+		Created: time.Now().UTC().Truncate(time.Second),
+		Archive: manifest.File{Name: filepath.Base(archive), Size: size, SHA256: sum},
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	var sigs []manifest.Signature
+	for _, path := range keys {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var k keyFile
+		if err := json.Unmarshal(raw, &k); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if len(k.PrivateKey) != ed25519.PrivateKeySize {
+			return fmt.Errorf("%s: kein ed25519-Schlüssel", path)
+		}
+		sigs = append(sigs, manifest.Sign(data, k.ID, k.PrivateKey))
+	}
+	sigData, err := json.MarshalIndent(sigs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(archive)
+	if err := os.WriteFile(filepath.Join(dir, manifest.FileName), data, 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifest.SignatureName), sigData, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("%s und %s geschrieben (%s, %d Bytes, SHA-256 %s)\n", manifest.FileName, manifest.SignatureName, m.Archive.Name, size, m.Archive.SHA256)
+	return nil
+}
+
+// verify checks the manifest next to the archive the way ltthgit does,
+// with the keys a launcher is built with. The release workflow runs it
+// before uploading, so that no release goes out that launchers refuse.
+func verify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	keysPath := fs.String("keys", "", "Öffentliche Schlüssel, z.B. cmd/ltthgit/assets/keys.json")
+	tag := fs.String("tag", "", "Release-Tag, z.B. v1.2.0")
+	fs.Parse(args)
+	if *keysPath == "" || *tag == "" || fs.NArg() != 1 {
+		usage()
+	}
+	archive := fs.Arg(0)
+
+	raw, err := os.ReadFile(*keysPath)
+	if err != nil {
+		return err
+	}
+	keys, err := manifest.ParseKeys(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %v", *keysPath, err)
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("%s enthält keine Schlüssel; Launcher daraus lehnen jedes Release ab", *keysPath)
+	}
+
+	dir := filepath.Dir(archive)
+	data, err := os.ReadFile(filepath.Join(dir, manifest.FileName))
+	if err != nil {
+		return err
+	}
+	sigData, err := os.ReadFile(filepath.Join(dir, manifest.SignatureName))
+	if err != nil {
+		return err
+	}
+	m, err := manifest.Verify(data, sigData, keys, *tag, time.Now())
+	if err != nil {
+		return err
+	}
+
+	size, sum, err := hashFile(archive)
+	if err != nil {
+		return err
+	}
+	if m.Archive.Name != filepath.Base(archive) || m.Archive.Size != size || m.Archive.SHA256 != sum {
+		return fmt.Errorf("%s passt nicht zum Manifest (%s, %d Bytes, SHA-256 %s)", archive, m.Archive.Name, m.Archive.Size, m.Archive.SHA256)
+	}
+	fmt.Printf("%s ist gültig signiert für %s\n", m.Archive.Name, *tag)
+	return nil
+}
+
+// hashFile returns the size and SHA-256 of the file at path.
+func hashFile(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,136 @@
+// Package events implements the Server-Sent Events protocol between the
+// launchers and their splash pages. Every event has a monotonically
+// increasing id, an SSE event name and a JSON payload, so status texts may
+// contain any characters and reconnecting browsers can resume where they
+// left off.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Type is the SSE event name.
+type Type string
+
+const (
+	TypeProgress   Type = "progress"
+	TypePhaseStart Type = "phase-start"
+	TypePhaseEnd   Type = "phase-end"
+	TypeLogLine    Type = "log-line"
+	TypeWarning    Type = "warning"
+	TypeError      Type = "error"
+	TypeRedirect   Type = "redirect"
+	TypeDone       Type = "done"
+	// TypeInstallProfile lists the optional features of the app and
+	// whether the chosen install profile includes them.
+	TypeInstallProfile Type = "install-profile"
+	// TypeRelease announces the version the cloud launcher is about to
+	// install.
+	TypeRelease Type = "release"
+)
+
+// Event is a single message on the stream. Data is one of the payload types
+// below and is marshalled with encoding/json.
+type Event struct {
+	// ID is 0 for events that are not part of the replay, such as the
+	// progress snapshot; they are sent without an id so that a client's
+	// Last-Event-ID keeps pointing at a real event.
+	ID   uint64
+	Type Type
+	Data interface{}
+}
+
+// Progress is the payload of TypeProgress.
+type Progress struct {
+	Progress int    `json:"progress"`
+	Status   string `json:"status"`
+}
+
+// Phase is the payload of TypePhaseStart and TypePhaseEnd.
+type Phase struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// LogLine is the payload of TypeLogLine, e.g. one line of npm output.
+type LogLine struct {
+	Source string `json:"source,omitempty"`
+	Line   string `json:"line"`
+}
+
+// Message is the payload of TypeWarning and TypeError.
+type Message struct {
+	Message string   `json:"message"`
+	Hints   []string `json:"hints,omitempty"`
+	// Code identifies a known failure, e.g. "NPM_ERESOLVE".
+	Code string `json:"code,omitempty"`
+	// Details are shown on request, e.g. an excerpt of the log.
+	Details []string `json:"details,omitempty"`
+	// Actions are offered as buttons while the launcher waits for a
+	// choice; the chosen ID is posted back to the launcher.
+	Actions []Action `json:"actions,omitempty"`
+}
+
+// Action is one choice offered with a Message.
+type Action struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// InstallProfile is the payload of TypeInstallProfile.
+type InstallProfile struct {
+	Profile  string    `json:"profile"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a plugin or module that needs optional dependencies.
+type Feature struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Profile is the smallest install profile that includes the feature.
+	Profile   string `json:"profile"`
+	Installed bool   `json:"installed"`
+}
+
+// Release is the payload of TypeRelease.
+type Release struct {
+	Tag     string `json:"tag"`
+	Name    string `json:"name,omitempty"`
+	Channel string `json:"channel"`
+	// Published is RFC 3339; empty for the nightly channel.
+	Published string `json:"published,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// Redirect is the payload of TypeRedirect.
+type Redirect struct {
+	URL string `json:"url"`
+}
+
+// Done is the payload of TypeDone, sent once the launcher has finished.
+type Done struct {
+	OK bool `json:"ok"`
+}
+
+// WriteTo writes ev in SSE wire format. encoding/json never emits raw
+// newlines, so the payload always fits on a single data: line.
+func (ev Event) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return 0, fmt.Errorf("marshal %s event: %v", ev.Type, err)
+	}
+	var n int
+	if ev.ID != 0 {
+		n, err = fmt.Fprintf(w, "id: %d\n", ev.ID)
+		if err != nil {
+			return int64(n), err
+		}
+	}
+	m, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	return int64(n + m), err
+}
@@ -0,0 +1,144 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultQueueSize is the number of undelivered events a subscriber may
+// have before the oldest ones are dropped.
+const DefaultQueueSize = 64
+
+// Hub fans events out to subscribers. Every subscriber has its own bounded
+// queue, so a slow browser tab never blocks the launcher or the other
+// clients; when its queue is full the oldest queued event is dropped.
+type Hub struct {
+	mu        sync.Mutex
+	subs      map[*Subscription]struct{}
+	queueSize int
+}
+
+// NewHub creates a hub whose subscribers buffer up to queueSize events.
+func NewHub(queueSize int) *Hub {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	return &Hub{
+		subs:      make(map[*Subscription]struct{}),
+		queueSize: queueSize,
+	}
+}
+
+// Subscribe registers a new subscriber. backlog is queued before any event
+// broadcast after this call; the queue is made large enough to hold all of
+// it, so a reconnecting client gets every event it asked for.
+func (h *Hub) Subscribe(backlog ...Event) *Subscription {
+	sub := &Subscription{
+		size:   max(h.queueSize, len(backlog)),
+		notify: make(chan struct{}, 1),
+	}
+	for _, ev := range backlog {
+		sub.push(ev)
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes sub from the hub. It is safe to call more than once.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	delete(h.subs, sub)
+	h.mu.Unlock()
+	sub.close()
+}
+
+// Broadcast queues ev for every subscriber without blocking.
+func (h *Hub) Broadcast(ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		sub.push(ev)
+	}
+}
+
+// Len returns the number of subscribers.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Subscription is one subscriber's queue.
+type Subscription struct {
+	mu      sync.Mutex
+	queue   []Event
+	size    int
+	dropped int
+	closed  bool
+	notify  chan struct{} // signalled when the queue becomes non-empty
+}
+
+func (s *Subscription) push(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	if len(s.queue) == s.size {
+		s.queue = s.queue[1:]
+		s.dropped++
+	}
+	s.queue = append(s.queue, ev)
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Subscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.queue = nil
+	close(s.notify)
+}
+
+// Next blocks until an event is queued and returns it. It returns false
+// once ctx is done or the subscription was removed from the hub.
+func (s *Subscription) Next(ctx context.Context) (Event, bool) {
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return Event{}, false
+		}
+		if len(s.queue) > 0 {
+			ev := s.queue[0]
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+			return ev, true
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return Event{}, false
+		}
+	}
+}
+
+// Dropped returns how many events were discarded because the subscriber
+// did not keep up.
+func (s *Subscription) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
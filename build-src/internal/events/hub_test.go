@@ -0,0 +1,370 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// drain returns the events queued for sub without waiting for new ones.
+func drain(t *testing.T, sub *Subscription) []Event {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var evs []Event
+	for {
+		ev, ok := sub.Next(ctx)
+		if !ok {
+			return evs
+		}
+		evs = append(evs, ev)
+	}
+}
+
+func ids(evs []Event) []uint64 {
+	out := make([]uint64, len(evs))
+	for i, ev := range evs {
+		out[i] = ev.ID
+	}
+	return out
+}
+
+func equalIDs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHubConcurrentSubscribers(t *testing.T) {
+	const (
+		senders     = 8
+		perSender   = 200
+		subscribers = 64
+	)
+	h := NewHub(16)
+
+	// Every payload is {sender, seq}; a subscriber may miss events but
+	// must see each sender's events in order
+	type payload struct{ sender, seq int }
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan string, subscribers)
+	for i := 0; i < subscribers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sub := h.Subscribe()
+			defer h.Unsubscribe(sub)
+			last := map[int]int{}
+			// Some subscribers leave early, the others read until the end
+			limit := perSender * senders
+			if i%4 == 0 {
+				limit = 10
+			}
+			for n := 0; n < limit; n++ {
+				ev, ok := sub.Next(ctx)
+				if !ok {
+					return
+				}
+				p := ev.Data.(payload)
+				if seq, seen := last[p.sender]; seen && p.seq <= seq {
+					errs <- "events of one sender out of order"
+					return
+				}
+				last[p.sender] = p.seq
+			}
+		}(i)
+	}
+
+	var send sync.WaitGroup
+	for s := 0; s < senders; s++ {
+		send.Add(1)
+		go func(s int) {
+			defer send.Done()
+			for n := 0; n < perSender; n++ {
+				h.Broadcast(Event{ID: uint64(n), Type: TypeLogLine, Data: payload{s, n}})
+				if n%50 == 0 {
+					h.Len()
+				}
+			}
+		}(s)
+	}
+	send.Wait()
+	// Let the readers finish what is queued, then stop the rest
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if n := h.Len(); n != 0 {
+		t.Errorf("Len() = %d after all subscribers left, want 0", n)
+	}
+}
+
+func TestSubscriptionDropsOldest(t *testing.T) {
+	h := NewHub(4)
+	sub := h.Subscribe()
+	for id := uint64(1); id <= 10; id++ {
+		h.Broadcast(Event{ID: id, Type: TypeProgress})
+	}
+	if got, want := ids(drain(t, sub)), []uint64{7, 8, 9, 10}; !equalIDs(got, want) {
+		t.Errorf("queued ids = %v, want %v", got, want)
+	}
+	if n := sub.Dropped(); n != 6 {
+		t.Errorf("Dropped() = %d, want 6", n)
+	}
+
+	// A fast subscriber is not affected by the slow one
+	fast := h.Subscribe()
+	h.Broadcast(Event{ID: 11, Type: TypeProgress})
+	if got := ids(drain(t, fast)); !equalIDs(got, []uint64{11}) {
+		t.Errorf("fast subscriber got %v, want [11]", got)
+	}
+}
+
+func TestUnsubscribeEndsNext(t *testing.T) {
+	h := NewHub(4)
+	sub := h.Subscribe()
+	done := make(chan bool)
+	go func() {
+		_, ok := sub.Next(context.Background())
+		done <- ok
+	}()
+	h.Unsubscribe(sub)
+	h.Unsubscribe(sub)
+	if <-done {
+		t.Error("Next returned an event after Unsubscribe")
+	}
+	h.Broadcast(Event{ID: 1})
+}
+
+func publishN(s *Stream, n int) {
+	for i := 0; i < n; i++ {
+		s.Progress(i, "step")
+	}
+}
+
+func TestStreamReplay(t *testing.T) {
+	tests := []struct {
+		name       string
+		replaySize int
+		published  int
+		lastID     uint64
+		resume     bool
+		wantIDs    []uint64
+		// wantSnapshot is whether the last event is the progress
+		// snapshot rather than a replayed event
+		wantSnapshot bool
+	}{
+		{name: "new client", replaySize: 8, published: 5, wantIDs: []uint64{1, 2, 3, 4, 5, 0}, wantSnapshot: true},
+		{name: "new client after eviction", replaySize: 4, published: 10, wantIDs: []uint64{7, 8, 9, 10, 0}, wantSnapshot: true},
+		{name: "new client before any event", replaySize: 4, published: 0, wantIDs: []uint64{0}, wantSnapshot: true},
+		{name: "resume", replaySize: 8, published: 5, lastID: 2, resume: true, wantIDs: []uint64{3, 4, 5}},
+		{name: "up to date", replaySize: 8, published: 5, lastID: 5, resume: true, wantIDs: nil},
+		{name: "earlier launcher run", replaySize: 8, published: 5, lastID: 40, resume: true, wantIDs: []uint64{1, 2, 3, 4, 5, 0}, wantSnapshot: true},
+		{name: "evicted", replaySize: 4, published: 10, lastID: 2, resume: true, wantIDs: []uint64{7, 8, 9, 10, 0}, wantSnapshot: true},
+		{name: "just evicted", replaySize: 4, published: 10, lastID: 6, resume: true, wantIDs: []uint64{7, 8, 9, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStream(tt.replaySize, "start")
+			publishN(s, tt.published)
+			sub := s.subscribe(tt.lastID, tt.resume)
+			defer s.hub.Unsubscribe(sub)
+
+			evs := drain(t, sub)
+			if got := ids(evs); !equalIDs(got, tt.wantIDs) {
+				t.Fatalf("ids = %v, want %v", got, tt.wantIDs)
+			}
+			if tt.wantSnapshot {
+				p, ok := evs[len(evs)-1].Data.(Progress)
+				if !ok || p != s.Snapshot() {
+					t.Errorf("last event = %+v, want the snapshot %+v", evs[len(evs)-1].Data, s.Snapshot())
+				}
+			}
+		})
+	}
+}
+
+func TestStreamReplayLargerThanQueue(t *testing.T) {
+	s := NewStream(DefaultReplaySize, "start")
+	publishN(s, DefaultReplaySize)
+	if DefaultReplaySize <= DefaultQueueSize {
+		t.Skip("the replay fits into a queue anyway")
+	}
+
+	// The client missed far more events than a queue normally holds
+	sub := s.subscribe(1, true)
+	defer s.hub.Unsubscribe(sub)
+	evs := drain(t, sub)
+	if len(evs) != DefaultReplaySize-1 {
+		t.Fatalf("replayed %d events, want %d", len(evs), DefaultReplaySize-1)
+	}
+	for i, ev := range evs {
+		if ev.ID != uint64(i+2) {
+			t.Fatalf("event %d has id %d, want %d", i, ev.ID, i+2)
+		}
+	}
+	if n := sub.Dropped(); n != 0 {
+		t.Errorf("Dropped() = %d, want 0", n)
+	}
+
+	// Live events after the replay still fit
+	s.Progress(100, "done")
+	if got := ids(drain(t, sub)); !equalIDs(got, []uint64{DefaultReplaySize + 1}) {
+		t.Errorf("live ids = %v", got)
+	}
+}
+
+func TestServeHTTPLastEventID(t *testing.T) {
+	s := NewStream(4, "start")
+	publishN(s, 10)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	// Events are separated by blank lines; the snapshot comes last and
+	// has no id
+	var got []string
+	id := "-"
+	sc := bufio.NewScanner(resp.Body)
+	for len(got) < 5 && sc.Scan() {
+		line := sc.Text()
+		if v, ok := strings.CutPrefix(line, "id: "); ok {
+			id = v
+		}
+		if line == "" {
+			got = append(got, id)
+			id = "-"
+		}
+	}
+	if want := "7 8 9 10 -"; strings.Join(got, " ") != want {
+		t.Errorf("ids = %v, want %s", got, want)
+	}
+}
+
+// TestStreamResumeAfterEviction reconnects a client that missed evicted
+// events twice and checks that it sees every buffered event exactly once
+// and that its progress never goes backwards.
+func TestStreamResumeAfterEviction(t *testing.T) {
+	s := NewStream(4, "start")
+	publishN(s, 3)
+
+	sub := s.subscribe(0, false)
+	first := drain(t, sub)
+	s.hub.Unsubscribe(sub)
+	if got, want := ids(first), []uint64{1, 2, 3, 0}; !equalIDs(got, want) {
+		t.Fatalf("new client got %v, want %v", got, want)
+	}
+	// EventSource keeps its Last-Event-ID for events without an id, so
+	// the client resumes after event 3 and gets nothing twice
+	sub = s.subscribe(3, true)
+	if got := ids(drain(t, sub)); len(got) != 0 {
+		t.Errorf("reconnect after the snapshot got %v, want nothing", got)
+	}
+	s.hub.Unsubscribe(sub)
+
+	// The client saw event 2, then events 3 to 10 were published while it
+	// was away; only 7 to 10 are still buffered
+	publishN(s, 7)
+	s.Publish(TypeWarning, Message{Message: "warning"})
+	sub = s.subscribe(2, true)
+	evs := drain(t, sub)
+	s.hub.Unsubscribe(sub)
+
+	if got, want := ids(evs), []uint64{8, 9, 10, 11, 0}; !equalIDs(got, want) {
+		t.Fatalf("ids = %v, want %v", got, want)
+	}
+	last := -1
+	for _, ev := range evs {
+		if p, ok := ev.Data.(Progress); ok {
+			if p.Progress < last {
+				t.Errorf("progress went back from %d to %d", last, p.Progress)
+			}
+			last = p.Progress
+		}
+	}
+
+	// Resuming from the last real id the client saw replays nothing twice
+	s.Progress(50, "more")
+	sub = s.subscribe(11, true)
+	defer s.hub.Unsubscribe(sub)
+	if got, want := ids(drain(t, sub)), []uint64{12}; !equalIDs(got, want) {
+		t.Errorf("ids after second reconnect = %v, want %v", got, want)
+	}
+}
+
+// TestStreamStickyEvents checks that a client that missed more than is
+// buffered still gets the release and the failure waiting for a choice.
+func TestStreamStickyEvents(t *testing.T) {
+	s := NewStream(4, "start")
+	s.Publish(TypeRelease, Release{Tag: "v1.2.0"})
+	s.Publish(TypeError, Message{Message: "old failure"})
+	s.Publish(TypeWarning, Message{Message: "warning"})
+	publishN(s, 3)
+	s.Publish(TypeError, Message{Message: "npm failed", Actions: []Action{{ID: "retry", Label: "Erneut versuchen"}}})
+	publishN(s, 3)
+
+	tests := []struct {
+		name    string
+		lastID  uint64
+		resume  bool
+		wantIDs []uint64
+	}{
+		// Only the latest error is kept; the warning is not sticky
+		{"new client", 0, false, []uint64{1, 7, 8, 9, 10, 0}},
+		{"saw the release", 1, true, []uint64{7, 8, 9, 10, 0}},
+		{"saw everything evicted", 5, true, []uint64{7, 8, 9, 10, 0}},
+		{"not evicted", 6, true, []uint64{7, 8, 9, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := s.subscribe(tt.lastID, tt.resume)
+			defer s.hub.Unsubscribe(sub)
+			evs := drain(t, sub)
+			if got := ids(evs); !equalIDs(got, tt.wantIDs) {
+				t.Fatalf("ids = %v, want %v", got, tt.wantIDs)
+			}
+		})
+	}
+
+	// Once the failure waiting for a choice has left the buffer, it is
+	// replayed from the sticky events
+	publishN(s, 4)
+	sub := s.subscribe(0, false)
+	defer s.hub.Unsubscribe(sub)
+	evs := drain(t, sub)
+	if got, want := ids(evs), []uint64{1, 7, 11, 12, 13, 14, 0}; !equalIDs(got, want) {
+		t.Fatalf("ids = %v, want %v", got, want)
+	}
+	if m, ok := evs[1].Data.(Message); !ok || len(m.Actions) != 1 {
+		t.Errorf("event 7 = %+v, want the failure with its action", evs[1].Data)
+	}
+}
@@ -0,0 +1,167 @@
+package events
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultReplaySize is the number of events kept for new and reconnecting
+// clients.
+const DefaultReplaySize = 256
+
+// Stream assigns ids to published events, keeps the most recent ones for
+// replay and serves them to /events clients through a Hub.
+type Stream struct {
+	mu     sync.Mutex // guards everything below and orders broadcasts
+	nextID uint64
+	replay []Event // oldest first, at most size entries
+	size   int
+	last   Progress
+	// sticky holds the latest event of each type in stickyTypes, which
+	// clients still need after it has left the replay buffer
+	sticky map[Type]Event
+	hub    *Hub
+}
+
+// stickyTypes are the events a splash page shows until they are replaced:
+// the version being installed, the install profile and the last failure
+// with the remedies it is waiting for.
+var stickyTypes = map[Type]bool{
+	TypeRelease:        true,
+	TypeInstallProfile: true,
+	TypeError:          true,
+}
+
+// NewStream creates a stream that keeps the last replaySize events.
+func NewStream(replaySize int, initialStatus string) *Stream {
+	if replaySize <= 0 {
+		replaySize = DefaultReplaySize
+	}
+	return &Stream{
+		nextID: 1,
+		size:   replaySize,
+		last:   Progress{Status: initialStatus},
+		sticky: make(map[Type]Event),
+		hub:    NewHub(DefaultQueueSize),
+	}
+}
+
+// Publish sends an event of type typ with payload data to all clients.
+func (s *Stream) Publish(typ Type, data interface{}) Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ev := Event{ID: s.nextID, Type: typ, Data: data}
+	s.nextID++
+
+	if p, ok := data.(Progress); ok {
+		s.last = p
+	}
+	if stickyTypes[typ] {
+		s.sticky[typ] = ev
+	}
+	if len(s.replay) == s.size {
+		s.replay = s.replay[1:]
+	}
+	s.replay = append(s.replay, ev)
+
+	s.hub.Broadcast(ev)
+	return ev
+}
+
+// Snapshot returns the most recent progress state.
+func (s *Stream) Snapshot() Progress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Progress publishes a TypeProgress event.
+func (s *Stream) Progress(value int, status string) {
+	s.Publish(TypeProgress, Progress{Progress: value, Status: status})
+}
+
+// subscribe registers a client whose queue starts with the events after
+// lastID. A new client, or one that talked to an earlier launcher run,
+// gets every buffered event, as if it had been connected from the start.
+// If the client missed more than is buffered, the sticky events it missed
+// come first and the current progress last, so that a failure waiting for
+// a choice is never lost and the bar does not move backwards. The progress
+// snapshot carries no id: it is not a replayed event, and a client resuming
+// from it must neither skip nor repeat one.
+func (s *Stream) subscribe(lastID uint64, resume bool) *Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fresh := !resume || lastID >= s.nextID
+	if fresh {
+		lastID = 0
+	}
+	evicted := len(s.replay) == 0 || s.replay[0].ID > lastID+1
+
+	var backlog []Event
+	if evicted {
+		for _, ev := range s.sticky {
+			if ev.ID > lastID && (len(s.replay) == 0 || ev.ID < s.replay[0].ID) {
+				backlog = append(backlog, ev)
+			}
+		}
+		sort.Slice(backlog, func(i, j int) bool { return backlog[i].ID < backlog[j].ID })
+	}
+	for _, ev := range s.replay {
+		if ev.ID > lastID {
+			backlog = append(backlog, ev)
+		}
+	}
+	if fresh || evicted {
+		backlog = append(backlog, Event{Type: TypeProgress, Data: s.last})
+	}
+	return s.hub.Subscribe(backlog...)
+}
+
+// lastEventID reads the id a reconnecting EventSource sends.
+func lastEventID(r *http.Request) (uint64, bool) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("lastEventId")
+	}
+	if v == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// ServeHTTP streams events to a client until it disconnects.
+func (s *Stream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	lastID, resume := lastEventID(r)
+	sub := s.subscribe(lastID, resume)
+	defer s.hub.Unsubscribe(sub)
+
+	// Listen for updates
+	for {
+		ev, ok := sub.Next(r.Context())
+		if !ok {
+			return
+		}
+		if _, err := ev.WriteTo(w); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,177 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// autoFixEnvFile checks if .env exists and creates it from .env.example if missing
+func (l *Launcher) autoFixEnvFile(p *PhaseProgress) error {
+	envPath := filepath.Join(l.appDir, ".env")
+	envExamplePath := filepath.Join(l.appDir, ".env.example")
+
+	// Check if .env already exists
+	if _, err := os.Stat(envPath); err == nil {
+		l.logger.Println("[INFO] .env file already exists")
+		return l.reconcileEnvFile(p, envPath, envExamplePath)
+	}
+
+	// Check if .env.example exists
+	if _, err := os.Stat(envExamplePath); os.IsNotExist(err) {
+		l.logger.Println("[WARNING] .env.example not found, cannot auto-create .env")
+		return fmt.Errorf(".env.example not found")
+	}
+
+	l.logger.Println("[AUTO-FIX] Creating .env from .env.example...")
+	p.Update(0.25, "🔧 Auto-Fix: Erstelle .env Datei...")
+
+	// Read .env.example
+	input, err := os.ReadFile(envExamplePath)
+	if err != nil {
+		l.logger.Printf("[ERROR] Failed to read .env.example: %v\n", err)
+		return err
+	}
+
+	// Write to .env
+	err = os.WriteFile(envPath, input, 0644)
+	if err != nil {
+		l.logger.Printf("[ERROR] Failed to write .env: %v\n", err)
+		return err
+	}
+
+	l.logger.Println("[SUCCESS] .env file created successfully")
+	p.Update(0.5, "✅ .env Datei erstellt!")
+	l.envFileFixed = true // Mark that we fixed the .env file
+	time.Sleep(1 * time.Second)
+
+	return nil
+}
+
+// envBackupTimeFormat is used in the names of .env backups.
+const envBackupTimeFormat = "20060102-150405"
+
+// reconcileEnvFile brings an existing .env up to date with .env.example:
+// settings the example gained since .env was created are appended with
+// their comments, settings the example no longer knows are reported. The
+// user's values, comments and ordering are left alone, and a timestamped
+// backup is written before .env is changed.
+func (l *Launcher) reconcileEnvFile(p *PhaseProgress, envPath, envExamplePath string) error {
+	example, err := readEnvFile(envExamplePath)
+	if os.IsNotExist(err) {
+		l.logger.Println("[INFO] .env.example not found, skipping .env reconciliation")
+		return nil
+	}
+	if err != nil {
+		l.logger.Printf("[ERROR] Failed to read .env.example: %v\n", err)
+		return err
+	}
+	env, err := readEnvFile(envPath)
+	if err != nil {
+		l.logger.Printf("[ERROR] Failed to read .env: %v\n", err)
+		return err
+	}
+
+	var obsolete []string
+	for _, key := range env.Keys() {
+		if _, active := env.Lookup(key); active && !example.Has(key) {
+			obsolete = append(obsolete, key)
+		}
+	}
+	if len(obsolete) > 0 {
+		l.logger.Printf("[WARNING] .env contains settings unknown to .env.example: %s\n", strings.Join(obsolete, ", "))
+		l.showWarning(fmt.Sprintf("Veraltete Einstellungen in .env (werden evtl. ignoriert): %s", strings.Join(obsolete, ", ")))
+	}
+
+	var added []string
+	var block []string
+	for _, key := range example.Keys() {
+		if env.Has(key) {
+			continue
+		}
+		added = append(added, key)
+		// Settings with their own description get a blank line before them
+		lines := example.block(key)
+		if len(lines) > 1 || len(block) == 0 {
+			block = append(block, "")
+		}
+		block = append(block, lines...)
+	}
+	if len(added) == 0 {
+		l.logger.Println("[INFO] .env is up to date with .env.example")
+		return nil
+	}
+
+	l.logger.Printf("[AUTO-FIX] Adding %d new settings to .env: %s\n", len(added), strings.Join(added, ", "))
+	p.Update(0.25, "🔧 Auto-Fix: Ergänze neue Einstellungen in .env...")
+
+	info, err := os.Stat(envPath)
+	if err != nil {
+		return err
+	}
+	backupPath := fmt.Sprintf("%s.backup-%s", envPath, time.Now().Format(envBackupTimeFormat))
+	original, err := os.ReadFile(envPath)
+	if err == nil {
+		err = os.WriteFile(backupPath, original, info.Mode().Perm())
+	}
+	if err != nil {
+		l.logger.Printf("[ERROR] Failed to back up .env, leaving it unchanged: %v\n", err)
+		return err
+	}
+	l.logger.Printf("[INFO] Backup of .env written to %s\n", backupPath)
+
+	env.Append("")
+	env.Append(fmt.Sprintf("# Neu aus .env.example übernommen am %s", time.Now().Format("2006-01-02 15:04")))
+	env.Append(block[1:]...)
+	if err := os.WriteFile(envPath, env.Bytes(), info.Mode().Perm()); err != nil {
+		l.logger.Printf("[ERROR] Failed to write .env: %v\n", err)
+		return err
+	}
+
+	msg := fmt.Sprintf("%d neue Einstellungen hinzugefügt", len(added))
+	if len(added) == 1 {
+		msg = "1 neue Einstellung hinzugefügt"
+	}
+	l.logger.Printf("[SUCCESS] .env updated: %s\n", strings.Join(added, ", "))
+	p.Update(0.5, "✅ .env: "+msg)
+	time.Sleep(1 * time.Second)
+
+	return nil
+}
+
+// autoFixPort picks the port the server is started on: PORT from .env
+// (default 3000), or the next free port if that one is taken.
+func (l *Launcher) autoFixPort(p *PhaseProgress) {
+	preferred := l.configuredPort()
+	l.port = preferred
+	l.logger.Printf("[INFO] Checking if port %d is available...\n", preferred)
+
+	if PortAvailable(preferred) {
+		l.logger.Printf("[SUCCESS] Port %d is available\n", preferred)
+		return
+	}
+
+	l.logger.Printf("[WARNING] Port %d is already in use\n", preferred)
+
+	// Check if server is already running on the preferred port
+	if l.checkServerHealthOnPort(preferred) {
+		l.logger.Printf("[INFO] Server is already running on port %d\n", preferred)
+		p.Update(0.5, fmt.Sprintf("ℹ️ Server läuft bereits auf Port %d", preferred))
+	}
+
+	port := FreePort(preferred)
+	if port == 0 {
+		l.logger.Printf("[WARNING] No free port in %d-%d, trying %d anyway\n", preferred+1, preferred+portSearchRange, preferred)
+		l.showWarning(fmt.Sprintf("Port %d belegt und kein freier Ausweich-Port gefunden", preferred))
+		return
+	}
+
+	l.port = port
+	l.logger.Printf("[AUTO-FIX] Starting server on port %d instead\n", port)
+	msg := fmt.Sprintf("Port %d belegt - Server nutzt Port %d", preferred, port)
+	p.Update(0.75, "⚠️ "+msg)
+	l.showWarning(msg)
+	time.Sleep(2 * time.Second)
+}
@@ -0,0 +1,98 @@
+package launcher
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+	"github.com/pkg/browser"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+// ConsoleSink prints progress as colored lines to a terminal and opens the
+// dashboard in the default browser once the server is ready.
+type ConsoleSink struct {
+	Out io.Writer
+
+	mu   sync.Mutex // progress arrives from the npm output goroutines too
+	last string
+}
+
+func (c *ConsoleSink) Progress(value int, status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if status == c.last {
+		return
+	}
+	c.last = status
+
+	color := colorCyan
+	switch {
+	case len(status) >= 6 && status[:6] == "FEHLER":
+		color = colorRed
+	case value == 100:
+		color = colorGreen
+	}
+	fmt.Fprintf(c.Out, "%s[%3d%%] %s%s\n", color, value, status, colorReset)
+}
+
+func (c *ConsoleSink) Redirect(url string) {
+	fmt.Fprintf(c.Out, "%sDashboard: %s%s\n", colorGreen, url, colorReset)
+	if err := browser.OpenURL(url); err != nil {
+		fmt.Fprintf(c.Out, "%sBrowser konnte nicht geöffnet werden: %v%s\n", colorYellow, err, colorReset)
+	}
+}
+
+// Event prints warnings and the plugins the install profile leaves out.
+// Npm and server output already reaches the console directly, so the other
+// event types are ignored.
+func (c *ConsoleSink) Event(typ events.Type, data interface{}) {
+	switch data := data.(type) {
+	case events.Message:
+		if typ == events.TypeWarning {
+			fmt.Fprintf(c.Out, "%sWARNUNG: %s%s\n", colorYellow, data.Message, colorReset)
+		}
+	case events.InstallProfile:
+		for _, f := range data.Features {
+			if !f.Installed {
+				fmt.Fprintf(c.Out, "%sInstallationsprofil %s: %s benötigt Profil %q%s\n", colorYellow, data.Profile, f.Title, f.Profile, colorReset)
+			}
+		}
+	}
+}
+
+// PromptRemedy returns a Config.ChooseRemedy for terminals: it explains
+// the npm failure on out and reads the number of a remedy from in.
+func PromptRemedy(in io.Reader, out io.Writer) func(f NpmFailure) Remedy {
+	reader := bufio.NewReader(in)
+	return func(f NpmFailure) Remedy {
+		fmt.Fprintf(out, "\n%s%s%s\n", colorRed, f.Status(), colorReset)
+		for _, line := range f.hintLines() {
+			fmt.Fprintln(out, line)
+		}
+		fmt.Fprintln(out)
+		for i, r := range f.Remedies {
+			fmt.Fprintf(out, "  %d) %s\n", i+1, r.Label())
+		}
+		fmt.Fprintln(out, "  0) Abbrechen")
+		fmt.Fprint(out, "Auswahl: ")
+
+		line, _ := reader.ReadString('\n')
+		n, err := strconv.Atoi(strings.TrimSpace(line))
+		if err != nil || n < 1 || n > len(f.Remedies) {
+			return ""
+		}
+		return f.Remedies[n-1]
+	}
+}
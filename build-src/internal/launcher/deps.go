@@ -0,0 +1,199 @@
+package launcher
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+)
+
+// npmCommand builds an npm invocation that works on every platform and
+// uses the npm that belongs to the chosen Node.js.
+func (l *Launcher) npmCommand(args ...string) *exec.Cmd {
+	var cmd *exec.Cmd
+	npm := filepath.Join(filepath.Dir(l.nodePath), "npm")
+	switch {
+	case runtime.GOOS == "windows":
+		// cmd looks npm up on the PATH from nodeEnv
+		cmd = exec.Command("cmd", append([]string{"/C", "npm"}, args...)...)
+	case l.nodePath != "" && fileExists(npm):
+		cmd = exec.Command(npm, args...)
+	default:
+		cmd = exec.Command("npm", args...)
+	}
+	cmd.Env = append(l.nodeEnv(), l.profileEnv()...)
+	return cmd
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// npmOutputLines is roughly how many output lines a full install prints;
+// it is only used to estimate progress when npm reports nothing structured.
+const npmOutputLines = 70
+
+// npmOutputKept is how many lines of npm output are kept to classify a
+// failure.
+const npmOutputKept = 200
+
+// installDependencies runs npm with args ("install", "ci" or "rebuild") in
+// the app directory and reports its output as progress.
+func (l *Launcher) installDependencies(p *PhaseProgress, args ...string) error {
+	label := "npm " + args[0]
+	l.logger.Printf("[INFO] Starting %s...\n", strings.Join(append([]string{"npm"}, args...), " "))
+	p.Status(fmt.Sprintf("%s wird gestartet...", label))
+
+	// Show initial warning about potential delay
+	p.Status(fmt.Sprintf("HINWEIS: %s kann mehrere Minuten dauern, besonders bei langsamer Internetverbindung. Bitte warten...", label))
+
+	var progress *npmProgress
+	if args[0] == "ci" {
+		progress = newNpmProgress(l.lockfileCounts(l.depsPlan.current.Platform, l.profile() == ProfileMinimal))
+	} else {
+		progress = newNpmProgress(0, 0)
+	}
+	started := time.Now()
+
+	cmd := l.npmCommand(append(append([]string{}, args...), npmProgressArgs...)...)
+	cmd.Dir = l.appDir
+
+	// Capture output for logging and progress updates
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("Failed to create stdout pipe: %v", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("Failed to create stderr pipe: %v", err)
+	}
+
+	// Start the command
+	if err := cmd.Start(); err != nil {
+		l.logger.Printf("[ERROR] Failed to start %s: %v\n", label, err)
+		return fmt.Errorf("Failed to start %s: %v", label, err)
+	}
+
+	// Track progress with live updates
+	var mu sync.Mutex
+	var output []string // for ClassifyNpmFailure
+	lastUpdate := time.Now()
+	lastStatus := ""
+	installDone := make(chan struct{})
+
+	// report shows npm's structured progress, or line when npm reports
+	// nothing structured
+	report := func(line string) {
+		status := progress.Status()
+		if status == "" {
+			if line == "" {
+				return
+			}
+			// Don't truncate - show full line for better visibility
+			if len(line) > 120 {
+				line = line[:117] + "..."
+			}
+			status = line
+		}
+		mu.Lock()
+		lastUpdate = time.Now()
+		changed := status != lastStatus
+		lastStatus = status
+		mu.Unlock()
+		if changed {
+			p.Update(progress.Fraction(), fmt.Sprintf("%s: %s", label, status))
+		}
+	}
+
+	// Heartbeat ticker to show activity even when npm produces no output
+	heartbeatTicker := time.NewTicker(3 * time.Second)
+	defer heartbeatTicker.Stop()
+
+	// Channels to signal when stdout/stderr reading is done
+	stdoutDone := make(chan bool)
+	stderrDone := make(chan bool)
+
+	forward := func(r io.Reader, source string, done chan<- bool) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := scanner.Text()
+			l.logger.Printf("[%s] %s\n", source, line)
+			if progress.Line(line) {
+				// Consumed as progress; it is in the log file
+				report("")
+				continue
+			}
+			mu.Lock()
+			if output = append(output, line); len(output) > npmOutputKept {
+				output = output[len(output)-npmOutputKept:]
+			}
+			mu.Unlock()
+			emit(l.sink, events.TypeLogLine, events.LogLine{Source: strings.TrimSuffix(source, " stdout"), Line: line})
+			if l.cfg.Console != nil {
+				fmt.Fprintln(l.cfg.Console, line)
+			}
+			if len(line) > 0 && source == "npm stdout" {
+				report(line)
+			}
+		}
+		done <- true
+	}
+	go forward(stdout, "npm stdout", stdoutDone)
+	go forward(stderr, "npm stderr", stderrDone)
+
+	// Heartbeat goroutine to show activity
+	go func() {
+		for {
+			select {
+			case <-installDone:
+				return
+			case <-heartbeatTicker.C:
+				mu.Lock()
+				idle := time.Since(lastUpdate)
+				mu.Unlock()
+				if idle < 3*time.Second {
+					continue
+				}
+				if status := progress.Status(); status != "" {
+					// Keeps the running time of native builds current
+					p.Update(progress.Fraction(), fmt.Sprintf("%s: %s", label, status))
+					continue
+				}
+				// If no output for more than 3 seconds, show activity indicator
+				frac := math.Max(progress.Fraction(), 0.15) // Show some progress during install
+				elapsed := int(idle.Seconds())
+				p.Update(frac, fmt.Sprintf("%s läuft... (%ds) - Bitte warten, Downloads können mehrere Minuten dauern", label, elapsed))
+			}
+		}
+	}()
+
+	// Wait for output processing to complete, then for the command
+	<-stdoutDone
+	<-stderrDone
+	err = cmd.Wait()
+	close(installDone)
+
+	for _, line := range progress.Summary(10) {
+		l.logger.Printf("[INFO] %s\n", line)
+	}
+
+	if err != nil {
+		failure := ClassifyNpmFailure(output, nodeRequirements(l.appDir))
+		l.logger.Printf("[ERROR] %s failed after %v: %v\n", label, time.Since(started).Round(time.Second), err)
+		l.logger.Printf("[ERROR] Classified as %s: %s\n", failure.Code, failure.Title)
+		return &npmError{label: label, failure: failure, err: err}
+	}
+
+	l.logger.Printf("[SUCCESS] %s completed successfully in %v\n", label, time.Since(started).Round(time.Second))
+	return nil
+}
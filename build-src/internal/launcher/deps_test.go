@@ -0,0 +1,195 @@
+package launcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeToolchain writes node and npm scripts into a new directory and
+// returns the path of node. npm prints npmOutput and exits with npmExit.
+func fakeToolchain(t *testing.T, npmOutput string, npmExit string) string {
+	t.Helper()
+	dir := t.TempDir()
+	node := `#!/bin/sh
+case "$1" in
+-p) echo "v20.11.0 115 linux-x64" ;;
+*) echo v20.11.0 ;;
+esac
+`
+	npm := "#!/bin/sh\ncat <<'EOF'\n" + npmOutput + "\nEOF\nexit " + npmExit + "\n"
+	for name, script := range map[string]string{"node": node, "npm": npm} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "node")
+}
+
+func TestPrepareDependencies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake node and npm are shell scripts")
+	}
+	tests := []struct {
+		name     string
+		npmExit  string
+		output   string
+		wantCode string
+	}{
+		{"installed", "0", "added 0 packages in 1s", ""},
+		{"ERESOLVE", "1", "npm ERR! code ERESOLVE\nnpm ERR! ERESOLVE unable to resolve dependency tree", "NPM_ERESOLVE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(appDir, "package.json"), []byte(`{"name": "app"}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			// The snapshot cache is next to the test binary and shared
+			// between the cases
+			settings := DefaultSettings()
+			settings.Snapshots.Enabled = false
+			sink := &recordingSink{}
+			l := New(Config{AppDir: appDir, Settings: settings, NodePath: fakeToolchain(t, tt.output, tt.npmExit)}, sink)
+			err := l.PrepareDependencies()
+
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("PrepareDependencies() = %v", err)
+				}
+				if got := sink.values[len(sink.values)-1]; got != 100 {
+					t.Errorf("last progress = %d, want 100", got)
+				}
+				return
+			}
+			var lerr *Error
+			if !errors.As(err, &lerr) || lerr.Code != tt.wantCode {
+				t.Fatalf("PrepareDependencies() = %v, want an *Error with code %s", err, tt.wantCode)
+			}
+			if len(lerr.Details) == 0 {
+				t.Error("Details do not hold npm's error output")
+			}
+			if want := "phase-end deps:error"; sink.events[len(sink.events)-1] != want {
+				t.Errorf("events = %q, want them to end with %q", sink.events, want)
+			}
+		})
+	}
+}
+
+func TestPrepareDependenciesWithoutNode(t *testing.T) {
+	l := New(Config{AppDir: t.TempDir()}, discardSink{})
+	if err := l.PrepareDependencies(); err == nil {
+		t.Error("PrepareDependencies ran without Config.NodePath")
+	}
+}
+
+func TestPlanDependencies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake node is a shell script")
+	}
+	nodePath := fakeToolchain(t, "", "0")
+
+	tests := []struct {
+		name string
+		// setup prepares node_modules; current is the fingerprint of the
+		// app and the fake node (v20.11.0, ABI 115, linux-x64)
+		setup      func(l *Launcher, current depsFingerprint)
+		wantAction depsAction
+		wantUpdate bool
+	}{
+		{
+			name:       "no node_modules",
+			setup:      func(*Launcher, depsFingerprint) {},
+			wantAction: depsInstall,
+		},
+		{
+			name: "interrupted install",
+			setup: func(l *Launcher, _ depsFingerprint) {
+				os.MkdirAll(l.nodeModulesDir(), 0755)
+			},
+			wantAction: depsInstall,
+		},
+		{
+			name: "adopted from an older launcher",
+			setup: func(l *Launcher, _ depsFingerprint) {
+				os.MkdirAll(l.nodeModulesDir(), 0755)
+				os.WriteFile(filepath.Join(l.nodeModulesDir(), ".package-lock.json"), []byte("{}"), 0644)
+			},
+			// Adopted installations hold every optional package
+			wantAction: depsProfile,
+			wantUpdate: true,
+		},
+		{
+			name:       "up to date",
+			setup:      func(l *Launcher, fp depsFingerprint) { l.writeDepsFingerprint(fp) },
+			wantAction: depsOK,
+		},
+		{
+			name: "lockfile changed",
+			setup: func(l *Launcher, fp depsFingerprint) {
+				fp.LockHash = "old"
+				l.writeDepsFingerprint(fp)
+			},
+			wantAction: depsInstall,
+		},
+		{
+			name: "other platform",
+			// Checked before the lockfile
+			setup: func(l *Launcher, fp depsFingerprint) {
+				fp.Platform, fp.LockHash = "win32-x64", "old"
+				l.writeDepsFingerprint(fp)
+			},
+			wantAction: depsReinstall,
+		},
+		{
+			name: "profile changed",
+			// Checked before the ABI
+			setup: func(l *Launcher, fp depsFingerprint) {
+				fp.Profile, fp.NodeABI = ProfileFull, "108"
+				l.writeDepsFingerprint(fp)
+			},
+			wantAction: depsProfile,
+		},
+		{
+			name: "new Node.js ABI",
+			setup: func(l *Launcher, fp depsFingerprint) {
+				fp.NodeVersion, fp.NodeABI = "v18.19.0", "108"
+				l.writeDepsFingerprint(fp)
+			},
+			wantAction: depsRebuild,
+		},
+		{
+			name: "Node.js patch update",
+			setup: func(l *Launcher, fp depsFingerprint) {
+				fp.NodeVersion = "v20.10.0"
+				l.writeDepsFingerprint(fp)
+			},
+			wantAction: depsOK,
+			wantUpdate: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(appDir, "package.json"), []byte(`{"name": "app"}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			l := New(Config{AppDir: appDir, Settings: DefaultSettings(), NodePath: nodePath}, discardSink{})
+			current, err := l.currentFingerprint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.setup(l, current)
+
+			plan := l.planDependencies()
+			if plan.action != tt.wantAction {
+				t.Errorf("action = %v (%s), want %v", plan.action, plan.reason, tt.wantAction)
+			}
+			if got := plan.update != nil; got != tt.wantUpdate {
+				t.Errorf("fingerprint update = %v, want %v", got, tt.wantUpdate)
+			}
+		})
+	}
+}
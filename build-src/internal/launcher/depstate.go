@@ -0,0 +1,247 @@
+package launcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// depsFingerprintFile is written into node_modules after every successful
+// install, so that it disappears together with the installation.
+const depsFingerprintFile = ".launcher-deps.json"
+
+// depsFingerprint describes what node_modules was installed for.
+type depsFingerprint struct {
+	// Lockfile is the file LockHash was taken from: package-lock.json, or
+	// package.json for apps without a lockfile.
+	Lockfile    string `json:"lockfile"`
+	LockHash    string `json:"lockHash"`
+	NodeVersion string `json:"nodeVersion"`
+	NodeABI     string `json:"nodeAbi"`
+	Platform    string `json:"platform"`
+	// Profile is the install profile; installations recorded before
+	// profiles existed contain every optional package.
+	Profile   InstallProfile `json:"profile,omitempty"`
+	Installed time.Time      `json:"installed"`
+}
+
+func (fp depsFingerprint) profile() InstallProfile {
+	if fp.Profile == "" {
+		return ProfileFull
+	}
+	return fp.Profile
+}
+
+type depsAction int
+
+const (
+	depsOK depsAction = iota
+	// depsInstall runs npm ci, or npm install without a lockfile
+	depsInstall
+	// depsRebuild recompiles native modules for a new Node.js ABI
+	depsRebuild
+	// depsReinstall deletes node_modules before installing
+	depsReinstall
+	// depsProfile adds or removes optional packages for a new install
+	// profile
+	depsProfile
+)
+
+func (a depsAction) String() string {
+	switch a {
+	case depsInstall:
+		return "install"
+	case depsRebuild:
+		return "rebuild"
+	case depsReinstall:
+		return "reinstall"
+	case depsProfile:
+		return "profile"
+	}
+	return "no-op"
+}
+
+// depsPlan is what the deps phase has to do, and why.
+type depsPlan struct {
+	action  depsAction
+	reason  string
+	current depsFingerprint
+	// recorded is the fingerprint of node_modules, if there is one
+	recorded depsFingerprint
+	// update is written by the launcher before it acts on the plan: the
+	// fingerprint of adopted node_modules, or a new Node.js patch version
+	update *depsFingerprint
+	// adopted is set for node_modules installed before the launcher
+	// recorded fingerprints
+	adopted bool
+}
+
+func (l *Launcher) nodeModulesDir() string {
+	return filepath.Join(l.appDir, "node_modules")
+}
+
+func (l *Launcher) hasLockfile() bool {
+	return fileExists(filepath.Join(l.appDir, "package-lock.json"))
+}
+
+// currentFingerprint describes the app and the chosen Node.js as they are
+// now.
+func (l *Launcher) currentFingerprint() (depsFingerprint, error) {
+	fp := depsFingerprint{Lockfile: "package-lock.json", Profile: l.profile()}
+	if !l.hasLockfile() {
+		fp.Lockfile = "package.json"
+	}
+	data, err := os.ReadFile(filepath.Join(l.appDir, fp.Lockfile))
+	if err != nil {
+		return fp, err
+	}
+	sum := sha256.Sum256(data)
+	fp.LockHash = hex.EncodeToString(sum[:])
+
+	output, err := exec.Command(l.nodePath, "-p", `[process.version, process.versions.modules, process.platform + "-" + process.arch].join(" ")`).Output()
+	if err != nil {
+		return fp, fmt.Errorf("Node.js-Details nicht lesbar: %v", err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 3 {
+		return fp, fmt.Errorf("unerwartete Ausgabe von node: %q", output)
+	}
+	fp.NodeVersion, fp.NodeABI, fp.Platform = fields[0], fields[1], fields[2]
+	return fp, nil
+}
+
+func (l *Launcher) readDepsFingerprint() (depsFingerprint, bool) {
+	var fp depsFingerprint
+	data, err := os.ReadFile(filepath.Join(l.nodeModulesDir(), depsFingerprintFile))
+	if err != nil {
+		return fp, false
+	}
+	if err := json.Unmarshal(data, &fp); err != nil {
+		l.logger.Printf("[WARNING] Ignoring unreadable %s: %v\n", depsFingerprintFile, err)
+		return fp, false
+	}
+	return fp, true
+}
+
+func (l *Launcher) writeDepsFingerprint(fp depsFingerprint) {
+	fp.Installed = time.Now()
+	data, err := json.MarshalIndent(fp, "", "  ")
+	if err == nil {
+		// npm does not create node_modules for an app without dependencies
+		err = os.MkdirAll(l.nodeModulesDir(), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(l.nodeModulesDir(), depsFingerprintFile), data, 0644)
+	}
+	if err != nil {
+		l.logger.Printf("[WARNING] Could not write %s: %v\n", depsFingerprintFile, err)
+	}
+}
+
+// planDependencies compares node_modules with the recorded fingerprint. It
+// changes nothing, so that the doctor can use it; fingerprint updates are
+// returned in plan.update.
+func (l *Launcher) planDependencies() depsPlan {
+	current, err := l.currentFingerprint()
+	if err != nil {
+		l.logger.Printf("[WARNING] Cannot fingerprint dependencies: %v\n", err)
+	}
+	plan := depsPlan{current: current}
+
+	info, statErr := os.Stat(l.nodeModulesDir())
+	if statErr != nil || !info.IsDir() {
+		plan.action, plan.reason = depsInstall, "node_modules fehlt"
+		return plan
+	}
+	if err != nil {
+		// Without a fingerprint to compare keep the old behaviour
+		plan.reason = "node_modules vorhanden"
+		return plan
+	}
+
+	recorded, ok := l.readDepsFingerprint()
+	if !ok {
+		if !l.npmFinishedInstall() {
+			plan.action, plan.reason = depsInstall, "Letzte Installation unvollständig"
+			return plan
+		}
+		// Installed before the launcher recorded fingerprints, with every
+		// optional package
+		recorded = current
+		recorded.Profile = ""
+		plan.update, plan.adopted = &recorded, true
+	}
+	plan.recorded = recorded
+
+	switch {
+	case recorded.Platform != current.Platform:
+		plan.action = depsReinstall
+		plan.reason = fmt.Sprintf("Plattform geändert (%s → %s)", recorded.Platform, current.Platform)
+	case recorded.Lockfile != current.Lockfile || recorded.LockHash != current.LockHash:
+		plan.action = depsInstall
+		plan.reason = fmt.Sprintf("%s geändert", current.Lockfile)
+	case recorded.profile() != current.Profile:
+		// Before the ABI check: the native phase rebuilds stale modules
+		// after the switch anyway
+		plan.action = depsProfile
+		plan.reason = fmt.Sprintf("Installationsprofil %s → %s", recorded.profile(), current.Profile)
+	case recorded.NodeABI != current.NodeABI:
+		plan.action = depsRebuild
+		plan.reason = fmt.Sprintf("Node.js %s → %s (ABI %s → %s)", recorded.NodeVersion, current.NodeVersion, recorded.NodeABI, current.NodeABI)
+	default:
+		if recorded.NodeVersion != current.NodeVersion {
+			// Same ABI; native modules keep working
+			plan.update = &current
+		}
+		plan.reason = "Abhängigkeiten aktuell"
+	}
+	return plan
+}
+
+// npmFinishedInstall reports whether npm completed the installation in
+// node_modules: npm 7+ writes node_modules/.package-lock.json at the end,
+// and it must not be older than package-lock.json.
+func (l *Launcher) npmFinishedInstall() bool {
+	hidden, err := os.Stat(filepath.Join(l.nodeModulesDir(), ".package-lock.json"))
+	if err != nil {
+		return false
+	}
+	lock, err := os.Stat(filepath.Join(l.appDir, "package-lock.json"))
+	return err != nil || !hidden.ModTime().Before(lock.ModTime())
+}
+
+// dependenciesUpToDate is the skip check of the deps phase; it keeps the
+// plan for phaseDeps.
+func (l *Launcher) dependenciesUpToDate() bool {
+	l.reportInstallProfile()
+	l.depsPlan = l.planDependencies()
+	if l.depsPlan.adopted {
+		l.logger.Println("[INFO] Adopting existing node_modules without fingerprint")
+	}
+	if l.depsPlan.update != nil {
+		l.writeDepsFingerprint(*l.depsPlan.update)
+	}
+	l.logAndSync("[INFO] Dependencies: %v - %s", l.depsPlan.action, l.depsPlan.reason)
+	return l.depsPlan.action == depsOK
+}
+
+// npmArgs returns the npm arguments for the plan, including those added
+// by remedies.
+func (l *Launcher) npmArgs(plan depsPlan) []string {
+	var args []string
+	switch {
+	case plan.action == depsRebuild:
+		args = []string{"rebuild"}
+	case l.hasLockfile():
+		args = append([]string{"ci", "--cache", "false"}, l.profileArgs()...)
+	default:
+		args = append([]string{"install", "--cache", "false"}, l.profileArgs()...)
+	}
+	return append(args, l.npmExtraArgs...)
+}
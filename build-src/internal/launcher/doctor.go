@@ -0,0 +1,118 @@
+package launcher
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// DoctorFile is the name of the report Doctor saves in the app's logs
+// directory.
+const DoctorFile = "doctor.txt"
+
+// Doctor checks the environment without starting the app: Node.js, npm,
+// the state of node_modules, the build tools and whether the native
+// modules can be installed without compiling. The report is written to w
+// and saved as logs/doctor.txt in the app directory, for support requests.
+// It reports whether no problem was found.
+func (l *Launcher) Doctor(w io.Writer) bool {
+	var report strings.Builder
+	ok := l.writeDoctorReport(&report)
+	io.WriteString(w, report.String())
+
+	path := filepath.Join(l.appDir, "logs", DoctorFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		if err := os.WriteFile(path, []byte(report.String()), 0644); err == nil {
+			fmt.Fprintf(w, "\nBericht gespeichert: %s\n", path)
+		}
+	}
+	return ok
+}
+
+func (l *Launcher) writeDoctorReport(w io.Writer) bool {
+	ok := true
+	fail := func(format string, args ...interface{}) {
+		ok = false
+		fmt.Fprintf(w, "  ✗ "+format+"\n", args...)
+	}
+
+	fmt.Fprintf(w, "Launcher-Diagnose vom %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "System: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(w, "App: %s\n\n", l.appDir)
+
+	fmt.Fprintln(w, "Node.js:")
+	if err := l.checkNodeJS(); err != nil {
+		fail("%v", err)
+		return false
+	}
+	compat := CheckNodeVersion(l.appDir, l.getNodeVersion())
+	fmt.Fprintf(w, "  %s (%s)\n", l.nodePath, compat.Version)
+	if compat.Reason != "" {
+		fmt.Fprintf(w, "  %s\n", compat.Reason)
+	}
+	if compat.Support == NodeTooOld || compat.Support == NodeTooNew {
+		fail("Version passt nicht zu engines.node %s", compat.Range)
+	}
+	if output, err := l.npmCommand("--version").Output(); err == nil {
+		fmt.Fprintf(w, "  npm %s\n", strings.TrimSpace(string(output)))
+	} else {
+		fail("npm nicht ausführbar: %v", err)
+	}
+
+	fmt.Fprintln(w, "\nAbhängigkeiten:")
+	l.depsPlan = l.planDependencies()
+	fp := l.depsPlan.current
+	fmt.Fprintf(w, "  Profil %s, ABI %s, %s\n", l.profile(), fp.NodeABI, fp.Platform)
+	fmt.Fprintf(w, "  Beim nächsten Start: %v - %s\n", l.depsPlan.action, l.depsPlan.reason)
+	if c := l.snapshots(); c != nil {
+		fmt.Fprintf(w, "  Snapshots: %s\n", c.describe())
+	}
+	if fp.NodeABI != "" && fileExists(filepath.Join(l.nodeModulesDir(), depsFingerprintFile)) {
+		if stale := l.staleNativeModules(fp.NodeABI); len(stale) > 0 {
+			fail("Für ein anderes Node.js gebaut: %s", strings.Join(modulePackages(stale), ", "))
+		}
+	}
+
+	fmt.Fprintln(w, "\nBuild-Werkzeuge:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	tools := l.buildTools()
+	for _, t := range tools {
+		if t.found() {
+			fmt.Fprintf(tw, "  ✓ %s\t%s\t%s\n", t.Name, t.Path, t.Version)
+		} else {
+			fmt.Fprintf(tw, "  ✗ %s\tfehlt\t%s\n", t.Name, t.Install)
+		}
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nNative Module:")
+	var compile []string
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, b := range l.plannedNativeBuilds(nil) {
+		mark := map[prebuiltStatus]string{prebuiltAvailable: "✓", prebuiltMissing: "!", prebuiltUnknown: "?"}[b.Status]
+		fmt.Fprintf(tw, "  %s %s\t%s\t%s\n", mark, b.Package, b.Version, b.Detail)
+		if b.Status == prebuiltMissing {
+			compile = append(compile, b.Package)
+		}
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nErgebnis:")
+	switch {
+	case len(compile) == 0:
+		fmt.Fprintln(w, "  Kein Quellbuild nötig")
+	case len(tools.missing()) == 0:
+		fmt.Fprintf(w, "  %s wird aus dem Quellcode gebaut; die Build-Werkzeuge sind vorhanden\n", strings.Join(compile, ", "))
+	default:
+		fail("%s muss aus dem Quellcode gebaut werden, es fehlt aber: %s", strings.Join(compile, ", "), strings.Join(tools.missing(), ", "))
+	}
+	if ok {
+		fmt.Fprintln(w, "  ✓ Keine Probleme gefunden")
+	}
+	return ok
+}
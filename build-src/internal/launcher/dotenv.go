@@ -0,0 +1,171 @@
+package launcher
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	// envKeyRe matches an assignment; envCommentedKeyRe a disabled one as
+	// used throughout .env.example ("# LOG_LEVEL=info"). The latter is
+	// stricter so that prose comments are not taken for keys.
+	envKeyRe          = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_.-]*)\s*=(.*)$`)
+	envCommentedKeyRe = regexp.MustCompile(`^\s*#\s*(?:export\s+)?([A-Z_][A-Z0-9_]*)=(.*)$`)
+)
+
+// envLine is one line of a dotenv file. Lines that are neither assignments
+// nor commented-out assignments have an empty key.
+type envLine struct {
+	raw       string
+	key       string
+	value     string
+	commented bool
+}
+
+// envFile is a dotenv file that can be written back unchanged apart from
+// the edits made to it: comments, blank lines and ordering are kept.
+type envFile struct {
+	lines []envLine
+	crlf  bool
+}
+
+func parseEnv(data []byte) *envFile {
+	f := &envFile{crlf: bytes.Contains(data, []byte("\r\n"))}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return f
+	}
+	for _, raw := range strings.Split(text, "\n") {
+		f.lines = append(f.lines, parseEnvLine(raw))
+	}
+	return f
+}
+
+func parseEnvLine(raw string) envLine {
+	line := envLine{raw: raw}
+	if m := envCommentedKeyRe.FindStringSubmatch(raw); m != nil {
+		line.key, line.value, line.commented = m[1], unquoteEnvValue(m[2]), true
+	} else if !strings.HasPrefix(strings.TrimSpace(raw), "#") {
+		if m := envKeyRe.FindStringSubmatch(raw); m != nil {
+			line.key, line.value = m[1], unquoteEnvValue(m[2])
+		}
+	}
+	return line
+}
+
+// unquoteEnvValue strips quotes, or for unquoted values an inline comment,
+// the way dotenv does. A quote escaped with a backslash does not end the
+// value, and in double quotes \n and \r stand for line breaks.
+func unquoteEnvValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && strings.ContainsRune(`"'`+"`", rune(value[0])) {
+		quote := value[0]
+		for i := 1; i < len(value); i++ {
+			switch value[i] {
+			case '\\':
+				i++
+			case quote:
+				inner := value[1:i]
+				if quote == '"' {
+					inner = strings.NewReplacer(`\n`, "\n", `\r`, "\r").Replace(inner)
+				}
+				return inner
+			}
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+func readEnvFile(path string) (*envFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseEnv(data), nil
+}
+
+// Lookup returns the value of the active assignment of key. As in dotenv
+// the first assignment wins.
+func (f *envFile) Lookup(key string) (string, bool) {
+	for _, line := range f.lines {
+		if line.key == key && !line.commented {
+			return line.value, true
+		}
+	}
+	return "", false
+}
+
+// Has reports whether key appears in the file, active or commented out.
+func (f *envFile) Has(key string) bool {
+	for _, line := range f.lines {
+		if line.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns the keys in order of first appearance, active and commented
+// out ones alike.
+func (f *envFile) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, line := range f.lines {
+		if line.key != "" && !seen[line.key] {
+			seen[line.key] = true
+			keys = append(keys, line.key)
+		}
+	}
+	return keys
+}
+
+// block returns the line of key together with the comment lines directly
+// above it that describe it, as the example file documents its settings.
+func (f *envFile) block(key string) []string {
+	for i, line := range f.lines {
+		if line.key != key {
+			continue
+		}
+		start := i
+		for start > 0 {
+			prev := f.lines[start-1]
+			if prev.key != "" || !strings.HasPrefix(strings.TrimSpace(prev.raw), "#") {
+				break
+			}
+			start--
+		}
+		var raw []string
+		for _, l := range f.lines[start : i+1] {
+			raw = append(raw, l.raw)
+		}
+		return raw
+	}
+	return nil
+}
+
+// Append adds raw lines at the end of the file.
+func (f *envFile) Append(raw ...string) {
+	for _, r := range raw {
+		f.lines = append(f.lines, parseEnvLine(r))
+	}
+}
+
+// Bytes renders the file with its original line endings.
+func (f *envFile) Bytes() []byte {
+	nl := "\n"
+	if f.crlf {
+		nl = "\r\n"
+	}
+	var b strings.Builder
+	for _, line := range f.lines {
+		b.WriteString(line.raw)
+		b.WriteString(nl)
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,119 @@
+package launcher
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvLine(t *testing.T) {
+	tests := []struct {
+		raw       string
+		key       string
+		value     string
+		commented bool
+	}{
+		{raw: "PORT=3000", key: "PORT", value: "3000"},
+		{raw: "  PORT = 3000  ", key: "PORT", value: "3000"},
+		{raw: "export NODE_ENV=production", key: "NODE_ENV", value: "production"},
+		{raw: "app.name-x=1", key: "app.name-x", value: "1"},
+		{raw: "EMPTY=", key: "EMPTY", value: ""},
+		{raw: `EMPTY=""`, key: "EMPTY", value: ""},
+
+		// Quoting
+		{raw: `NAME="with spaces"`, key: "NAME", value: "with spaces"},
+		{raw: `NAME='single'`, key: "NAME", value: "single"},
+		{raw: "NAME=`back`", key: "NAME", value: "back"},
+		{raw: `NAME="a # not a comment"`, key: "NAME", value: "a # not a comment"},
+		{raw: `NAME="quoted" # comment`, key: "NAME", value: "quoted"},
+		{raw: `NAME='it''s'`, key: "NAME", value: "it"},
+		{raw: `NAME="unterminated`, key: "NAME", value: `"unterminated`},
+		{raw: `NAME="`, key: "NAME", value: `"`},
+
+		// Escapes
+		{raw: `MULTI="line1\nline2"`, key: "MULTI", value: "line1\nline2"},
+		{raw: `MULTI="a\r\nb"`, key: "MULTI", value: "a\r\nb"},
+		{raw: `RAW='line1\nline2'`, key: "RAW", value: `line1\nline2`},
+		{raw: `QUOTE="say \"hi\"" # c`, key: "QUOTE", value: `say \"hi\"`},
+		{raw: `QUOTE='it\'s'`, key: "QUOTE", value: `it\'s`},
+		{raw: `PATH=C:\tools\node`, key: "PATH", value: `C:\tools\node`},
+
+		// Comments
+		{raw: "LEVEL=info # default", key: "LEVEL", value: "info"},
+		{raw: "COLOR=#ff0000", key: "COLOR", value: "#ff0000"},
+		{raw: "URL=http://x/#frag", key: "URL", value: "http://x/#frag"},
+		{raw: "# LOG_LEVEL=info", key: "LOG_LEVEL", value: "info", commented: true},
+		{raw: "#LOG_LEVEL='debug' # note", key: "LOG_LEVEL", value: "debug", commented: true},
+		{raw: "# export TOKEN=abc", key: "TOKEN", value: "abc", commented: true},
+		{raw: "# Set this to true to enable it"},
+		{raw: "# lower=case is prose"},
+		{raw: "   # indented comment"},
+		{raw: ""},
+		{raw: "not an assignment"},
+		{raw: "1BAD=x"},
+	}
+	for _, tt := range tests {
+		got := parseEnvLine(tt.raw)
+		if got.key != tt.key || got.value != tt.value || got.commented != tt.commented {
+			t.Errorf("parseEnvLine(%q) = key %q, value %q, commented %v; want %q, %q, %v",
+				tt.raw, got.key, got.value, got.commented, tt.key, tt.value, tt.commented)
+		}
+	}
+}
+
+const testEnv = `# Server
+# The port of the dashboard
+PORT=3000
+
+# LOG_LEVEL=info
+LOG_LEVEL=debug
+LOG_LEVEL=error
+# TIMEOUT=30
+`
+
+func TestEnvFile(t *testing.T) {
+	f := parseEnv([]byte(testEnv))
+
+	for key, want := range map[string]string{"PORT": "3000", "LOG_LEVEL": "debug"} {
+		if got, ok := f.Lookup(key); !ok || got != want {
+			t.Errorf("Lookup(%s) = %q, %v, want %q", key, got, ok, want)
+		}
+	}
+	if _, ok := f.Lookup("TIMEOUT"); ok {
+		t.Error("Lookup found a commented-out setting")
+	}
+	if !f.Has("TIMEOUT") || f.Has("MISSING") {
+		t.Error("Has does not report commented-out settings only")
+	}
+	if got, want := f.Keys(), []string{"PORT", "LOG_LEVEL", "TIMEOUT"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	if got, want := f.block("PORT"), []string{"# Server", "# The port of the dashboard", "PORT=3000"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("block(PORT) = %q, want %q", got, want)
+	}
+	if got, want := f.block("LOG_LEVEL"), []string{"# LOG_LEVEL=info"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("block(LOG_LEVEL) = %q, want %q", got, want)
+	}
+}
+
+func TestEnvFileRoundTrip(t *testing.T) {
+	for _, input := range []string{
+		testEnv,
+		strings.ReplaceAll(testEnv, "\n", "\r\n"),
+		"",
+	} {
+		f := parseEnv([]byte(input))
+		if got := string(f.Bytes()); got != input {
+			t.Errorf("Bytes() = %q, want %q", got, input)
+		}
+	}
+
+	f := parseEnv([]byte("A=1\r\n"))
+	f.Append("", "# added", "B=2")
+	if got, want := string(f.Bytes()), "A=1\r\n\r\n# added\r\nB=2\r\n"; got != want {
+		t.Errorf("after Append: %q, want %q", got, want)
+	}
+	if v, _ := f.Lookup("B"); v != "2" {
+		t.Errorf("appended B = %q", v)
+	}
+}
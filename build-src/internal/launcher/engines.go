@@ -0,0 +1,168 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/semver"
+)
+
+// NodeSupport is the verdict on a Node.js version.
+type NodeSupport int
+
+const (
+	// NodeUnknown means the version or the app's requirements could not be
+	// read; the launcher continues.
+	NodeUnknown NodeSupport = iota
+	NodeSupported
+	// NodeUntested versions satisfy the engines range but lie outside the
+	// range the app was tested with.
+	NodeUntested
+	NodeTooOld
+	NodeTooNew
+)
+
+func (s NodeSupport) String() string {
+	switch s {
+	case NodeSupported:
+		return "supported"
+	case NodeUntested:
+		return "untested"
+	case NodeTooOld:
+		return "too old"
+	case NodeTooNew:
+		return "too new"
+	}
+	return "unknown"
+}
+
+// NodeCompat is the result of checking a Node.js version against the app.
+type NodeCompat struct {
+	Version string
+	// Range is engines.node from the app's package.json, Tested the
+	// optional testedEngines.node.
+	Range   string
+	Tested  string
+	Support NodeSupport
+	// Reason explains Support to the user.
+	Reason string
+}
+
+// Recommendation tells the user which Node.js to install: the tested range
+// if the app has one, else engines.node.
+func (c NodeCompat) Recommendation() string {
+	switch {
+	case c.Tested != "":
+		return fmt.Sprintf("Empfohlen: Node.js %s (getestet)", c.Tested)
+	case c.Range != "":
+		return fmt.Sprintf("Benötigt: Node.js %s", c.Range)
+	}
+	return "Empfohlen: die aktuelle Node.js LTS Version"
+}
+
+// nodeRequirements returns the Node.js ranges of the app in appDir, for
+// hints given before a version is checked. Only Range and Tested are set.
+func nodeRequirements(appDir string) NodeCompat {
+	m, _ := readPackageManifest(appDir)
+	return NodeCompat{Range: m.Engines["node"], Tested: m.TestedEngines["node"]}
+}
+
+// packageManifest holds the fields of the app's package.json the launcher
+// uses.
+type packageManifest struct {
+	Version       string            `json:"version"`
+	Engines       map[string]string `json:"engines"`
+	TestedEngines map[string]string `json:"testedEngines"`
+	// InstallFeatures maps plugins to the optional packages they need.
+	InstallFeatures map[string]installFeature `json:"installFeatures"`
+	// NativeModules tells where prebuilt binaries of native modules come
+	// from.
+	NativeModules map[string]nativeModuleSpec `json:"nativeModules"`
+	// UpdatePolicy tells the cloud launcher what an update must not touch.
+	UpdatePolicy struct {
+		Protected []string `json:"protected"`
+	} `json:"updatePolicy"`
+}
+
+func readPackageManifest(appDir string) (packageManifest, error) {
+	var m packageManifest
+	data, err := os.ReadFile(filepath.Join(appDir, "package.json"))
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("package.json ist ungültig: %v", err)
+	}
+	return m, nil
+}
+
+// ProtectedPaths returns the paths that updates leave alone, from
+// updatePolicy.protected in appDir/package.json. They are relative to
+// appDir with forward slashes; a trailing slash protects a directory and
+// everything below it, and * and ? match as in path.Match.
+func ProtectedPaths(appDir string) ([]string, error) {
+	m, err := readPackageManifest(appDir)
+	if err != nil {
+		return nil, err
+	}
+	return m.UpdatePolicy.Protected, nil
+}
+
+// CheckNodeVersion checks version (as printed by "node --version") against
+// the engines.node range in appDir/package.json. The app decides which
+// versions it accepts; the launchers only evaluate the range.
+func CheckNodeVersion(appDir, version string) NodeCompat {
+	c := NodeCompat{Version: version}
+
+	m, err := readPackageManifest(appDir)
+	if err != nil {
+		c.Reason = fmt.Sprintf("Node.js-Anforderungen der App nicht lesbar: %v", err)
+		return c
+	}
+	c.Range, c.Tested = m.Engines["node"], m.TestedEngines["node"]
+	if c.Range == "" {
+		c.Reason = "package.json gibt keine Node.js-Version vor"
+		return c
+	}
+
+	v, err := semver.Parse(version)
+	if err != nil {
+		c.Reason = fmt.Sprintf("Node.js-Version %q nicht erkannt", version)
+		return c
+	}
+	r, err := semver.ParseRange(c.Range)
+	if err != nil {
+		c.Reason = err.Error()
+		return c
+	}
+
+	switch r.Position(v) {
+	case -1:
+		c.Support = NodeTooOld
+		c.Reason = fmt.Sprintf("Node.js %s ist zu alt - benötigt: %s", version, c.Range)
+		return c
+	case 1:
+		c.Support = NodeTooNew
+		c.Reason = fmt.Sprintf("Node.js %s ist zu neu - unterstützt: %s", version, c.Range)
+		return c
+	}
+	if !r.Contains(v) {
+		// Between two alternatives of the range; an update helps
+		c.Support = NodeTooOld
+		c.Reason = fmt.Sprintf("Node.js %s wird nicht unterstützt - benötigt: %s", version, c.Range)
+		return c
+	}
+
+	c.Support = NodeSupported
+	c.Reason = fmt.Sprintf("Node.js %s wird unterstützt (%s)", version, c.Range)
+	if c.Tested == "" {
+		return c
+	}
+	if tested, err := semver.ParseRange(c.Tested); err == nil && !tested.Contains(v) {
+		c.Support = NodeUntested
+		c.Reason = fmt.Sprintf("Node.js %s ist erlaubt, aber nicht getestet - getestet: %s", version, c.Tested)
+	}
+	return c
+}
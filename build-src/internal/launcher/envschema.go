@@ -0,0 +1,223 @@
+package launcher
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// envAnnotationRe matches the schema annotations in .env.example, comment
+// lines directly above a setting such as "# @enum: error, warn, info".
+var envAnnotationRe = regexp.MustCompile(`^\s*#\s*@([a-z-]+)\s*(?::\s*(.*?))?\s*$`)
+
+// envRule is the schema of one setting. Rules written as annotations in
+// .env.example are enforced; rules only inferred from the example value
+// produce warnings.
+type envRule struct {
+	key      string
+	typ      string // "string", "bool", "int", "port", "url" or "enum"
+	values   []string
+	schemes  []string
+	min, max int
+	hasRange bool
+
+	required   bool
+	requiredIf string // "KEY" (is set) or "KEY=value"
+
+	explicit bool
+}
+
+// deriveEnvSchema builds the rules for all settings in example.
+func deriveEnvSchema(example *envFile) []envRule {
+	var rules []envRule
+	for _, key := range example.Keys() {
+		rule := inferEnvRule(key, example.rawValue(key))
+		for _, raw := range example.block(key) {
+			m := envAnnotationRe.FindStringSubmatch(raw)
+			if m == nil {
+				continue
+			}
+			rule.explicit = true
+			arg := m[2]
+			switch m[1] {
+			case "type":
+				rule.typ = arg
+			case "enum":
+				rule.typ, rule.values = "enum", splitList(arg)
+			case "url":
+				rule.typ, rule.schemes = "url", splitList(arg)
+			case "range":
+				lo, hi, _ := strings.Cut(arg, "-")
+				min, err1 := strconv.Atoi(strings.TrimSpace(lo))
+				max, err2 := strconv.Atoi(strings.TrimSpace(hi))
+				if err1 == nil && err2 == nil {
+					rule.min, rule.max, rule.hasRange = min, max, true
+				}
+			case "required":
+				rule.required = true
+			case "required-if":
+				rule.requiredIf = arg
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// inferEnvRule guesses the type of key from its example value.
+func inferEnvRule(key, example string) envRule {
+	rule := envRule{key: key, typ: "string"}
+	switch {
+	case key == "PORT" || strings.HasSuffix(key, "_PORT"):
+		rule.typ = "port"
+	case example == "true" || example == "false":
+		rule.typ = "bool"
+	case example != "" && strings.Trim(example, "0123456789") == "":
+		rule.typ = "int"
+	case strings.HasPrefix(example, "ws://") || strings.HasPrefix(example, "wss://"):
+		rule.typ, rule.schemes = "url", []string{"ws", "wss"}
+	case strings.HasPrefix(example, "http://") || strings.HasPrefix(example, "https://"):
+		rule.typ, rule.schemes = "url", []string{"http", "https"}
+	}
+	return rule
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// rawValue returns the value of key whether or not it is commented out.
+func (f *envFile) rawValue(key string) string {
+	for _, line := range f.lines {
+		if line.key == key {
+			return line.value
+		}
+	}
+	return ""
+}
+
+// check validates the value of the rule's setting in env and returns a
+// message for the user, or "" if it is fine. Unset and empty settings only
+// fail the required rules; the server uses its defaults for them.
+func (r envRule) check(env *envFile) string {
+	value, _ := env.Lookup(r.key)
+	if value == "" {
+		if r.required {
+			return fmt.Sprintf("%s fehlt", r.key)
+		}
+		if r.requiredIf != "" && envConditionMet(env, r.requiredIf) {
+			return fmt.Sprintf("%s fehlt (benötigt, wenn %s gesetzt ist)", r.key, r.requiredIf)
+		}
+		return ""
+	}
+
+	switch r.typ {
+	case "bool":
+		if value != "true" && value != "false" {
+			return fmt.Sprintf("%s: %q ist weder true noch false", r.key, value)
+		}
+	case "int", "port":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Sprintf("%s: %q ist keine Zahl", r.key, value)
+		}
+		if r.typ == "port" && (n < 1 || n > 65535) {
+			return fmt.Sprintf("%s: %d ist kein gültiger Port (1-65535)", r.key, n)
+		}
+		if r.hasRange && (n < r.min || n > r.max) {
+			return fmt.Sprintf("%s: %d liegt nicht zwischen %d und %d", r.key, n, r.min, r.max)
+		}
+	case "enum":
+		for _, v := range r.values {
+			if value == v {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%s: %q ist ungültig (erlaubt: %s)", r.key, value, strings.Join(r.values, ", "))
+	case "url":
+		u, err := url.Parse(value)
+		if err != nil || u.Host == "" || !schemeAllowed(u.Scheme, r.schemes) {
+			return fmt.Sprintf("%s: %q ist keine gültige URL (erwartet %s://...)", r.key, value, strings.Join(r.schemes, ":// oder "))
+		}
+	}
+	return ""
+}
+
+func schemeAllowed(scheme string, schemes []string) bool {
+	if len(schemes) == 0 {
+		return scheme != ""
+	}
+	for _, s := range schemes {
+		if strings.EqualFold(scheme, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// envConditionMet evaluates a required-if condition against env.
+func envConditionMet(env *envFile, cond string) bool {
+	key, want, hasValue := strings.Cut(cond, "=")
+	value, _ := env.Lookup(strings.TrimSpace(key))
+	if hasValue {
+		return value == strings.TrimSpace(want)
+	}
+	return value != ""
+}
+
+// validateEnvFile checks .env against the schema from .env.example. Broken
+// annotated settings stop the start, since the server would crash or
+// misbehave with them; settings that only look wrong are warned about.
+func (l *Launcher) validateEnvFile(p *PhaseProgress) error {
+	example, err := readEnvFile(filepath.Join(l.appDir, ".env.example"))
+	if err != nil {
+		l.logger.Printf("[INFO] Skipping .env validation: %v\n", err)
+		return nil
+	}
+	env, err := readEnvFile(filepath.Join(l.appDir, ".env"))
+	if err != nil {
+		l.logger.Printf("[INFO] Skipping .env validation: %v\n", err)
+		return nil
+	}
+
+	var problems []string
+	for _, rule := range deriveEnvSchema(example) {
+		msg := rule.check(env)
+		if msg == "" {
+			continue
+		}
+		if !rule.explicit {
+			l.logAndSync("[WARNING] .env: %s", msg)
+			l.showWarning(".env: " + msg)
+			continue
+		}
+		l.logAndSync("[ERROR] .env: %s", msg)
+		problems = append(problems, msg)
+	}
+	if len(problems) == 0 {
+		l.logger.Println("[SUCCESS] .env is valid")
+		return nil
+	}
+
+	status := "⚠️ Ungültige Einstellungen in .env"
+	p.Status(status)
+	hints := make([]string, 0, len(problems)+1)
+	for _, msg := range problems {
+		hints = append(hints, "❌ "+msg)
+	}
+	hints = append(hints, "💡 Korrigiere die Werte in app/.env (Beispiele in app/.env.example)")
+	return &Error{
+		Status: status,
+		Hints:  hints,
+		Err:    fmt.Errorf("%d invalid settings in .env", len(problems)),
+	}
+}
@@ -0,0 +1,139 @@
+package launcher
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testEnvExample = `# Log level
+# @enum: error, warn, info, debug
+LOG_LEVEL=info
+
+# Parallel downloads
+# @range: 1-10
+WORKERS=2
+
+# @required
+API_KEY=
+
+# TTS provider
+TTS_PROVIDER=browser
+
+# Needed for Google TTS
+# @required-if: TTS_PROVIDER=google
+GOOGLE_KEY=
+
+# @url: http, https
+# PROXY_URL=http://localhost:8080
+
+# @required-if: PROXY_URL
+# PROXY_USER=
+
+PORT=3000
+DEBUG=false
+RETRIES=3
+WEBSOCKET=wss://example.com/ws
+NAME=tool
+`
+
+// checkEnv returns the messages of the schema from testEnvExample for env
+// by key, and which of them are enforced.
+func checkEnv(env string) (map[string]string, map[string]bool) {
+	msgs, enforced := map[string]string{}, map[string]bool{}
+	for _, rule := range deriveEnvSchema(parseEnv([]byte(testEnvExample))) {
+		if msg := rule.check(parseEnv([]byte(env))); msg != "" {
+			msgs[rule.key], enforced[rule.key] = msg, rule.explicit
+		}
+	}
+	return msgs, enforced
+}
+
+func TestDeriveEnvSchema(t *testing.T) {
+	rules := map[string]envRule{}
+	for _, rule := range deriveEnvSchema(parseEnv([]byte(testEnvExample))) {
+		rules[rule.key] = rule
+	}
+
+	tests := []struct {
+		key  string
+		want envRule
+	}{
+		{"LOG_LEVEL", envRule{typ: "enum", values: []string{"error", "warn", "info", "debug"}, explicit: true}},
+		{"WORKERS", envRule{typ: "int", min: 1, max: 10, hasRange: true, explicit: true}},
+		{"API_KEY", envRule{typ: "string", required: true, explicit: true}},
+		{"TTS_PROVIDER", envRule{typ: "string"}},
+		{"GOOGLE_KEY", envRule{typ: "string", requiredIf: "TTS_PROVIDER=google", explicit: true}},
+		{"PROXY_URL", envRule{typ: "url", schemes: []string{"http", "https"}, explicit: true}},
+		{"PROXY_USER", envRule{typ: "string", requiredIf: "PROXY_URL", explicit: true}},
+		{"PORT", envRule{typ: "port"}},
+		{"DEBUG", envRule{typ: "bool"}},
+		{"RETRIES", envRule{typ: "int"}},
+		{"WEBSOCKET", envRule{typ: "url", schemes: []string{"ws", "wss"}}},
+		{"NAME", envRule{typ: "string"}},
+	}
+	if len(rules) != len(tests) {
+		t.Errorf("got %d rules, want %d", len(rules), len(tests))
+	}
+	for _, tt := range tests {
+		tt.want.key = tt.key
+		if got := rules[tt.key]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rule %s = %+v, want %+v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestEnvRuleCheck(t *testing.T) {
+	valid := "API_KEY=secret\n"
+	tests := []struct {
+		name string
+		env  string
+		// want maps keys to a part of the expected message
+		want map[string]string
+		// warnings are the keys whose problems only warn
+		warnings []string
+	}{
+		{name: "valid", env: valid},
+		{name: "defaults for unset settings", env: valid + "LOG_LEVEL=\nWORKERS=\n"},
+		{name: "required missing", env: "", want: map[string]string{"API_KEY": "API_KEY fehlt"}},
+		{name: "required empty", env: `API_KEY=""`, want: map[string]string{"API_KEY": "API_KEY fehlt"}},
+		{name: "enum", env: valid + "LOG_LEVEL=verbose", want: map[string]string{"LOG_LEVEL": `"verbose" ist ungültig (erlaubt: error, warn, info, debug)`}},
+		{name: "enum is case sensitive", env: valid + "LOG_LEVEL=INFO", want: map[string]string{"LOG_LEVEL": "ungültig"}},
+		{name: "enum quoted", env: valid + `LOG_LEVEL="warn"`},
+		{name: "range low", env: valid + "WORKERS=0", want: map[string]string{"WORKERS": "0 liegt nicht zwischen 1 und 10"}},
+		{name: "range high", env: valid + "WORKERS=11", want: map[string]string{"WORKERS": "11 liegt nicht zwischen 1 und 10"}},
+		{name: "range bounds", env: valid + "WORKERS=1\n# WORKERS=99\n"},
+		{name: "range not a number", env: valid + "WORKERS=many", want: map[string]string{"WORKERS": "keine Zahl"}},
+		{name: "required-if value met", env: valid + "TTS_PROVIDER=google", want: map[string]string{"GOOGLE_KEY": "benötigt, wenn TTS_PROVIDER=google gesetzt ist"}},
+		{name: "required-if value satisfied", env: valid + "TTS_PROVIDER=google\nGOOGLE_KEY=k"},
+		{name: "required-if value not met", env: valid + "TTS_PROVIDER=browser"},
+		{name: "required-if set", env: valid + "PROXY_URL=http://proxy:8080", want: map[string]string{"PROXY_USER": "benötigt, wenn PROXY_URL gesetzt ist"}},
+		{name: "required-if commented out", env: valid + "# PROXY_URL=http://proxy:8080"},
+		{name: "url scheme", env: valid + "PROXY_URL=ftp://proxy\nPROXY_USER=u", want: map[string]string{"PROXY_URL": "keine gültige URL (erwartet http:// oder https://...)"}},
+		{name: "url without host", env: valid + "PROXY_URL=proxy:8080\nPROXY_USER=u", want: map[string]string{"PROXY_URL": "keine gültige URL"}},
+		{
+			name:     "inferred rules only warn",
+			env:      valid + "PORT=70000\nDEBUG=yes\nRETRIES=3x\nWEBSOCKET=http://x\n",
+			want:     map[string]string{"PORT": "kein gültiger Port", "DEBUG": "weder true noch false", "RETRIES": "keine Zahl", "WEBSOCKET": "ws:// oder wss://"},
+			warnings: []string{"PORT", "DEBUG", "RETRIES", "WEBSOCKET"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs, enforced := checkEnv(tt.env)
+			if len(msgs) != len(tt.want) {
+				t.Errorf("messages = %q, want %d", msgs, len(tt.want))
+			}
+			for key, part := range tt.want {
+				if !strings.Contains(msgs[key], part) {
+					t.Errorf("%s: message %q, want it to contain %q", key, msgs[key], part)
+				}
+			}
+			for _, key := range tt.warnings {
+				if enforced[key] {
+					t.Errorf("%s is enforced, want only a warning", key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,472 @@
+// Package launcher contains the launcher engine shared by all local
+// launchers (GUI, dev, console and backup). The binaries in cmd/ are thin
+// front-ends: they choose where progress is shown (a Sink), where the
+// Node.js output goes and what happens when the launcher exits.
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+)
+
+// Sink receives progress updates from the launcher.
+type Sink interface {
+	Progress(value int, status string)
+	Redirect(url string)
+}
+
+// EventSink is implemented by sinks that show more than a progress bar,
+// such as phase changes, warnings and npm output.
+type EventSink interface {
+	Event(typ events.Type, data interface{})
+}
+
+// emit sends an event to sink if it supports them.
+func emit(sink Sink, typ events.Type, data interface{}) {
+	if es, ok := sink.(EventSink); ok {
+		es.Event(typ, data)
+	}
+}
+
+// Config selects the output behaviour of a launcher front-end.
+type Config struct {
+	// AppDir is the directory containing launch.js and package.json.
+	AppDir string
+	// LogTitle is written into the header of the log file.
+	LogTitle string
+	// LogPath overrides the default app/logs/launcher_<timestamp>.log.
+	LogPath string
+	// Console receives npm and Node.js output in addition to the log file.
+	// GUI builds have no console and leave this nil.
+	Console io.Writer
+	// Stdin is passed to the Node.js process.
+	Stdin io.Reader
+	// Env holds extra environment variables for the Node.js process.
+	Env []string
+	// ConfirmNodeVersion is asked whether to continue with a Node.js
+	// version that is newer than the app supports. If nil the launcher
+	// continues with a warning.
+	ConfirmNodeVersion func(c NodeCompat) bool
+	// ChooseRemedy is asked which of f.Remedies to try after npm failed.
+	// Returning "" gives up. If nil the launcher gives up with hints.
+	ChooseRemedy func(f NpmFailure) Remedy
+	// Settings are the options from launcher.json. The zero value means
+	// DefaultSettings.
+	Settings Settings
+	// NodePath is the Node.js that PrepareDependencies installs with. Run
+	// ignores it and chooses one itself.
+	NodePath string
+}
+
+// ErrAborted is returned by Run when the user chose not to continue.
+var ErrAborted = errors.New("vom Benutzer abgebrochen")
+
+// Error is returned by Run when a phase fails. Status is the message that
+// was shown to the user, Hints are suggestions the front-end may display
+// before exiting. Code and Details are set for known failures, such as a
+// classified npm error and npm's error output.
+type Error struct {
+	Status  string
+	Hints   []string
+	Code    string
+	Details []string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Server is a running Node.js server started by Run.
+type Server struct {
+	Cmd  *exec.Cmd
+	URL  string
+	Port int
+	Done <-chan error
+}
+
+type Launcher struct {
+	cfg            Config
+	sink           Sink
+	nodePath       string
+	appDir         string
+	logFile        *os.File
+	logger         *log.Logger
+	envFileFixed   bool // Track if we auto-created .env file
+	port           int  // port the server is started on; see serverPort
+	output         *outputWatcher
+	depsPlan       depsPlan        // set by dependenciesUpToDate
+	staleNative    []nativeModule  // set by nativeModulesMatch
+	abiRebuilt     bool            // Track if we rebuilt after a NODE_MODULE_VERSION crash
+	npmExtraArgs   []string        // added by remedies, e.g. --legacy-peer-deps
+	depsInstalled  bool            // npm changed node_modules in this run; see saveSnapshot
+	tools          toolchain       // cached by buildTools
+	preflighted    map[string]bool // package lists checked by preflightNativeBuild
+	nodeCandidates []nodeCandidate // cached by discoverNode
+	shutdownToken  string          // authorises requestShutdown; see shutdownTokenEnv
+
+	pipeline *Pipeline
+	server   *Server
+	stopping atomic.Bool // set by Shutdown; the supervisor stops restarting
+
+	pidMu        sync.Mutex // guards the PID file
+	shutdownOnce sync.Once
+	shutdownDone chan struct{} // closed once Shutdown has finished
+
+	procMu      sync.Mutex // guards cmd, processDied and exited
+	cmd         *exec.Cmd
+	processDied chan error
+	exited      chan struct{} // closed once cmd has been reaped
+}
+
+// New creates a launcher for cfg that reports progress to sink.
+func New(cfg Config, sink Sink) *Launcher {
+	if cfg.Settings.Restart.Policy == "" {
+		cfg.Settings = DefaultSettings()
+	}
+	return &Launcher{
+		cfg:      cfg,
+		sink:     sink,
+		nodePath: cfg.NodePath,
+		appDir:   cfg.AppDir,
+		logger:   log.New(io.Discard, "", log.LstdFlags),
+
+		shutdownToken: newShutdownToken(),
+
+		shutdownDone: make(chan struct{}),
+	}
+}
+
+// Dirs returns the directory of the running executable and the app
+// directory next to it.
+func Dirs() (exeDir, appDir string, err error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", "", fmt.Errorf("Kann Programmverzeichnis nicht ermitteln: %v", err)
+	}
+	exeDir = filepath.Dir(exePath)
+	return exeDir, filepath.Join(exeDir, "app"), nil
+}
+
+// Run checks the environment, installs dependencies if needed, starts the
+// Node.js server and waits until it responds. On failure the returned error
+// is an *Error, or ErrAborted if the user cancelled.
+func (l *Launcher) Run() (*Server, error) {
+	l.pipeline = NewPipeline(l.sink, l.logAndSync, l.phases()...)
+	l.logAndSync("[INFO] Launcher phases: %s", l.pipeline)
+
+	if err := l.pipeline.Run(); err != nil {
+		l.reportError(err)
+		return nil, err
+	}
+
+	l.sink.Progress(100, "Server erfolgreich gestartet!")
+	l.logger.Println("[SUCCESS] Server is running and healthy!")
+	l.sink.Progress(100, "Weiterleitung zum Dashboard...")
+	l.logger.Println("[INFO] Redirecting to dashboard...")
+	l.sink.Redirect(l.server.URL)
+	emit(l.sink, events.TypeDone, events.Done{OK: true})
+
+	return l.server, nil
+}
+
+// PrepareDependencies runs the deps, native and snapshot phases of Run with
+// Config.NodePath: it installs or repairs node_modules according to its
+// fingerprint and the install profile, but starts nothing. It is meant for
+// front-ends that start the server themselves. On failure the returned
+// error is an *Error; unlike Run it does not report it on the sink.
+func (l *Launcher) PrepareDependencies() error {
+	if l.nodePath == "" {
+		return fmt.Errorf("Kein Node.js angegeben (Config.NodePath)")
+	}
+	l.pipeline = NewPipeline(l.sink, l.logAndSync, l.selectPhases("deps", "native", "snapshot")...)
+	l.logAndSync("[INFO] Dependency phases with Node.js %s: %s", l.nodePath, l.pipeline)
+	return l.pipeline.Run()
+}
+
+// ProvisionNode runs the runtime phase of Run: if runtime.provision is set
+// and no configured or installed Node.js satisfies the app's engines range,
+// it downloads the pinned release next to the executable, where FindNode
+// finds it. Like PrepareDependencies it is meant for front-ends that start
+// the server themselves and returns failures as an *Error.
+func (l *Launcher) ProvisionNode() error {
+	l.pipeline = NewPipeline(l.sink, l.logAndSync, l.selectPhases("runtime")...)
+	return l.pipeline.Run()
+}
+
+// selectPhases returns the named phases of Run in pipeline order.
+func (l *Launcher) selectPhases(names ...string) []Phase {
+	var phases []Phase
+	for _, ph := range l.phases() {
+		for _, name := range names {
+			if ph.Name == name {
+				phases = append(phases, ph)
+			}
+		}
+	}
+	return phases
+}
+
+// reportError publishes a failed run as an error event.
+func (l *Launcher) reportError(err error) {
+	msg := events.Message{Message: err.Error()}
+	var lerr *Error
+	if errors.As(err, &lerr) {
+		msg = events.Message{Message: lerr.Status, Hints: lerr.Hints, Code: lerr.Code, Details: lerr.Details}
+	}
+	emit(l.sink, events.TypeError, msg)
+	emit(l.sink, events.TypeDone, events.Done{OK: false})
+}
+
+// showWarning shows msg as a warning on the sink. Callers log on their own.
+func (l *Launcher) showWarning(msg string) {
+	emit(l.sink, events.TypeWarning, events.Message{Message: msg})
+}
+
+// phases returns the launcher pipeline. Weights roughly follow how long each
+// phase usually takes.
+func (l *Launcher) phases() []Phase {
+	return []Phase{
+		{
+			Name:       "runtime",
+			Title:      "Lade Node.js herunter...",
+			Weight:     15,
+			Skip:       l.hasUsableNode,
+			SkipStatus: "Prüfe Node.js Installation...",
+			Run:        l.provisionNode,
+		},
+		{
+			Name:   "node",
+			Title:  "Prüfe Node.js Installation...",
+			Weight: 2,
+			Run:    l.phaseNode,
+		},
+		{
+			Name:   "appdir",
+			Title:  "Prüfe App-Verzeichnis...",
+			Weight: 1,
+			Run:    l.phaseAppDir,
+		},
+		{
+			Name:       "deps",
+			Title:      "Installiere Abhängigkeiten...",
+			Weight:     50,
+			Skip:       l.dependenciesUpToDate,
+			SkipStatus: "Abhängigkeiten aktuell...",
+			// Each retry applies a remedy the user picked
+			Retries:     3,
+			BeforeRetry: l.remedyInstallFailure,
+			Run:         l.phaseDeps,
+		},
+		{
+			Name:       "native",
+			Title:      "Baue native Module neu...",
+			Weight:     10,
+			Skip:       l.nativeModulesMatch,
+			SkipStatus: "Prüfe native Module...",
+			Run:        l.phaseNative,
+		},
+		{
+			Name:     "snapshot",
+			Title:    "Sichere node_modules...",
+			Weight:   5,
+			Optional: true,
+			Skip:     l.snapshotIsCurrent,
+			Run:      l.saveSnapshot,
+		},
+		{
+			Name:     "autofix",
+			Title:    "Prüfe Konfiguration...",
+			Weight:   2,
+			Optional: true,
+			Run:      l.phaseAutoFix,
+		},
+		{
+			Name:   "config",
+			Title:  "Prüfe Einstellungen in .env...",
+			Weight: 1,
+			Run:    l.validateEnvFile,
+		},
+		{
+			Name:   "start",
+			Title:  "Starte Tool...",
+			Weight: 2,
+			Run:    l.startServer,
+		},
+		{
+			Name:        "health",
+			Title:       "Warte auf Server-Start...",
+			Weight:      10,
+			Retries:     2,
+			BeforeRetry: l.retryHealth,
+			Run:         l.waitForHealth,
+		},
+	}
+}
+
+func (l *Launcher) phaseNode(p *PhaseProgress) error {
+	if err := l.checkNodeJS(); err != nil {
+		l.logAndSync("[ERROR] Node.js check failed: %v", err)
+		status := "FEHLER: Node.js ist nicht installiert!"
+		p.Status(status)
+		return &Error{
+			Status: status,
+			Hints: []string{
+				"Bitte installiere Node.js von https://nodejs.org",
+				nodeRequirements(l.appDir).Recommendation(),
+				fmt.Sprintf("Oder setze \"runtime\": {\"provision\": true} in %s", SettingsFile),
+			},
+			Err: err,
+		}
+	}
+
+	p.Update(0.5, "Node.js gefunden...")
+	l.logAndSync("[SUCCESS] Node.js found at: %s", l.nodePath)
+
+	version := l.getNodeVersion()
+	p.Update(1, fmt.Sprintf("Node.js Version: %s", version))
+	l.logger.Printf("[INFO] Node.js version: %s\n", version)
+	switch c := l.checkNodeVersionCompatibility(p, version); c.Support {
+	case NodeTooOld:
+		status := "FEHLER: " + c.Reason
+		p.Status(status)
+		return &Error{
+			Status: status,
+			Hints: []string{
+				"Bitte installiere eine passende Node.js LTS Version von https://nodejs.org",
+				fmt.Sprintf("Benötigt: Node.js %s", c.Range),
+			},
+			Err: fmt.Errorf("Node.js %s ist zu alt", version),
+		}
+	case NodeTooNew:
+		if l.cfg.ConfirmNodeVersion != nil && !l.cfg.ConfirmNodeVersion(c) {
+			l.logger.Println("[INFO] User aborted because of the Node.js version")
+			return ErrAborted
+		}
+		l.logger.Println("[WARNING] Continuing with unsupported Node.js version")
+	}
+	return nil
+}
+
+func (l *Launcher) phaseAppDir(p *PhaseProgress) error {
+	l.logger.Printf("[INFO] Checking app directory: %s\n", l.appDir)
+
+	if _, err := os.Stat(l.appDir); os.IsNotExist(err) {
+		l.logger.Printf("[ERROR] App directory not found: %s\n", l.appDir)
+		status := "FEHLER: app Verzeichnis nicht gefunden"
+		p.Status(status)
+		return &Error{Status: status, Err: fmt.Errorf("app Verzeichnis nicht gefunden: %s", l.appDir)}
+	}
+
+	p.Update(1, "App-Verzeichnis gefunden...")
+	l.logger.Printf("[SUCCESS] App directory exists: %s\n", l.appDir)
+	return nil
+}
+
+func (l *Launcher) phaseDeps(p *PhaseProgress) error {
+	plan := l.depsPlan
+	var err error
+	restored := l.restoreSnapshot(p, plan.current)
+	switch {
+	case restored:
+		// The snapshot was saved with this fingerprint
+	case plan.action == depsProfile:
+		l.logger.Printf("[INFO] %s - adjusting optional dependencies\n", plan.reason)
+		p.Status(fmt.Sprintf("🔧 %s - passe optionale Pakete an...", plan.reason))
+		err = l.switchProfile(p, plan.recorded.profile())
+	default:
+		args := l.npmArgs(plan)
+		l.logger.Printf("[INFO] %s - running npm %s\n", plan.reason, args[0])
+		p.Status(fmt.Sprintf("🔧 %s - führe npm %s aus...", plan.reason, args[0]))
+
+		if plan.action == depsReinstall {
+			l.logAndSync("[AUTO-FIX] Removing node_modules for a clean install")
+			if err := os.RemoveAll(l.nodeModulesDir()); err != nil {
+				l.logger.Printf("[WARNING] Could not remove node_modules: %v\n", err)
+			}
+		}
+		l.preflightNativeBuild(p, nil)
+		err = l.installDependencies(p, args...)
+	}
+	if err != nil {
+		l.logger.Printf("[ERROR] Dependency installation failed: %v\n", err)
+		return l.installError(p, err)
+	}
+	l.depsInstalled = !restored
+
+	// npm install may have created or updated package-lock.json
+	if fp, err := l.currentFingerprint(); err == nil {
+		l.writeDepsFingerprint(fp)
+	} else {
+		l.logger.Printf("[WARNING] Cannot fingerprint dependencies: %v\n", err)
+	}
+
+	p.Update(1, "Installation abgeschlossen!")
+	l.logger.Println("[SUCCESS] Dependencies installed successfully")
+	return nil
+}
+
+func (l *Launcher) phaseAutoFix(p *PhaseProgress) error {
+	// Auto-fix: Stop a server left behind by a crashed launcher; it would
+	// still hold the port
+	l.cleanupOrphans()
+
+	// Auto-fix: Create .env file if missing
+	if err := l.autoFixEnvFile(p); err != nil {
+		l.logger.Printf("[WARNING] Could not auto-create .env: %v\n", err)
+		l.showWarning(fmt.Sprintf(".env konnte nicht automatisch erstellt werden: %v", err))
+	}
+
+	// Auto-fix: Check port availability
+	l.autoFixPort(p)
+
+	p.Update(1, "Konfiguration geprüft!")
+	return nil
+}
+
+// ShowHints walks the user through the hints of a failed run on the sink.
+func (l *Launcher) ShowHints(err error) {
+	var lerr *Error
+	if !errors.As(err, &lerr) {
+		return
+	}
+	value := 0
+	if l.pipeline != nil {
+		value = l.pipeline.Percent()
+	}
+	for _, hint := range lerr.Hints {
+		l.sink.Progress(value, hint)
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// installHints are shown when npm failed for an unknown reason; node holds
+// the app's Node.js ranges.
+func installHints(node NodeCompat) []string {
+	if runtime.GOOS != "windows" {
+		return []string{
+			"💡 Prüfe app/logs/launcher_*.log für Details",
+			"💡 Oder führe manuell: cd app && npm install",
+		}
+	}
+	return []string{
+		"💡 Passt die Node.js-Version? " + node.Recommendation(),
+		"💡 Fehlende Visual Studio Build Tools ('Desktop development with C++')",
+		"💡 Prüfe app/logs/launcher_*.log für Details",
+	}
+}
@@ -0,0 +1,95 @@
+package launcher
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// SetupLogging creates the launcher log file. Unless Config.LogPath is set
+// the file is created in the app's logs directory. If it fails the launcher
+// keeps logging to io.Discard.
+func (l *Launcher) SetupLogging() error {
+	logPath := l.cfg.LogPath
+	if logPath == "" {
+		logDir := filepath.Join(l.appDir, "logs")
+		if err := os.MkdirAll(logDir, 0755); err != nil {
+			return fmt.Errorf("failed to create log directory: %v", err)
+		}
+
+		timestamp := time.Now().Format("2006-01-02_15-04-05")
+		logPath = filepath.Join(logDir, fmt.Sprintf("launcher_%s.log", timestamp))
+	}
+
+	// Open with sync flag to ensure writes are flushed immediately
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_SYNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create log file: %v", err)
+	}
+
+	l.logFile = logFile
+
+	// Only write to file (not stdout) because in GUI mode stdout doesn't exist
+	// This prevents silent failures when built with -H windowsgui
+	l.logger = log.New(logFile, "", log.LstdFlags)
+
+	title := l.cfg.LogTitle
+	if title == "" {
+		title = "TikTok Stream Tool - Launcher Log"
+	}
+
+	l.logger.Println("========================================")
+	l.logger.Println(title)
+	l.logger.Println("========================================")
+	l.logger.Printf("Log file: %s\n", logPath)
+	l.logger.Printf("Platform: %s\n", runtime.GOOS)
+	l.logger.Printf("Architecture: %s\n", runtime.GOARCH)
+	l.logger.Println("========================================")
+
+	// Force sync to ensure header is written
+	if err := logFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log file: %v", err)
+	}
+
+	return nil
+}
+
+// LogPath returns the path of the open log file, or "" without one.
+func (l *Launcher) LogPath() string {
+	if l.logFile == nil {
+		return ""
+	}
+	return l.logFile.Name()
+}
+
+// Close closes the log file
+func (l *Launcher) Close() {
+	if l.logFile != nil {
+		l.logger.Println("========================================")
+		l.logger.Println("Launcher finished")
+		l.logger.Println("========================================")
+		l.logFile.Sync() // Ensure all writes are flushed
+		l.logFile.Close()
+	}
+}
+
+// Logf logs a message and immediately syncs to disk
+func (l *Launcher) Logf(format string, args ...interface{}) {
+	l.logAndSync(format, args...)
+}
+
+// logAndSync logs a message and immediately syncs to disk
+// This ensures logs are written even if the process crashes
+func (l *Launcher) logAndSync(format string, args ...interface{}) {
+	if len(args) > 0 {
+		l.logger.Printf(format, args...)
+	} else {
+		l.logger.Println(format)
+	}
+	if l.logFile != nil {
+		l.logFile.Sync()
+	}
+}
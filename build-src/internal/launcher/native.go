@@ -0,0 +1,238 @@
+package launcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// nativeModule is a compiled addon (.node file) in node_modules.
+type nativeModule struct {
+	Path    string
+	Package string
+	// ABI is the NODE_MODULE_VERSION the addon was built for, napiABI for
+	// Node-API addons that work with every version, classicABI for addons
+	// whose version cannot be read, or "" if unknown.
+	ABI string
+}
+
+const (
+	napiABI    = "napi"
+	classicABI = "classic"
+)
+
+var (
+	// Addons built with NODE_MODULE_INIT, NAN_MODULE_WORKER_ENABLED and
+	// friends export their init function as
+	// node_register_module_v<NODE_MODULE_VERSION>.
+	registerSymbolRe = regexp.MustCompile(`node_register_module_v(\d+)`)
+	napiSymbol       = []byte("napi_register_module_v1")
+	// The classic NODE_MODULE macro registers the addon from a static
+	// constructor instead; its version is a number in the data section,
+	// which cannot be found without parsing the binary.
+	classicSymbol = []byte("node_module_register")
+)
+
+// moduleABI reads the ABI an addon was built for from its symbol table.
+func moduleABI(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	if m := registerSymbolRe.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	if bytes.Contains(data, napiSymbol) {
+		return napiABI
+	}
+	if bytes.Contains(data, classicSymbol) {
+		return classicABI
+	}
+	return ""
+}
+
+// packageOfModule returns the npm package a file in node_modules belongs
+// to, e.g. "better-sqlite3" or "@serialport/bindings-cpp".
+func packageOfModule(path string) string {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for i := len(parts) - 2; i >= 0; i-- {
+		if parts[i] != "node_modules" {
+			continue
+		}
+		name := parts[i+1]
+		if strings.HasPrefix(name, "@") && i+2 < len(parts) {
+			name += "/" + parts[i+2]
+		}
+		return name
+	}
+	return ""
+}
+
+// findNativeModules lists the addons in node_modules. Prebuilt binaries
+// that are only picked at runtime (prebuilds/) are not checked.
+func (l *Launcher) findNativeModules() []nativeModule {
+	var found []nativeModule
+	filepath.WalkDir(l.nodeModulesDir(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == "prebuilds" || d.Name() == ".bin" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(d.Name(), ".node") && d.Type().IsRegular() {
+			found = append(found, nativeModule{Path: path, Package: packageOfModule(path), ABI: moduleABI(path)})
+		}
+		return nil
+	})
+	return found
+}
+
+// staleNativeModules returns the addons built for another ABI than abi.
+// Classic NODE_MODULE addons are not among them: if one does not match,
+// the server's NODE_MODULE_VERSION error triggers rebuildAfterABICrash.
+func (l *Launcher) staleNativeModules(abi string) []nativeModule {
+	var stale []nativeModule
+	for _, m := range l.findNativeModules() {
+		switch m.ABI {
+		case abi, napiABI:
+		case classicABI:
+			l.logger.Printf("[INFO] Native module %s: built with NODE_MODULE, ABI checked when the server loads it\n", m.Path)
+		case "":
+			l.logger.Printf("[INFO] Native module %s: ABI unknown, not checked\n", m.Path)
+		default:
+			stale = append(stale, m)
+		}
+	}
+	return stale
+}
+
+// modulePackages returns the sorted, distinct packages of modules.
+func modulePackages(modules []nativeModule) []string {
+	seen := make(map[string]bool)
+	var pkgs []string
+	for _, m := range modules {
+		if m.Package != "" && !seen[m.Package] {
+			seen[m.Package] = true
+			pkgs = append(pkgs, m.Package)
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// nativeModulesMatch is the skip check of the native phase: it compares
+// the addons in node_modules with the ABI of the chosen Node.js.
+func (l *Launcher) nativeModulesMatch() bool {
+	abi := l.depsPlan.current.NodeABI
+	if abi == "" {
+		l.logger.Println("[WARNING] Node.js ABI unknown - skipping native module check")
+		return true
+	}
+
+	started := time.Now()
+	l.staleNative = l.staleNativeModules(abi)
+	for _, m := range l.staleNative {
+		l.logAndSync("[WARNING] Native module %s was built for ABI %s, Node.js %s needs ABI %s", m.Path, m.ABI, l.depsPlan.current.NodeVersion, abi)
+	}
+	l.logger.Printf("[INFO] Native modules checked in %v: %d built for another ABI\n", time.Since(started).Round(time.Millisecond), len(l.staleNative))
+	return len(l.staleNative) == 0
+}
+
+// phaseNative rebuilds the addons found by nativeModulesMatch.
+func (l *Launcher) phaseNative(p *PhaseProgress) error {
+	pkgs := modulePackages(l.staleNative)
+	l.logAndSync("[AUTO-FIX] Rebuilding native modules: %s", strings.Join(pkgs, ", "))
+	p.Status(fmt.Sprintf("🔧 Native Module passen nicht zu Node.js %s - baue %s neu...", l.depsPlan.current.NodeVersion, strings.Join(pkgs, ", ")))
+
+	l.preflightNativeBuild(p, pkgs)
+	if err := l.rebuildNativeModules(p, pkgs); err != nil {
+		l.logger.Printf("[ERROR] Native module rebuild failed: %v\n", err)
+		status := "FEHLER: Native Module konnten nicht neu gebaut werden"
+		p.Status(status)
+		return &Error{
+			Status: status,
+			Hints: append([]string{
+				fmt.Sprintf("Betroffen: %s", strings.Join(pkgs, ", ")),
+				"Oder lösche app/node_modules und starte den Launcher neu",
+			}, installHints(nodeRequirements(l.appDir))...),
+			Err: err,
+		}
+	}
+
+	l.depsInstalled = true
+	p.Update(1, "Native Module neu gebaut!")
+	l.logger.Println("[SUCCESS] Native modules rebuilt")
+	return nil
+}
+
+// rebuildNativeModules runs npm rebuild for pkgs (all packages if empty)
+// and checks that the addons now match the chosen Node.js.
+func (l *Launcher) rebuildNativeModules(p *PhaseProgress, pkgs []string) error {
+	if err := l.installDependencies(p, append([]string{"rebuild"}, pkgs...)...); err != nil {
+		return err
+	}
+	abi := l.depsPlan.current.NodeABI
+	if abi == "" {
+		return nil
+	}
+	if stale := l.staleNativeModules(abi); len(stale) > 0 {
+		return fmt.Errorf("%s weiterhin für ABI %s gebaut (benötigt: %s)", strings.Join(modulePackages(stale), ", "), stale[0].ABI, abi)
+	}
+	return nil
+}
+
+// rebuildAfterABICrash is the health phase's retry hook for a server that
+// crashed loading a native module built for another Node.js: the modules
+// named in the output are rebuilt and the server is started once more.
+func (l *Launcher) rebuildAfterABICrash(p *PhaseProgress, err error) bool {
+	if !errors.Is(err, errServerCrashed) || l.abiRebuilt || l.output == nil {
+		return false
+	}
+	abi, paths := l.output.ABIError()
+	if !abi {
+		return false
+	}
+	// Mark that we already tried the fix
+	l.abiRebuilt = true
+
+	var modules []nativeModule
+	for _, path := range paths {
+		modules = append(modules, nativeModule{Path: path, Package: packageOfModule(path)})
+	}
+	pkgs := modulePackages(modules)
+	what := strings.Join(pkgs, ", ")
+	if what == "" {
+		// Node.js did not name the module; rebuild everything
+		what = "all packages"
+	}
+	l.logAndSync("[AUTO-FIX] Server crashed with a NODE_MODULE_VERSION error - rebuilding %s...", what)
+	p.Status("🔧 Native Module passen nicht zu Node.js - baue neu...")
+
+	if err := l.rebuildNativeModules(p, pkgs); err != nil {
+		l.logAndSync("[ERROR] Native module rebuild failed: %v", err)
+		return false
+	}
+	if err := l.startServer(p); err != nil {
+		l.logAndSync("[ERROR] Retry failed to start server: %v", err)
+		return false
+	}
+
+	p.Status("🔄 Server neugestartet - warte auf Antwort...")
+	l.logAndSync("[INFO] Server restarted after native module rebuild - waiting for health check...")
+	return true
+}
+
+// retryHealth is the health phase's retry hook; each fix is tried once.
+func (l *Launcher) retryHealth(p *PhaseProgress, err error) bool {
+	return l.rebuildAfterABICrash(p, err) || l.restartAfterEnvFix(p, err)
+}
@@ -0,0 +1,81 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Fake addon contents: only the symbol names matter to moduleABI.
+const (
+	addonV108    = "\x7fELF\x00node_register_module_v108\x00"
+	addonV115    = "\x7fELF\x00node_register_module_v115\x00"
+	addonNAPI    = "\x7fELF\x00napi_register_module_v1\x00"
+	addonClassic = "\x7fELF\x00node_module_register\x00"
+)
+
+func TestModuleABI(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name, content, want string
+	}{
+		{"versioned", addonV115, "115"},
+		{"node-api", addonNAPI, napiABI},
+		{"classic NODE_MODULE", addonClassic, classicABI},
+		// NAN addons may carry both; the versioned symbol counts
+		{"versioned and classic", addonClassic + addonV108, "108"},
+		{"no symbol", "\x7fELF\x00something else\x00", ""},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name+".node")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := moduleABI(path); got != tt.want {
+			t.Errorf("%s: moduleABI = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := moduleABI(filepath.Join(dir, "missing.node")); got != "" {
+		t.Errorf("missing file: moduleABI = %q, want \"\"", got)
+	}
+}
+
+func TestStaleNativeModules(t *testing.T) {
+	appDir := t.TempDir()
+	for rel, content := range map[string]string{
+		"better-sqlite3/build/Release/better_sqlite3.node":            addonV108,
+		"better-sqlite3/build/Release/obj.target/test_extension.node": addonV108,
+		"bcrypt/lib/binding/napi-v3/bcrypt_lib.node":                  addonV115,
+		"@serialport/bindings-cpp/build/Release/bindings.node":        addonNAPI,
+		"legacy-addon/build/Release/addon.node":                       addonClassic,
+		"usb/prebuilds/linux-x64/node.napi.node":                      addonV108,
+		"outer/node_modules/nested/build/Release/nested.node":         "node_register_module_v93",
+		"outer/index.js": "module.exports = {}",
+	} {
+		path := filepath.Join(appDir, "node_modules", filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := New(Config{AppDir: appDir}, discardSink{})
+	if got := len(l.findNativeModules()); got != 6 {
+		t.Errorf("found %d addons, want 6 outside prebuilds/", got)
+	}
+	stale := l.staleNativeModules("115")
+	if got, want := modulePackages(stale), []string{"better-sqlite3", "nested"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stale packages = %v, want %v", got, want)
+	}
+	if len(stale) != 3 {
+		t.Errorf("stale addons = %+v, want both of better-sqlite3 and nested", stale)
+	}
+
+	// Node-API and classic addons never count as stale
+	if stale := l.staleNativeModules("108"); !reflect.DeepEqual(modulePackages(stale), []string{"bcrypt", "nested"}) {
+		t.Errorf("stale packages for ABI 108 = %v", modulePackages(stale))
+	}
+}
@@ -0,0 +1,130 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// checkNodeJS chooses the Node.js to run the app with: the path from
+// launcher.json if set, otherwise the newest installation that satisfies
+// the app's engines range.
+func (l *Launcher) checkNodeJS() error {
+	if path := l.cfg.Settings.Node.Path; path != "" {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			l.logAndSync("[INFO] Using Node.js from %s: %s", SettingsFile, path)
+			l.nodePath = path
+			return nil
+		}
+		l.logAndSync("[WARNING] Node.js from %s not found: %s", SettingsFile, path)
+		l.showWarning(fmt.Sprintf("Node.js aus %s nicht gefunden: %s", SettingsFile, path))
+	}
+
+	candidates := l.discoverNode()
+	if len(candidates) == 0 {
+		return fmt.Errorf("Node.js ist nicht installiert")
+	}
+	chosen := chooseNode(candidates)
+	l.logAndSync("[INFO] Using Node.js %s from %s (%s)", chosen.Compat.Version, chosen.Source, chosen.Path)
+	l.nodePath = chosen.Path
+	return nil
+}
+
+// discoverNode finds and checks the Node.js installations once.
+func (l *Launcher) discoverNode() []nodeCandidate {
+	if l.nodeCandidates != nil {
+		return l.nodeCandidates
+	}
+	candidates := checkNodeCandidates(l.appDir, findNodeCandidates())
+	l.logger.Printf("[INFO] Found %d Node.js installation(s):\n", len(candidates))
+	for _, c := range candidates {
+		l.logger.Printf("[INFO]   %s %s (%s): %v\n", c.Compat.Version, c.Path, c.Source, c.Compat.Support)
+	}
+	l.nodeCandidates = candidates
+	return candidates
+}
+
+// checkNodeCandidates checks each candidate against the engines range of
+// the app in appDir.
+func checkNodeCandidates(appDir string, candidates []nodeCandidate) []nodeCandidate {
+	// Starting node takes a moment; ask all installations at once
+	var wg sync.WaitGroup
+	for i := range candidates {
+		wg.Add(1)
+		go func(c *nodeCandidate) {
+			defer wg.Done()
+			c.Compat = CheckNodeVersion(appDir, nodeVersion(c.Path))
+		}(&candidates[i])
+	}
+	wg.Wait()
+	return candidates
+}
+
+// FindNode returns the Node.js to run the app in appDir with, chosen like
+// the local launchers do: the path from s if it exists, otherwise the
+// newest installation that satisfies the app's engines range, or the first
+// one found if none does, so that c explains the problem. If none is
+// installed, c only holds the app's ranges for the hint which version to
+// install.
+func FindNode(appDir string, s NodeSettings) (path string, c NodeCompat, err error) {
+	if fileExists(s.Path) {
+		return s.Path, CheckNodeVersion(appDir, nodeVersion(s.Path)), nil
+	}
+	candidates := checkNodeCandidates(appDir, findNodeCandidates())
+	if len(candidates) == 0 {
+		return "", nodeRequirements(appDir), fmt.Errorf("Node.js ist nicht installiert")
+	}
+	chosen := chooseNode(candidates)
+	return chosen.Path, chosen.Compat, nil
+}
+
+// hasUsableNode is the skip check of the runtime phase: a Node.js only
+// needs to be downloaded if none is configured or installed that satisfies
+// the app's engines range.
+func (l *Launcher) hasUsableNode() bool {
+	rs := l.cfg.Settings.Runtime
+	if !rs.Provision || rs.Version == "" {
+		return true
+	}
+	if path := l.cfg.Settings.Node.Path; path != "" && fileExists(path) {
+		return true
+	}
+	for _, c := range l.discoverNode() {
+		if supportRank(c.Compat.Support) > 0 {
+			return true
+		}
+		// Downloading the same release again would not help
+		if c.Source == "runtime" && strings.TrimPrefix(c.Compat.Version, "v") == rs.Version {
+			return true
+		}
+	}
+	l.logAndSync("[INFO] No suitable Node.js installed - provisioning v%s", rs.Version)
+	return false
+}
+
+func (l *Launcher) getNodeVersion() string {
+	return nodeVersion(l.nodePath)
+}
+
+// checkNodeVersionCompatibility checks version (e.g. "v24.11.1") against
+// the app's engines range, logs the result and warns about versions that are
+// not fully supported. The caller decides whether to stop.
+func (l *Launcher) checkNodeVersionCompatibility(p *PhaseProgress, version string) NodeCompat {
+	c := CheckNodeVersion(l.appDir, version)
+	l.logger.Printf("[INFO] Node.js %s against engines %q (tested %q): %v\n", version, c.Range, c.Tested, c.Support)
+
+	switch c.Support {
+	case NodeSupported:
+		l.logger.Printf("[SUCCESS] %s\n", c.Reason)
+	case NodeUnknown:
+		l.logger.Printf("[WARNING] Cannot check Node.js version: %s\n", c.Reason)
+	case NodeTooOld:
+		l.logger.Printf("[ERROR] %s\n", c.Reason)
+	default:
+		l.logger.Printf("[WARNING] %s\n", c.Reason)
+		p.Status("⚠️ " + c.Reason)
+		l.showWarning(c.Reason)
+	}
+	return c
+}
@@ -0,0 +1,120 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeNode writes a node script into a new directory that prints version,
+// and returns the directory.
+func fakeNode(t *testing.T, version string) string {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho " + version + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "node"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// isolateNode hides the Node.js installations of this machine: only the
+// given directories are on PATH and the version managers find nothing.
+func isolateNode(t *testing.T, dirs ...string) {
+	t.Helper()
+	home := t.TempDir()
+	for _, key := range []string{"HOME", "NVM_DIR", "FNM_DIR", "VOLTA_HOME", "ASDF_DATA_DIR", "N_PREFIX"} {
+		t.Setenv(key, home)
+	}
+	t.Setenv("PATH", strings.Join(dirs, string(os.PathListSeparator)))
+}
+
+func TestFindNode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake node is a shell script")
+	}
+	appDir := t.TempDir()
+	pkg := `{"engines": {"node": ">=18 <23"}, "testedEngines": {"node": ">=20 <23"}}`
+	if err := os.WriteFile(filepath.Join(appDir, "package.json"), []byte(pkg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		versions []string
+		want     string
+		support  NodeSupport
+	}{
+		{"newest tested", []string{"v18.19.0", "v22.3.0", "v20.11.0"}, "v22.3.0", NodeSupported},
+		{"tested over untested", []string{"v18.19.0", "v20.11.0"}, "v20.11.0", NodeSupported},
+		{"outside the range skipped", []string{"v24.1.0", "v18.19.0"}, "v18.19.0", NodeUntested},
+		{"none in range", []string{"v16.20.0", "v24.1.0"}, "v16.20.0", NodeTooOld},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dirs []string
+			for _, v := range tt.versions {
+				dirs = append(dirs, fakeNode(t, v))
+			}
+			isolateNode(t, dirs...)
+
+			path, c, err := FindNode(appDir, NodeSettings{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Version != tt.want || c.Support != tt.support {
+				t.Errorf("FindNode chose %s (%v), want %s (%v)", c.Version, c.Support, tt.want, tt.support)
+			}
+			if got := nodeVersion(path); got != c.Version {
+				t.Errorf("path %s runs %s, want %s", path, got, c.Version)
+			}
+		})
+	}
+}
+
+func TestFindNodeMissing(t *testing.T) {
+	appDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(appDir, "package.json"), []byte(`{"engines": {"node": ">=20"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	isolateNode(t)
+
+	_, c, err := FindNode(appDir, NodeSettings{})
+	if err == nil {
+		t.Fatal("FindNode found a Node.js on an empty PATH")
+	}
+	if c.Range != ">=20" {
+		t.Errorf("Range = %q, want the app's engines range for the hint", c.Range)
+	}
+}
+
+func TestFindNodeConfigured(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake node is a shell script")
+	}
+	appDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(appDir, "package.json"), []byte(`{"engines": {"node": ">=20"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	isolateNode(t, fakeNode(t, "v22.3.0"))
+	configured := filepath.Join(fakeNode(t, "v18.19.0"), "node")
+
+	path, c, err := FindNode(appDir, NodeSettings{Path: configured})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != configured || c.Support != NodeTooOld {
+		t.Errorf("FindNode = %s (%v), want the configured %s checked against the range", path, c.Support, configured)
+	}
+
+	// A configured path that does not exist falls back to the search
+	path, c, err = FindNode(appDir, NodeSettings{Path: filepath.Join(t.TempDir(), "node")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != "v22.3.0" {
+		t.Errorf("FindNode chose %s (%s), want the installed v22.3.0", path, c.Version)
+	}
+}
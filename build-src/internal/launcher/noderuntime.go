@@ -0,0 +1,205 @@
+package launcher
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/semver"
+)
+
+// nodeCandidate is one Node.js installation found on this machine.
+type nodeCandidate struct {
+	Path   string
+	Source string // where it was found, for the log
+	Compat NodeCompat
+}
+
+func nodeExecutable() string {
+	if runtime.GOOS == "windows" {
+		return "node.exe"
+	}
+	return "node"
+}
+
+// RuntimeDir is the folder next to the executable that holds a portable
+// Node.js for the launcher.
+const RuntimeDir = "runtime"
+
+// bundledNodePaths returns where a portable Node.js beside the executable
+// keeps its binary: at the top of the Windows zip, in bin/ of the tarballs.
+func bundledNodePaths() []string {
+	exeDir, _, err := Dirs()
+	if err != nil {
+		return nil
+	}
+	dir := filepath.Join(exeDir, RuntimeDir, "node")
+	return []string{filepath.Join(dir, nodeExecutable()), filepath.Join(dir, "bin", nodeExecutable())}
+}
+
+// versionManagerGlobs returns glob patterns for the Node.js versions
+// installed by nvm, fnm, volta and friends.
+func versionManagerGlobs() map[string][]string {
+	home, _ := os.UserHomeDir()
+	env := func(key, fallback string) string {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		return fallback
+	}
+
+	if runtime.GOOS == "windows" {
+		appData, localAppData := os.Getenv("APPDATA"), os.Getenv("LOCALAPPDATA")
+		return map[string][]string{
+			"nvm-windows": {filepath.Join(env("NVM_HOME", filepath.Join(appData, "nvm")), "v*", "node.exe")},
+			"fnm":         {filepath.Join(env("FNM_DIR", filepath.Join(appData, "fnm")), "node-versions", "*", "installation", "node.exe")},
+			"volta":       {filepath.Join(env("VOLTA_HOME", filepath.Join(localAppData, "Volta")), "tools", "image", "node", "*", "node.exe")},
+			"nodejs":      {filepath.Join(env("ProgramFiles", `C:\Program Files`), "nodejs", "node.exe")},
+		}
+	}
+	return map[string][]string{
+		"nvm": {filepath.Join(env("NVM_DIR", filepath.Join(home, ".nvm")), "versions", "node", "*", "bin", "node")},
+		"fnm": {
+			filepath.Join(env("FNM_DIR", filepath.Join(home, ".local", "share", "fnm")), "node-versions", "*", "installation", "bin", "node"),
+			filepath.Join(home, ".fnm", "node-versions", "*", "installation", "bin", "node"),
+		},
+		"volta":    {filepath.Join(env("VOLTA_HOME", filepath.Join(home, ".volta")), "tools", "image", "node", "*", "bin", "node")},
+		"asdf":     {filepath.Join(env("ASDF_DATA_DIR", filepath.Join(home, ".asdf")), "installs", "nodejs", "*", "bin", "node")},
+		"nodenv":   {filepath.Join(home, ".nodenv", "versions", "*", "bin", "node")},
+		"n":        {filepath.Join(env("N_PREFIX", "/usr/local"), "n", "versions", "node", "*", "bin", "node")},
+		"homebrew": {"/opt/homebrew/opt/node*/bin/node", "/usr/local/opt/node*/bin/node"},
+	}
+}
+
+// findNodeCandidates lists the Node.js installations in the order the
+// launcher prefers them when they are otherwise equal: the bundled runtime,
+// PATH, then version managers. The same binary is only listed once.
+func findNodeCandidates() []nodeCandidate {
+	var found []nodeCandidate
+	seen := make(map[string]bool)
+	add := func(path, source string) {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			return
+		}
+		key := path
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			key = resolved
+		}
+		if abs, err := filepath.Abs(key); err == nil {
+			key = abs
+		}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		found = append(found, nodeCandidate{Path: path, Source: source})
+	}
+
+	for _, path := range bundledNodePaths() {
+		add(path, "runtime")
+	}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != "" {
+			add(filepath.Join(dir, nodeExecutable()), "PATH")
+		}
+	}
+
+	globs := versionManagerGlobs()
+	managers := make([]string, 0, len(globs))
+	for name := range globs {
+		managers = append(managers, name)
+	}
+	sort.Strings(managers)
+	for _, name := range managers {
+		for _, pattern := range globs[name] {
+			matches, _ := filepath.Glob(pattern)
+			for _, path := range matches {
+				add(path, name)
+			}
+		}
+	}
+	return found
+}
+
+// nodeVersion runs "node --version" for the binary at path.
+func nodeVersion(path string) string {
+	output, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// supportRank orders verdicts from most to least preferred; versions
+// outside the engines range are never picked over one inside it.
+func supportRank(s NodeSupport) int {
+	switch s {
+	case NodeSupported:
+		return 3
+	case NodeUntested:
+		return 2
+	case NodeUnknown:
+		return 1
+	}
+	return 0
+}
+
+// chooseNode picks the newest candidate that satisfies the app's engines
+// range, preferring tested versions. If none does, the first candidate is
+// returned so that the version check can explain the problem.
+func chooseNode(candidates []nodeCandidate) nodeCandidate {
+	best := -1
+	for i, c := range candidates {
+		if supportRank(c.Compat.Support) == 0 {
+			continue
+		}
+		if best < 0 || betterNode(c, candidates[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return candidates[0]
+	}
+	return candidates[best]
+}
+
+func betterNode(a, b nodeCandidate) bool {
+	if ra, rb := supportRank(a.Compat.Support), supportRank(b.Compat.Support); ra != rb {
+		return ra > rb
+	}
+	va, errA := semver.Parse(a.Compat.Version)
+	vb, errB := semver.Parse(b.Compat.Version)
+	if errA != nil || errB != nil {
+		return errA == nil && errB != nil
+	}
+	return vb.Less(va)
+}
+
+// nodeEnv returns the environment for Node.js and npm processes: the
+// directory of the chosen node comes first on PATH, so that npm and its
+// scripts run with the same Node.js as the server.
+func (l *Launcher) nodeEnv() []string {
+	return NodeEnv(l.nodePath)
+}
+
+// NodeEnv returns the current environment with the directory of nodePath
+// first on PATH, for launchers that start node themselves.
+func NodeEnv(nodePath string) []string {
+	env := os.Environ()
+	if nodePath == "" {
+		return env
+	}
+	dir := filepath.Dir(nodePath)
+	for i, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		if strings.EqualFold(key, "PATH") {
+			env[i] = key + "=" + dir + string(os.PathListSeparator) + value
+			return env
+		}
+	}
+	return append(env, "PATH="+dir)
+}
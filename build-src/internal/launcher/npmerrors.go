@@ -0,0 +1,329 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Remedy is something the launcher can try after npm failed.
+type Remedy string
+
+const (
+	RemedyRetry          Remedy = "retry"
+	RemedyCleanCache     Remedy = "clean-cache"
+	RemedyLegacyPeerDeps Remedy = "legacy-peer-deps"
+)
+
+// Label describes the remedy for a button or a menu.
+func (r Remedy) Label() string {
+	switch r {
+	case RemedyRetry:
+		return "Erneut versuchen"
+	case RemedyCleanCache:
+		return "npm-Cache leeren und erneut versuchen"
+	case RemedyLegacyPeerDeps:
+		return "Mit --legacy-peer-deps erneut versuchen"
+	}
+	return string(r)
+}
+
+// NpmFailure is a failed npm run matched against known error signatures.
+type NpmFailure struct {
+	// Code identifies the kind of failure, e.g. "NPM_ERESOLVE".
+	Code string
+	// Title and Explanation describe the failure to the user.
+	Title       string
+	Explanation string
+	Hints       []string
+	// Remedies are the fixes worth trying, the most promising first.
+	Remedies []Remedy
+	// Excerpt holds npm's error output.
+	Excerpt []string
+}
+
+// npmFailureKind is a known npm failure. It is recognised by the code npm
+// prints ("npm ERR! code ERESOLVE", "npm error code ERESOLVE") or, failing
+// that, by a pattern in the output.
+type npmFailureKind struct {
+	code        string
+	npmCodes    []string
+	pattern     *regexp.Regexp
+	title       string
+	explanation string
+	hints       func() []string
+	remedies    []Remedy
+}
+
+func staticHints(hints ...string) func() []string {
+	return func() []string { return hints }
+}
+
+// buildToolHints tells how to install a C++ toolchain on this platform.
+func buildToolHints() []string {
+	switch runtime.GOOS {
+	case "windows":
+		return []string{
+			"💡 Installiere die Visual Studio Build Tools mit 'Desktop development with C++'",
+			"💡 Download: https://visualstudio.microsoft.com/downloads/",
+			"💡 Oder nutze eine Node.js LTS Version, für die fertige Module existieren",
+		}
+	case "darwin":
+		return []string{
+			"💡 Installiere die Xcode Command Line Tools: xcode-select --install",
+			"💡 Oder nutze eine Node.js LTS Version, für die fertige Module existieren",
+		}
+	}
+	return []string{
+		"💡 Installiere Compiler und Python, z.B.: sudo apt install build-essential python3",
+		"💡 Oder nutze eine Node.js LTS Version, für die fertige Module existieren",
+	}
+}
+
+// npmFailureKinds are checked in order; the first match wins.
+var npmFailureKinds = []npmFailureKind{
+	{
+		code:        "NPM_ENOSPC",
+		npmCodes:    []string{"ENOSPC"},
+		pattern:     regexp.MustCompile(`ENOSPC|no space left on device`),
+		title:       "Kein Speicherplatz mehr frei",
+		explanation: "Auf dem Laufwerk ist nicht genug Platz für die Abhängigkeiten.",
+		hints:       staticHints("💡 Gib mindestens 1 GB Speicherplatz frei", "💡 Das Leeren des npm-Caches schafft ebenfalls Platz"),
+		remedies:    []Remedy{RemedyCleanCache, RemedyRetry},
+	},
+	{
+		code:        "NPM_EINTEGRITY",
+		npmCodes:    []string{"EINTEGRITY"},
+		pattern:     regexp.MustCompile(`EINTEGRITY|integrity checksum failed|Verification failed while extracting`),
+		title:       "Beschädigter Download",
+		explanation: "Ein heruntergeladenes Paket stimmt nicht mit seiner Prüfsumme überein. Meist ist der npm-Cache beschädigt oder ein Download wurde unterbrochen.",
+		hints:       staticHints("💡 Leere den npm-Cache und versuche es erneut"),
+		remedies:    []Remedy{RemedyCleanCache, RemedyRetry},
+	},
+	{
+		code:        "NPM_ERESOLVE",
+		npmCodes:    []string{"ERESOLVE"},
+		pattern:     regexp.MustCompile(`ERESOLVE|Conflicting peer dependency|unable to resolve dependency tree`),
+		title:       "Versionskonflikt zwischen Paketen",
+		explanation: "npm findet keine Kombination von Paketversionen, die alle Anforderungen (peer dependencies) erfüllt.",
+		hints:       staticHints("💡 --legacy-peer-deps installiert trotz des Konflikts, wie ältere npm Versionen"),
+		remedies:    []Remedy{RemedyLegacyPeerDeps, RemedyRetry},
+	},
+	{
+		code:        "NPM_EBUILD",
+		pattern:     regexp.MustCompile(`gyp ERR!|find any Visual Studio|No Xcode or CLT|xcrun: error|make: (\S+: )?not found|g\+\+: (command )?not found|Could not find any Python`),
+		title:       "Native Module konnten nicht gebaut werden",
+		explanation: "Ein Paket mit nativem Code (z.B. better-sqlite3) musste kompiliert werden, aber die Build-Werkzeuge fehlen oder passen nicht zur Node.js Version.",
+		hints:       buildToolHints,
+		remedies:    []Remedy{RemedyRetry},
+	},
+	{
+		code:        "NPM_ENETWORK",
+		npmCodes:    []string{"ENOTFOUND", "ETIMEDOUT", "ECONNRESET", "ECONNREFUSED", "EAI_AGAIN", "ENETUNREACH", "ERR_SOCKET_TIMEOUT"},
+		pattern:     regexp.MustCompile(`ENOTFOUND|ETIMEDOUT|ECONNRESET|ECONNREFUSED|EAI_AGAIN|ENETUNREACH|socket hang up|network request to .* failed`),
+		title:       "Keine Verbindung zur npm-Registry",
+		explanation: "npm konnte die Pakete nicht herunterladen, weil die Registry nicht erreichbar war.",
+		hints:       staticHints("💡 Prüfe deine Internetverbindung", "💡 Firewall, Proxy oder VPN können npm blockieren"),
+		remedies:    []Remedy{RemedyRetry},
+	},
+	{
+		code:        "NPM_EACCES",
+		npmCodes:    []string{"EACCES", "EPERM", "EBUSY"},
+		pattern:     regexp.MustCompile(`EACCES|EPERM|EBUSY|permission denied|operation not permitted|resource busy or locked`),
+		title:       "Keine Schreibrechte",
+		explanation: "npm darf Dateien im App-Ordner oder im npm-Cache nicht ändern. Oft sperrt ein laufendes Programm oder ein Virenscanner die Dateien.",
+		hints: staticHints(
+			"💡 Beende laufende Instanzen des Tools und schließe Programme, die Dateien im App-Ordner geöffnet haben",
+			"💡 Installiere das Tool nicht in einen geschützten Ordner wie 'Programme'",
+		),
+		remedies: []Remedy{RemedyRetry, RemedyCleanCache},
+	},
+	{
+		code:        "NPM_ENOTARGET",
+		npmCodes:    []string{"ETARGET", "E404"},
+		pattern:     regexp.MustCompile(`ETARGET|E404|No matching version found|404 Not Found`),
+		title:       "Paket nicht gefunden",
+		explanation: "Ein Paket oder eine Version aus package.json gibt es in der npm-Registry nicht.",
+		hints:       staticHints("💡 Lade die neueste Version des Tools herunter"),
+		remedies:    []Remedy{RemedyCleanCache, RemedyRetry},
+	},
+	{
+		code:        "NPM_ELOCKFILE",
+		npmCodes:    []string{"EUSAGE"},
+		pattern:     regexp.MustCompile(`can only install packages when your package\.json and package-lock\.json`),
+		title:       "package-lock.json passt nicht zu package.json",
+		explanation: "npm ci installiert nur, wenn package-lock.json zu package.json passt.",
+		hints:       staticHints("💡 Lade die neueste Version des Tools herunter", "💡 Oder führe manuell aus: cd app && npm install"),
+	},
+	{
+		code:        "NPM_EJSONPARSE",
+		npmCodes:    []string{"EJSONPARSE"},
+		pattern:     regexp.MustCompile(`EJSONPARSE`),
+		title:       "package.json ist beschädigt",
+		explanation: "npm kann package.json oder package-lock.json nicht lesen.",
+		hints:       staticHints("💡 Lade die neueste Version des Tools herunter"),
+	},
+}
+
+// npmCodeRe matches the error code line of npm 6-9 and npm 10+.
+var npmCodeRe = regexp.MustCompile(`^npm (?:ERR!|error) code (\S+)`)
+
+// npmExcerptLines is how many lines of npm's error output are kept.
+const npmExcerptLines = 30
+
+// ClassifyNpmFailure matches npm's error output against known failures.
+// node holds the app's Node.js ranges for the hints.
+func ClassifyNpmFailure(output []string, node NodeCompat) NpmFailure {
+	kind, found := matchNpmFailure(output)
+	if !found {
+		return NpmFailure{
+			Code:        "NPM_UNKNOWN",
+			Title:       "Installation fehlgeschlagen",
+			Explanation: "npm ist mit einem unbekannten Fehler abgebrochen.",
+			Hints:       installHints(node),
+			Remedies:    []Remedy{RemedyRetry, RemedyCleanCache},
+			Excerpt:     npmExcerpt(output),
+		}
+	}
+	return NpmFailure{
+		Code:        kind.code,
+		Title:       kind.title,
+		Explanation: kind.explanation,
+		Hints:       kind.hints(),
+		Remedies:    kind.remedies,
+		Excerpt:     npmExcerpt(output),
+	}
+}
+
+func matchNpmFailure(output []string) (npmFailureKind, bool) {
+	for _, line := range output {
+		m := npmCodeRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		for _, kind := range npmFailureKinds {
+			for _, code := range kind.npmCodes {
+				if code == m[1] {
+					return kind, true
+				}
+			}
+		}
+	}
+
+	// No code, or one without a kind (such as a failed install script)
+	for _, kind := range npmFailureKinds {
+		for _, line := range output {
+			if kind.pattern.MatchString(line) {
+				return kind, true
+			}
+		}
+	}
+	return npmFailureKind{}, false
+}
+
+// npmExcerpt returns npm's error lines, or the end of the output if there
+// are none.
+func npmExcerpt(output []string) []string {
+	var excerpt []string
+	for _, line := range output {
+		if strings.HasPrefix(line, "npm ERR!") || strings.HasPrefix(line, "npm error") || strings.HasPrefix(line, "gyp ERR!") {
+			excerpt = append(excerpt, line)
+		}
+	}
+	if len(excerpt) == 0 {
+		excerpt = output
+	}
+	if len(excerpt) > npmExcerptLines {
+		excerpt = excerpt[len(excerpt)-npmExcerptLines:]
+	}
+	return append([]string(nil), excerpt...)
+}
+
+// npmError is returned by installDependencies when npm fails.
+type npmError struct {
+	label   string
+	failure NpmFailure
+	err     error
+}
+
+func (e *npmError) Error() string {
+	return fmt.Sprintf("%s fehlgeschlagen (%s): %v", e.label, e.failure.Code, e.err)
+}
+
+func (e *npmError) Unwrap() error {
+	return e.err
+}
+
+// Status is the message shown for the failure.
+func (f NpmFailure) Status() string {
+	return fmt.Sprintf("FEHLER [%s]: %s", f.Code, f.Title)
+}
+
+// hintLines returns the explanation followed by the hints.
+func (f NpmFailure) hintLines() []string {
+	return append([]string{"📋 " + f.Explanation}, f.Hints...)
+}
+
+// installError turns a failed npm run into the *Error of the deps phase.
+func (l *Launcher) installError(p *PhaseProgress, err error) error {
+	var nerr *npmError
+	if !errors.As(err, &nerr) {
+		status := fmt.Sprintf("FEHLER: %v", err)
+		p.Status(status)
+		return &Error{Status: status, Hints: installHints(nodeRequirements(l.appDir)), Err: err}
+	}
+
+	f := nerr.failure
+	l.logAndSync("[ERROR] npm failure %s: %s", f.Code, f.Title)
+	p.Status(f.Status())
+	return &Error{
+		Status:  f.Status(),
+		Hints:   append(f.hintLines(), "💡 Prüfe app/logs/launcher_*.log für Details"),
+		Code:    f.Code,
+		Details: f.Excerpt,
+		Err:     err,
+	}
+}
+
+// remedyInstallFailure is the deps phase's retry hook: it asks the
+// front-end which remedy to try and applies it before npm runs again.
+func (l *Launcher) remedyInstallFailure(p *PhaseProgress, err error) bool {
+	var nerr *npmError
+	if !errors.As(err, &nerr) || l.cfg.ChooseRemedy == nil || len(nerr.failure.Remedies) == 0 {
+		return false
+	}
+
+	remedy := l.cfg.ChooseRemedy(nerr.failure)
+	if remedy == "" {
+		l.logAndSync("[INFO] No remedy chosen for %s - giving up", nerr.failure.Code)
+		return false
+	}
+	l.logAndSync("[AUTO-FIX] Remedy for %s: %s", nerr.failure.Code, remedy)
+
+	switch remedy {
+	case RemedyCleanCache:
+		p.Status("🔧 Leere npm-Cache...")
+		if err := l.cleanNpmCache(); err != nil {
+			l.logAndSync("[WARNING] npm cache clean failed: %v", err)
+		}
+	case RemedyLegacyPeerDeps:
+		l.npmExtraArgs = append(l.npmExtraArgs, "--legacy-peer-deps")
+	}
+	p.Status(fmt.Sprintf("🔄 %s...", remedy.Label()))
+	return true
+}
+
+// cleanNpmCache empties the cache npmArgs installs from.
+func (l *Launcher) cleanNpmCache() error {
+	cmd := l.npmCommand("cache", "clean", "--force", "--cache", "false")
+	cmd.Dir = l.appDir
+	output, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			l.logger.Printf("[npm cache] %s\n", line)
+		}
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+package launcher
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClassifyNpmFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		code     string
+		remedies []Remedy
+	}{
+		{
+			name: "EACCES npm 9",
+			output: `npm ERR! code EACCES
+npm ERR! syscall mkdir
+npm ERR! path /usr/lib/node_modules/express
+npm ERR! errno -13
+npm ERR! Error: EACCES: permission denied, mkdir '/usr/lib/node_modules/express'`,
+			code:     "NPM_EACCES",
+			remedies: []Remedy{RemedyRetry, RemedyCleanCache},
+		},
+		{
+			name: "EPERM on Windows npm 10",
+			output: `npm error code EPERM
+npm error syscall rename
+npm error path C:\LTTH\app\node_modules\.better-sqlite3-x1Yz
+npm error errno -4048
+npm error Error: EPERM: operation not permitted, rename 'C:\LTTH\app\node_modules\.better-sqlite3-x1Yz'`,
+			code:     "NPM_EACCES",
+			remedies: []Remedy{RemedyRetry, RemedyCleanCache},
+		},
+		{
+			name: "ENOTFOUND",
+			output: `npm ERR! code ENOTFOUND
+npm ERR! syscall getaddrinfo
+npm ERR! errno ENOTFOUND
+npm ERR! network request to https://registry.npmjs.org/express failed, reason: getaddrinfo ENOTFOUND registry.npmjs.org
+npm ERR! network This is a problem related to network connectivity.`,
+			code:     "NPM_ENETWORK",
+			remedies: []Remedy{RemedyRetry},
+		},
+		{
+			name: "ETIMEDOUT npm 10",
+			output: `npm error code ETIMEDOUT
+npm error errno ETIMEDOUT
+npm error network request to https://registry.npmjs.org/ws failed, reason: connect ETIMEDOUT 104.16.3.35:443`,
+			code:     "NPM_ENETWORK",
+			remedies: []Remedy{RemedyRetry},
+		},
+		{
+			name: "node-gyp without Visual Studio",
+			output: `npm ERR! code 1
+npm ERR! path C:\LTTH\app\node_modules\better-sqlite3
+npm ERR! command failed
+npm ERR! command C:\Windows\system32\cmd.exe /d /s /c prebuild-install || node-gyp rebuild --release
+npm ERR! prebuild-install warn install No prebuilt binaries found (target=23.1.0 runtime=node arch=x64 libc= platform=win32)
+npm ERR! gyp info using node-gyp@10.0.1
+npm ERR! gyp ERR! find VS could not find a version of Visual Studio 2017 or newer to use
+npm ERR! gyp ERR! find VS You need to install the latest version of Visual Studio
+npm ERR! gyp ERR! configure error
+npm ERR! gyp ERR! stack Error: Could not find any Visual Studio installation to use`,
+			code:     "NPM_EBUILD",
+			remedies: []Remedy{RemedyRetry},
+		},
+		{
+			name: "node-gyp without a compiler",
+			output: `gyp info spawn make
+make: g++: No such file or directory
+gyp ERR! build error
+gyp ERR! stack Error: ` + "`make`" + ` failed with exit code: 2
+npm error code 1`,
+			code:     "NPM_EBUILD",
+			remedies: []Remedy{RemedyRetry},
+		},
+		{
+			name: "EINTEGRITY",
+			output: `npm ERR! code EINTEGRITY
+npm ERR! sha512-abc== integrity checksum failed when using sha512: wanted sha512-abc== but got sha512-def==. (8345 bytes)`,
+			code:     "NPM_EINTEGRITY",
+			remedies: []Remedy{RemedyCleanCache, RemedyRetry},
+		},
+		{
+			name: "ERESOLVE",
+			output: `npm ERR! code ERESOLVE
+npm ERR! ERESOLVE unable to resolve dependency tree
+npm ERR!
+npm ERR! While resolving: pupcidslittletiktokhelper@1.0.3
+npm ERR! Found: react@18.2.0
+npm ERR! Could not resolve dependency:
+npm ERR! peer react@"^17.0.0" from some-widget@2.1.0`,
+			code:     "NPM_ERESOLVE",
+			remedies: []Remedy{RemedyLegacyPeerDeps, RemedyRetry},
+		},
+		{
+			name: "code wins over patterns",
+			// The ERESOLVE report mentions a path with "EACCES" in it
+			output: `npm error code ERESOLVE
+npm error A complete log of this run can be found in: /home/EACCES/.npm/_logs/debug-0.log`,
+			code:     "NPM_ERESOLVE",
+			remedies: []Remedy{RemedyLegacyPeerDeps, RemedyRetry},
+		},
+		{
+			name: "pattern without code",
+			output: `npm WARN old lockfile
+Error: socket hang up`,
+			code:     "NPM_ENETWORK",
+			remedies: []Remedy{RemedyRetry},
+		},
+		{
+			name:     "unknown",
+			output:   "npm ERR! code EWHATEVER\nnpm ERR! something else went wrong",
+			code:     "NPM_UNKNOWN",
+			remedies: []Remedy{RemedyRetry, RemedyCleanCache},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := ClassifyNpmFailure(strings.Split(tt.output, "\n"), NodeCompat{})
+			if f.Code != tt.code {
+				t.Errorf("Code = %s, want %s", f.Code, tt.code)
+			}
+			if !reflect.DeepEqual(f.Remedies, tt.remedies) {
+				t.Errorf("Remedies = %v, want %v", f.Remedies, tt.remedies)
+			}
+			if f.Title == "" || f.Explanation == "" || len(f.Hints) == 0 {
+				t.Errorf("failure %s has no title, explanation or hints: %+v", f.Code, f)
+			}
+			if len(f.Excerpt) == 0 {
+				t.Error("Excerpt is empty")
+			}
+		})
+	}
+}
+
+func TestNpmExcerpt(t *testing.T) {
+	output := []string{
+		"added 3 packages",
+		"npm ERR! code ERESOLVE",
+		"> some script output",
+		"gyp ERR! stack",
+		"npm error code E404",
+	}
+	want := []string{"npm ERR! code ERESOLVE", "gyp ERR! stack", "npm error code E404"}
+	if got := npmExcerpt(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("npmExcerpt = %q, want %q", got, want)
+	}
+
+	// Without error lines the end of the output is kept
+	var long []string
+	for i := 0; i < npmExcerptLines+5; i++ {
+		long = append(long, "line")
+	}
+	long[len(long)-1] = "last"
+	got := npmExcerpt(long)
+	if len(got) != npmExcerptLines || got[len(got)-1] != "last" {
+		t.Errorf("npmExcerpt kept %d lines ending in %q, want %d ending in \"last\"", len(got), got[len(got)-1], npmExcerptLines)
+	}
+}
@@ -0,0 +1,360 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// npmProgressArgs make npm report what it is doing: --timing prints a
+// "npm timing <step> Completed in <n>ms" line for every step and package,
+// loglevel info adds tarball fetches and the start of install scripts.
+var npmProgressArgs = []string{"--timing", "--loglevel", "info"}
+
+var (
+	npmTimingRe = regexp.MustCompile(`^npm timing (\S+) Completed in (\d+)ms`)
+	npmRunRe    = regexp.MustCompile(`^npm info run \S+ (\S+) (node_modules/\S+) `)
+	npmFetchRe  = regexp.MustCompile(`^npm http fetch GET \d+ (\S+\.tgz)`)
+)
+
+type npmStage int
+
+const (
+	npmResolve npmStage = iota
+	npmFetch
+	npmExtract
+	npmBuild
+	npmDone
+)
+
+// packageTiming is how long npm spent on one package.
+type packageTiming struct {
+	unpack time.Duration // fetch and extract
+	build  time.Duration // install scripts
+}
+
+// npmProgress follows npm's --timing and info output and turns it into
+// package counts, the current stage and the native builds running.
+type npmProgress struct {
+	mu sync.Mutex
+
+	stage npmStage
+	// total is the number of packages npm will install; 0 if unknown.
+	// buildTotal is the number of packages with install scripts.
+	total, buildTotal int
+	resolved, done    int
+	built             map[string]bool
+	building          map[string]time.Time
+	fetching          string
+
+	packages map[string]*packageTiming
+	steps    map[string]time.Duration
+
+	// Older npm versions print none of the lines above; then progress is
+	// estimated from the amount of output as before
+	structured bool
+	lines      int
+}
+
+func newNpmProgress(total, buildTotal int) *npmProgress {
+	return &npmProgress{
+		total:      total,
+		buildTotal: buildTotal,
+		built:      make(map[string]bool),
+		building:   make(map[string]time.Time),
+		packages:   make(map[string]*packageTiming),
+		steps:      make(map[string]time.Duration),
+	}
+}
+
+func (n *npmProgress) timing(location string) *packageTiming {
+	t := n.packages[location]
+	if t == nil {
+		t = &packageTiming{}
+		n.packages[location] = t
+	}
+	return t
+}
+
+// Line processes one line of npm output and reports whether it was one of
+// the structured lines; those need not be shown to the user.
+func (n *npmProgress) Line(line string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.lines++
+
+	if m := npmTimingRe.FindStringSubmatch(line); m != nil {
+		ms, _ := strconv.Atoi(m[2])
+		n.step(m[1], time.Duration(ms)*time.Millisecond)
+		n.structured = true
+		return true
+	}
+	if m := npmRunRe.FindStringSubmatch(line); m != nil {
+		if _, running := n.building[m[2]]; !running {
+			n.building[m[2]] = time.Now()
+		}
+		n.stage = npmBuild
+		n.structured = true
+		return true
+	}
+	if m := npmFetchRe.FindStringSubmatch(line); m != nil {
+		n.fetching = tarballPackage(m[1])
+		if n.stage < npmFetch {
+			n.stage = npmFetch
+		}
+		n.structured = true
+		return true
+	}
+	return strings.HasPrefix(line, "npm timing ") || strings.HasPrefix(line, "npm http ") || strings.HasPrefix(line, "npm info ")
+}
+
+// tarballPackage returns the package name from a registry tarball URL,
+// <registry>/<name>/-/<name>-<version>.tgz.
+func tarballPackage(url string) string {
+	path, _, _ := strings.Cut(url, "/-/")
+	parts := strings.Split(strings.ReplaceAll(path, "%2f", "/"), "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && strings.HasPrefix(parts[len(parts)-2], "@") {
+		name = parts[len(parts)-2] + "/" + name
+	}
+	return name
+}
+
+// step handles a "npm timing" line.
+func (n *npmProgress) step(name string, d time.Duration) {
+	switch {
+	case strings.HasPrefix(name, "idealTree:node_modules/"):
+		n.resolved++
+	case name == "reify:loadTrees":
+		n.steps["resolve"] = d
+		if n.total == 0 {
+			n.total = n.resolved
+		}
+		n.stage = npmFetch
+	case strings.HasPrefix(name, "reifyNode:"):
+		n.done++
+		n.timing(strings.TrimPrefix(name, "reifyNode:")).unpack = d
+		n.stage = npmExtract
+	case name == "reify:unpack":
+		n.steps["fetch+extract"] = d
+		n.stage = npmBuild
+	case strings.HasPrefix(name, "build:run:"):
+		// build:run:<event>:<location>
+		parts := strings.SplitN(name, ":", 4)
+		if len(parts) == 4 {
+			n.built[parts[3]] = true
+			delete(n.building, parts[3])
+			n.timing(parts[3]).build += d
+		}
+	case name == "build":
+		n.steps["build"] = d
+	case name == "reify" || strings.HasPrefix(name, "command:"):
+		n.stage = npmDone
+	}
+}
+
+// Fraction returns the progress between 0 and 1. Downloading and
+// extracting takes the first 60%, install scripts the rest.
+func (n *npmProgress) Fraction() float64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.structured {
+		// Never claim completion before npm has exited
+		return math.Min(float64(n.lines)/npmOutputLines, 0.9)
+	}
+	switch n.stage {
+	case npmResolve:
+		return 0.05
+	case npmFetch, npmExtract:
+		if n.total == 0 {
+			return 0.05
+		}
+		return 0.05 + 0.55*math.Min(float64(n.done)/float64(n.total), 1)
+	case npmBuild:
+		if n.buildTotal == 0 {
+			return 0.6
+		}
+		return 0.6 + 0.35*math.Min(float64(len(n.built))/float64(n.buildTotal), 1)
+	}
+	return 0.95
+}
+
+// Status describes the progress, e.g. "142/611 Pakete, baue better-sqlite3".
+// It is empty as long as npm reported nothing structured.
+func (n *npmProgress) Status() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.structured {
+		return ""
+	}
+	count := fmt.Sprintf("%d Pakete", n.done)
+	if n.total > 0 {
+		count = fmt.Sprintf("%d/%d Pakete", min(n.done, n.total), max(n.done, n.total))
+	}
+	if n.done == 0 && (n.stage == npmBuild || len(n.built) > 0) {
+		// npm rebuild installs nothing
+		count = fmt.Sprintf("%d Pakete gebaut", len(n.built))
+	}
+
+	switch n.stage {
+	case npmResolve:
+		if n.resolved == 0 {
+			return "Löse Abhängigkeiten auf..."
+		}
+		return fmt.Sprintf("Löse Abhängigkeiten auf... (%d Pakete)", n.resolved)
+	case npmFetch:
+		if n.fetching != "" {
+			return fmt.Sprintf("%s, lade %s herunter", count, n.fetching)
+		}
+		return fmt.Sprintf("%s, lade herunter", count)
+	case npmExtract:
+		return fmt.Sprintf("%s, entpacke", count)
+	case npmBuild:
+		if len(n.building) == 0 {
+			return fmt.Sprintf("%s, führe Install-Skripte aus", count)
+		}
+		var names []string
+		var since time.Time
+		for location, started := range n.building {
+			names = append(names, packageOfModule(location))
+			if since.IsZero() || started.Before(since) {
+				since = started
+			}
+		}
+		sort.Strings(names)
+		return fmt.Sprintf("%s, baue %s (%ds)", count, strings.Join(names, ", "), int(time.Since(since).Seconds()))
+	}
+	return fmt.Sprintf("%s, fertig", count)
+}
+
+// Summary returns log lines with the time spent per stage and on the
+// slowest packages.
+func (n *npmProgress) Summary(top int) []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.structured {
+		return nil
+	}
+	var stages []string
+	for _, name := range []string{"resolve", "fetch+extract", "build"} {
+		if d, ok := n.steps[name]; ok {
+			stages = append(stages, fmt.Sprintf("%s %v", name, d.Round(100*time.Millisecond)))
+		}
+	}
+	lines := []string{fmt.Sprintf("npm: %d packages installed, %d install scripts run (%s)", n.done, len(n.built), strings.Join(stages, ", "))}
+
+	locations := make([]string, 0, len(n.packages))
+	for location := range n.packages {
+		locations = append(locations, location)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		a, b := n.packages[locations[i]], n.packages[locations[j]]
+		return a.unpack+a.build > b.unpack+b.build
+	})
+	if len(locations) > top {
+		locations = locations[:top]
+	}
+	for _, location := range locations {
+		t := n.packages[location]
+		line := fmt.Sprintf("  %-40s %8v", location, (t.unpack + t.build).Round(time.Millisecond))
+		if t.build > 0 {
+			line += fmt.Sprintf(" (build %v)", t.build.Round(time.Millisecond))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// lockfilePackage is an entry of "packages" in package-lock.json v2/v3.
+type lockfilePackage struct {
+	Version          string   `json:"version"`
+	Link             bool     `json:"link"`
+	Optional         bool     `json:"optional"`
+	HasInstallScript bool     `json:"hasInstallScript"`
+	OS               []string `json:"os"`
+	CPU              []string `json:"cpu"`
+}
+
+// lockfilePackages returns the packages of package-lock.json that npm
+// installs on platform ("<os>-<arch>" as in the dependency fingerprint),
+// without the optional ones if omitOptional is set.
+func (l *Launcher) lockfilePackages(platform string, omitOptional bool) map[string]lockfilePackage {
+	data, err := os.ReadFile(filepath.Join(l.appDir, "package-lock.json"))
+	if err != nil {
+		return nil
+	}
+	var lock struct {
+		Packages map[string]lockfilePackage `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil
+	}
+
+	osName, arch, _ := strings.Cut(platform, "-")
+	for location, pkg := range lock.Packages {
+		if !strings.HasPrefix(location, "node_modules/") || pkg.Link || (omitOptional && pkg.Optional) ||
+			(platform != "" && (!platformAllowed(pkg.OS, osName) || !platformAllowed(pkg.CPU, arch))) {
+			delete(lock.Packages, location)
+		}
+	}
+	return lock.Packages
+}
+
+// lockfileCounts returns how many packages npm ci installs and how many of
+// them run install scripts; see lockfilePackages.
+func (l *Launcher) lockfileCounts(platform string, omitOptional bool) (total, scripts int) {
+	for _, pkg := range l.lockfilePackages(platform, omitOptional) {
+		total++
+		if pkg.HasInstallScript {
+			scripts++
+		}
+	}
+	return total, scripts
+}
+
+// lockfileVersions returns the versions of the top-level packages in
+// node_modules by name; see lockfilePackages.
+func (l *Launcher) lockfileVersions(platform string, omitOptional bool) map[string]string {
+	versions := make(map[string]string)
+	for location, pkg := range l.lockfilePackages(platform, omitOptional) {
+		name := strings.TrimPrefix(location, "node_modules/")
+		if !strings.Contains(name, "/node_modules/") {
+			versions[name] = pkg.Version
+		}
+	}
+	return versions
+}
+
+// platformAllowed evaluates an os or cpu list of package.json, which may
+// name allowed values or exclude values with a leading "!".
+func platformAllowed(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	allowed := false
+	onlyExcludes := true
+	for _, entry := range list {
+		if strings.HasPrefix(entry, "!") {
+			if entry[1:] == value {
+				return false
+			}
+			continue
+		}
+		onlyExcludes = false
+		if entry == value {
+			allowed = true
+		}
+	}
+	return allowed || onlyExcludes
+}
@@ -0,0 +1,165 @@
+package launcher
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestNpmProgressLine(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		structured bool
+		stage      npmStage
+	}{
+		// npm 8
+		{"npm 8 ideal tree", "npm timing idealTree:node_modules/express Completed in 3ms", true, npmResolve},
+		{"npm 8 reify node", "npm timing reifyNode:node_modules/express Completed in 120ms", true, npmExtract},
+		{"npm 8 run", "npm info run better-sqlite3@9.2.2 install node_modules/better-sqlite3 prebuild-install || node-gyp rebuild --release", true, npmBuild},
+		{"npm 8 fetch", "npm http fetch GET 200 https://registry.npmjs.org/express/-/express-4.18.2.tgz 35ms (cache miss)", true, npmFetch},
+		// npm 9
+		{"npm 9 load trees", "npm timing reify:loadTrees Completed in 812ms", true, npmFetch},
+		{"npm 9 build script", "npm timing build:run:install:node_modules/bcrypt Completed in 4321ms", true, npmResolve},
+		{"npm 9 command", "npm timing command:ci Completed in 25012ms", true, npmDone},
+		// npm 10
+		{"npm 10 reify", "npm timing reify Completed in 23456ms", true, npmDone},
+		{"npm 10 scoped fetch", "npm http fetch GET 200 https://registry.npmjs.org/@serialport/bindings-cpp/-/bindings-cpp-12.0.1.tgz 88ms (cache hit)", true, npmFetch},
+		{"npm 10 run postinstall", "npm info run usb@2.11.0 postinstall node_modules/usb node-gyp-build", true, npmBuild},
+		// CRLF from Windows consoles
+		{"CRLF timing", "npm timing reifyNode:node_modules/ws Completed in 7ms\r", true, npmExtract},
+		{"CRLF run", "npm info run bcrypt@5.1.1 install node_modules/bcrypt node-pre-gyp install --fallback-to-build\r", true, npmBuild},
+		// Known prefixes without progress, and unknown lines
+		{"timing without step", "npm timing npm:load Completed in 45ms", true, npmResolve},
+		{"info line", "npm info using npm@10.2.4", true, npmResolve},
+		{"warning", "npm WARN deprecated inflight@1.0.6: This module is not supported", false, npmResolve},
+		{"script output", "> better-sqlite3@9.2.2 install", false, npmResolve},
+		{"summary", "added 611 packages, and audited 612 packages in 38s", false, npmResolve},
+		{"empty", "", false, npmResolve},
+		{"timing lookalike", "npm timingX reifyNode:node_modules/a Completed in 1ms", false, npmResolve},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := newNpmProgress(10, 2)
+			if got := n.Line(tt.line); got != tt.structured {
+				t.Errorf("Line() = %v, want %v", got, tt.structured)
+			}
+			if n.stage != tt.stage {
+				t.Errorf("stage = %v, want %v", n.stage, tt.stage)
+			}
+		})
+	}
+}
+
+// npmCiOutput is an abridged `npm ci --timing --loglevel info` run of npm
+// 10 on Windows: three packages, one of them with an install script.
+const npmCiOutput = "npm info using npm@10.2.4\r\n" +
+	"npm info using node@v20.11.0\r\n" +
+	"npm timing npm:load Completed in 41ms\r\n" +
+	"npm timing idealTree:node_modules/express Completed in 2ms\r\n" +
+	"npm timing idealTree:node_modules/better-sqlite3 Completed in 1ms\r\n" +
+	"npm timing idealTree:node_modules/@serialport/bindings-cpp Completed in 1ms\r\n" +
+	"npm timing reify:loadTrees Completed in 640ms\r\n" +
+	"npm http fetch GET 200 https://registry.npmjs.org/express/-/express-4.18.2.tgz 35ms (cache miss)\r\n" +
+	"npm timing reifyNode:node_modules/express Completed in 180ms\r\n" +
+	"npm WARN deprecated glob@7.2.3: Glob versions prior to v9 are no longer supported\r\n" +
+	"npm timing reifyNode:node_modules/@serialport/bindings-cpp Completed in 95ms\r\n" +
+	"npm timing reifyNode:node_modules/better-sqlite3 Completed in 410ms\r\n" +
+	"npm timing reify:unpack Completed in 700ms\r\n" +
+	"npm info run better-sqlite3@9.2.2 install node_modules/better-sqlite3 prebuild-install || node-gyp rebuild --release\r\n" +
+	"npm timing build:run:install:node_modules/better-sqlite3 Completed in 5230ms\r\n" +
+	"npm timing build Completed in 5300ms\r\n" +
+	"npm timing reify Completed in 6900ms\r\n" +
+	"\r\n" +
+	"added 3 packages in 7s\r\n" +
+	"npm timing command:ci Completed in 7012ms\r\n"
+
+func TestNpmProgressTranscript(t *testing.T) {
+	n := newNpmProgress(0, 1)
+	var unstructured []string
+	last := 0.0
+	sc := bufio.NewScanner(strings.NewReader(npmCiOutput))
+	for sc.Scan() {
+		if !n.Line(sc.Text()) {
+			unstructured = append(unstructured, sc.Text())
+		}
+		frac := n.Fraction()
+		if frac < last-1e-9 {
+			t.Errorf("progress went back from %.2f to %.2f after %q", last, frac, sc.Text())
+		}
+		last = frac
+
+		switch sc.Text() {
+		case "npm timing reify:loadTrees Completed in 640ms":
+			if n.total != 3 {
+				t.Errorf("total = %d after resolving, want 3", n.total)
+			}
+		case "npm timing reifyNode:node_modules/express Completed in 180ms":
+			if got, want := n.Status(), "1/3 Pakete, entpacke"; got != want {
+				t.Errorf("Status() = %q, want %q", got, want)
+			}
+		case "npm info run better-sqlite3@9.2.2 install node_modules/better-sqlite3 prebuild-install || node-gyp rebuild --release":
+			if got, want := n.Status(), "3/3 Pakete, baue better-sqlite3 (0s)"; got != want {
+				t.Errorf("Status() = %q, want %q", got, want)
+			}
+		}
+	}
+
+	want := []string{"npm WARN deprecated glob@7.2.3: Glob versions prior to v9 are no longer supported", "", "added 3 packages in 7s"}
+	if strings.Join(unstructured, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unstructured lines = %q, want %q", unstructured, want)
+	}
+	if got := n.Fraction(); got != 0.95 {
+		t.Errorf("final Fraction() = %v, want 0.95", got)
+	}
+	if got, want := n.Status(), "3/3 Pakete, fertig"; got != want {
+		t.Errorf("final Status() = %q, want %q", got, want)
+	}
+
+	summary := n.Summary(2)
+	if len(summary) != 3 {
+		t.Fatalf("Summary(2) = %q, want a headline and two packages", summary)
+	}
+	if want := "npm: 3 packages installed, 1 install scripts run (resolve 600ms, fetch+extract 700ms, build 5.3s)"; summary[0] != want {
+		t.Errorf("summary headline = %q, want %q", summary[0], want)
+	}
+	if !strings.Contains(summary[1], "node_modules/better-sqlite3") || !strings.Contains(summary[1], "(build 5.23s)") {
+		t.Errorf("slowest package = %q, want better-sqlite3 with its build time", summary[1])
+	}
+	if !strings.Contains(summary[2], "node_modules/express") {
+		t.Errorf("second slowest package = %q, want express", summary[2])
+	}
+}
+
+func TestNpmProgressUnstructured(t *testing.T) {
+	// npm 6 prints none of the timing lines
+	n := newNpmProgress(0, 0)
+	for i := 0; i < 3*npmOutputLines; i++ {
+		n.Line("added 1 package")
+	}
+	if got := n.Fraction(); got != 0.9 {
+		t.Errorf("Fraction() = %v, want it capped at 0.9", got)
+	}
+	if got := n.Status(); got != "" {
+		t.Errorf("Status() = %q, want empty", got)
+	}
+	if got := n.Summary(10); got != nil {
+		t.Errorf("Summary() = %q, want nil", got)
+	}
+}
+
+func TestTarballPackage(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://registry.npmjs.org/express/-/express-4.18.2.tgz", "express"},
+		{"https://registry.npmjs.org/@serialport/bindings-cpp/-/bindings-cpp-12.0.1.tgz", "@serialport/bindings-cpp"},
+		{"https://registry.npmjs.org/@serialport%2fbindings-cpp/-/bindings-cpp-12.0.1.tgz", "@serialport/bindings-cpp"},
+		{"https://npm.example.com/repository/npm/ws/-/ws-8.16.0.tgz", "ws"},
+	}
+	for _, tt := range tests {
+		if got := tarballPackage(tt.url); got != tt.want {
+			t.Errorf("tarballPackage(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package launcher
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+var (
+	// listeningRe matches the dashboard address server.js prints once it
+	// listens.
+	listeningRe = regexp.MustCompile(`https?://(?:localhost|127\.0\.0\.1):(\d+)/dashboard\.html`)
+	// Node.js reports a native module built for another ABI over several
+	// lines: "The module '<path>.node'" followed by "was compiled against a
+	// different Node.js version using NODE_MODULE_VERSION 115".
+	nativeModuleRe = regexp.MustCompile(`The module '([^']+\.node)'`)
+	abiErrorRe     = regexp.MustCompile(`NODE_MODULE_VERSION \d+`)
+)
+
+// outputWatcher is an io.Writer for the server's output that remembers
+// what the launcher needs to know from it: the port the server announced
+// and native modules that failed to load.
+type outputWatcher struct {
+	mu      sync.Mutex
+	partial []byte
+	port    int
+	abi     bool
+	modules []string
+	module  string // last native module named in the output
+}
+
+func (w *outputWatcher) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := w.partial[:i]
+		w.partial = w.partial[i+1:]
+		if m := listeningRe.FindSubmatch(line); m != nil && w.port == 0 {
+			w.port, _ = strconv.Atoi(string(m[1]))
+		}
+		if m := nativeModuleRe.FindSubmatch(line); m != nil {
+			w.module = string(m[1])
+		}
+		if abiErrorRe.Match(line) {
+			w.abi = true
+			if w.module != "" {
+				w.modules = append(w.modules, w.module)
+				w.module = ""
+			}
+		}
+	}
+	// Output without newlines must not grow the buffer forever
+	if len(w.partial) > 4096 {
+		w.partial = w.partial[:0]
+	}
+	return len(p), nil
+}
+
+// Port returns the announced port, or 0 if none was seen yet.
+func (w *outputWatcher) Port() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.port
+}
+
+// ABIError reports whether the server failed to load a native module built
+// for another Node.js version, and the paths of the modules if Node.js
+// named them.
+func (w *outputWatcher) ABIError() (bool, []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.abi, append([]string(nil), w.modules...)
+}
@@ -0,0 +1,174 @@
+package launcher
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+)
+
+// Phase is one named step of the launcher pipeline. Its share of the
+// overall progress bar is given by Weight relative to the other phases.
+type Phase struct {
+	// Name identifies the phase in the log, e.g. "deps".
+	Name string
+	// Title is the status shown when the phase starts.
+	Title string
+	// Weight is the phase's share of the overall progress.
+	Weight float64
+	// Optional phases only log their error; the pipeline continues.
+	Optional bool
+	// Skip, if set, is asked before the phase runs. SkipStatus is shown
+	// instead of Title when it returns true.
+	Skip       func() bool
+	SkipStatus string
+	// Retries is the number of extra attempts after Run failed.
+	// BeforeRetry is called before each one; returning false gives up.
+	Retries     int
+	BeforeRetry func(p *PhaseProgress, err error) bool
+	// Run does the work and reports its sub-progress on p.
+	Run func(p *PhaseProgress) error
+}
+
+// PhaseProgress lets a running phase report progress between 0 and 1.
+type PhaseProgress struct {
+	pl    *Pipeline
+	index int
+	frac  float64
+}
+
+// Update sets the phase's sub-progress to frac and shows status.
+func (p *PhaseProgress) Update(frac float64, status string) {
+	p.pl.mu.Lock()
+	p.frac = math.Max(0, math.Min(1, frac))
+	value := p.pl.percent(p.index, p.frac)
+	p.pl.mu.Unlock()
+	p.pl.report(value, status)
+}
+
+// Status shows status without changing the sub-progress.
+func (p *PhaseProgress) Status(status string) {
+	p.pl.mu.Lock()
+	value := p.pl.percent(p.index, p.frac)
+	p.pl.mu.Unlock()
+	p.pl.report(value, status)
+}
+
+// Pipeline runs phases in order and maps their sub-progress onto a single
+// 0-100 progress value for a Sink.
+type Pipeline struct {
+	phases []Phase
+	sink   Sink
+	logf   func(format string, args ...interface{})
+	offset []float64 // accumulated weight before each phase
+	total  float64
+
+	mu   sync.Mutex // guards last and the phases' sub-progress
+	last int
+}
+
+// NewPipeline creates a pipeline reporting to sink and logging with logf.
+func NewPipeline(sink Sink, logf func(format string, args ...interface{}), phases ...Phase) *Pipeline {
+	pl := &Pipeline{
+		phases: phases,
+		sink:   sink,
+		logf:   logf,
+		offset: make([]float64, len(phases)),
+	}
+	for i, ph := range phases {
+		pl.offset[i] = pl.total
+		pl.total += ph.Weight
+	}
+	return pl
+}
+
+// percent converts the sub-progress of phase i into overall progress.
+func (pl *Pipeline) percent(i int, frac float64) int {
+	if pl.total <= 0 {
+		return 0
+	}
+	return int((pl.offset[i] + pl.phases[i].Weight*frac) / pl.total * 100)
+}
+
+// report forwards a progress update, never letting the bar move backwards.
+func (pl *Pipeline) report(value int, status string) {
+	pl.mu.Lock()
+	if value < pl.last {
+		value = pl.last
+	}
+	pl.last = value
+	pl.mu.Unlock()
+	pl.sink.Progress(value, status)
+}
+
+// Percent returns the last progress value sent to the sink.
+func (pl *Pipeline) Percent() int {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	return pl.last
+}
+
+// Run executes all phases. It stops at the first error of a phase that is
+// not optional and returns that error unchanged.
+func (pl *Pipeline) Run() error {
+	for i, ph := range pl.phases {
+		p := &PhaseProgress{pl: pl, index: i}
+
+		if ph.Skip != nil && ph.Skip() {
+			pl.logf("[Phase %s] Skipped", ph.Name)
+			emit(pl.sink, events.TypePhaseEnd, events.Phase{Name: ph.Name, Title: ph.Title, Skipped: true})
+			p.Update(1, ph.SkipStatus)
+			continue
+		}
+
+		pl.logf("[Phase %s] %s", ph.Name, ph.Title)
+		emit(pl.sink, events.TypePhaseStart, events.Phase{Name: ph.Name, Title: ph.Title})
+		p.Update(0, ph.Title)
+		start := time.Now()
+
+		err := ph.Run(p)
+		for attempt := 0; err != nil && attempt < ph.Retries; attempt++ {
+			if ph.BeforeRetry != nil && !ph.BeforeRetry(p, err) {
+				break
+			}
+			pl.logf("[Phase %s] Retry %d/%d after: %v", ph.Name, attempt+1, ph.Retries, err)
+			err = ph.Run(p)
+		}
+
+		if err != nil {
+			if !ph.Optional {
+				pl.logf("[Phase %s] Failed after %v: %v", ph.Name, time.Since(start).Round(time.Millisecond), err)
+				emit(pl.sink, events.TypePhaseEnd, events.Phase{Name: ph.Name, Title: ph.Title, Error: err.Error()})
+				return err
+			}
+			pl.logf("[WARNING] Optional phase %s failed: %v", ph.Name, err)
+			emit(pl.sink, events.TypeWarning, events.Message{Message: fmt.Sprintf("%s: %v", ph.Title, err)})
+		}
+
+		pl.logf("[Phase %s] Done in %v", ph.Name, time.Since(start).Round(time.Millisecond))
+		emit(pl.sink, events.TypePhaseEnd, events.Phase{Name: ph.Name, Title: ph.Title})
+		pl.mu.Lock()
+		p.frac = 1
+		pl.last = pl.percent(i, 1)
+		pl.mu.Unlock()
+	}
+	return nil
+}
+
+// String describes the phases and their share, for the log.
+func (pl *Pipeline) String() string {
+	s := ""
+	for i, ph := range pl.phases {
+		if i > 0 {
+			s += ", "
+		}
+		share := 0.0
+		if pl.total > 0 {
+			share = ph.Weight / pl.total * 100
+		}
+		s += fmt.Sprintf("%s=%.0f%%", ph.Name, share)
+	}
+	return s
+}
@@ -0,0 +1,244 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+)
+
+// recordingSink keeps everything a pipeline reports.
+type recordingSink struct {
+	mu       sync.Mutex
+	values   []int
+	statuses []string
+	events   []string
+}
+
+func (s *recordingSink) Progress(value int, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = append(s.values, value)
+	s.statuses = append(s.statuses, status)
+}
+
+func (s *recordingSink) Redirect(string) {}
+
+// Event records phase events as "<type> <name>", with ":skipped" or
+// ":error" appended, and warnings as "warning".
+func (s *recordingSink) Event(typ events.Type, data interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch d := data.(type) {
+	case events.Phase:
+		ev := fmt.Sprintf("%s %s", typ, d.Name)
+		if d.Skipped {
+			ev += ":skipped"
+		}
+		if d.Error != "" {
+			ev += ":error"
+		}
+		s.events = append(s.events, ev)
+	default:
+		s.events = append(s.events, string(typ))
+	}
+}
+
+func TestPipelineWeightedProgress(t *testing.T) {
+	sink := &recordingSink{}
+	pl := NewPipeline(sink, t.Logf,
+		Phase{Name: "a", Title: "A", Weight: 1, Run: func(p *PhaseProgress) error {
+			p.Update(0.5, "half of a")
+			return nil
+		}},
+		Phase{Name: "b", Title: "B", Weight: 3, Run: func(p *PhaseProgress) error {
+			p.Update(0.5, "half of b")
+			p.Status("still b")
+			p.Update(2, "beyond b")
+			return nil
+		}},
+	)
+	if err := pl.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a is 25% of the bar, b the remaining 75%
+	if want := []int{0, 12, 25, 62, 62, 100}; !reflect.DeepEqual(sink.values, want) {
+		t.Errorf("values = %v, want %v", sink.values, want)
+	}
+	if want := []string{"A", "half of a", "B", "half of b", "still b", "beyond b"}; !reflect.DeepEqual(sink.statuses, want) {
+		t.Errorf("statuses = %q, want %q", sink.statuses, want)
+	}
+	if want := []string{"phase-start a", "phase-end a", "phase-start b", "phase-end b"}; !reflect.DeepEqual(sink.events, want) {
+		t.Errorf("events = %q, want %q", sink.events, want)
+	}
+	if got := pl.Percent(); got != 100 {
+		t.Errorf("Percent() = %d, want 100", got)
+	}
+	if got, want := pl.String(), "a=25%, b=75%"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestPipelineNeverGoesBackwards(t *testing.T) {
+	sink := &recordingSink{}
+	pl := NewPipeline(sink, t.Logf,
+		Phase{Name: "a", Weight: 1, Run: func(p *PhaseProgress) error {
+			p.Update(0.8, "almost")
+			p.Update(0.2, "back")
+			p.Update(-1, "negative")
+			return nil
+		}},
+	)
+	if err := pl.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 80, 80, 80}; !reflect.DeepEqual(sink.values, want) {
+		t.Errorf("values = %v, want %v", sink.values, want)
+	}
+}
+
+func TestPipelineSkip(t *testing.T) {
+	sink := &recordingSink{}
+	ran := false
+	pl := NewPipeline(sink, t.Logf,
+		Phase{Name: "a", Title: "A", Weight: 1, SkipStatus: "A not needed", Skip: func() bool { return true }, Run: func(p *PhaseProgress) error {
+			ran = true
+			return nil
+		}},
+		Phase{Name: "b", Title: "B", Weight: 1, Skip: func() bool { return false }, Run: func(p *PhaseProgress) error { return nil }},
+	)
+	if err := pl.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if ran {
+		t.Error("skipped phase ran")
+	}
+	if want := []int{50, 50}; !reflect.DeepEqual(sink.values, want) {
+		t.Errorf("values = %v, want %v", sink.values, want)
+	}
+	if want := []string{"A not needed", "B"}; !reflect.DeepEqual(sink.statuses, want) {
+		t.Errorf("statuses = %q, want %q", sink.statuses, want)
+	}
+	if want := []string{"phase-end a:skipped", "phase-start b", "phase-end b"}; !reflect.DeepEqual(sink.events, want) {
+		t.Errorf("events = %q, want %q", sink.events, want)
+	}
+}
+
+func TestPipelineOptional(t *testing.T) {
+	sink := &recordingSink{}
+	ranNext := false
+	pl := NewPipeline(sink, t.Logf,
+		Phase{Name: "opt", Title: "Optional", Weight: 1, Optional: true, Run: func(p *PhaseProgress) error {
+			return errors.New("not important")
+		}},
+		Phase{Name: "next", Title: "Next", Weight: 1, Run: func(p *PhaseProgress) error {
+			ranNext = true
+			return nil
+		}},
+	)
+	if err := pl.Run(); err != nil {
+		t.Fatalf("Run() = %v, want the optional failure ignored", err)
+	}
+	if !ranNext {
+		t.Error("phase after the optional one did not run")
+	}
+	if want := []string{"phase-start opt", "warning", "phase-end opt", "phase-start next", "phase-end next"}; !reflect.DeepEqual(sink.events, want) {
+		t.Errorf("events = %q, want %q", sink.events, want)
+	}
+}
+
+func TestPipelineFailure(t *testing.T) {
+	sink := &recordingSink{}
+	failure := errors.New("broken")
+	ranNext := false
+	pl := NewPipeline(sink, t.Logf,
+		Phase{Name: "a", Weight: 1, Run: func(p *PhaseProgress) error {
+			p.Update(0.4, "working")
+			return failure
+		}},
+		Phase{Name: "b", Weight: 1, Run: func(p *PhaseProgress) error {
+			ranNext = true
+			return nil
+		}},
+	)
+	if err := pl.Run(); err != failure {
+		t.Fatalf("Run() = %v, want the phase's error unchanged", err)
+	}
+	if ranNext {
+		t.Error("phase after the failed one ran")
+	}
+	if want := []string{"phase-start a", "phase-end a:error"}; !reflect.DeepEqual(sink.events, want) {
+		t.Errorf("events = %q, want %q", sink.events, want)
+	}
+	if got := pl.Percent(); got != 20 {
+		t.Errorf("Percent() = %d, want 20", got)
+	}
+}
+
+func TestPipelineRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries int
+		// failures is how often Run fails before it succeeds
+		failures int
+		// allow is how many retries BeforeRetry allows; -1 means no hook
+		allow    int
+		wantRuns int
+		wantErr  bool
+	}{
+		{"succeeds on retry", 2, 1, -1, 2, false},
+		{"retries exhausted", 2, 5, -1, 3, true},
+		{"hook gives up", 3, 5, 1, 2, true},
+		{"hook allows", 3, 2, 3, 3, false},
+		{"no retries", 0, 1, -1, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs, hooks := 0, 0
+			ph := Phase{Name: "a", Weight: 1, Retries: tt.retries, Run: func(p *PhaseProgress) error {
+				runs++
+				if runs <= tt.failures {
+					return fmt.Errorf("failure %d", runs)
+				}
+				return nil
+			}}
+			if tt.allow >= 0 {
+				ph.BeforeRetry = func(p *PhaseProgress, err error) bool {
+					hooks++
+					if want := fmt.Sprintf("failure %d", runs); err.Error() != want {
+						t.Errorf("BeforeRetry got %v, want %s", err, want)
+					}
+					return hooks <= tt.allow
+				}
+			}
+			err := NewPipeline(&recordingSink{}, t.Logf, ph).Run()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() = %v, want error %v", err, tt.wantErr)
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("Run called %d times, want %d", runs, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestPipelineWithoutWeights(t *testing.T) {
+	sink := &recordingSink{}
+	pl := NewPipeline(sink, t.Logf, Phase{Name: "a", Run: func(p *PhaseProgress) error {
+		p.Update(0.5, "half")
+		return nil
+	}})
+	if err := pl.Run(); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range sink.values {
+		if v != 0 {
+			t.Errorf("values = %v, want all 0", sink.values)
+			break
+		}
+	}
+}
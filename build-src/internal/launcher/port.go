@@ -0,0 +1,88 @@
+package launcher
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	defaultPort = 3000
+	// portSearchRange is how many ports after the preferred one are tried
+	// when it is taken.
+	portSearchRange = 10
+)
+
+// ConfiguredPort returns the PORT from the .env in appDir, or 3000. An
+// invalid PORT is ignored: the result is then 3000 with an error saying why.
+func ConfiguredPort(appDir string) (int, error) {
+	env, err := readEnvFile(filepath.Join(appDir, ".env"))
+	if err != nil {
+		return defaultPort, nil
+	}
+	value, ok := env.Lookup("PORT")
+	if !ok || value == "" {
+		return defaultPort, nil
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return defaultPort, fmt.Errorf("invalid PORT=%q in .env", value)
+	}
+	return port, nil
+}
+
+// configuredPort returns the PORT from the app's .env, or 3000.
+func (l *Launcher) configuredPort() int {
+	port, err := ConfiguredPort(l.appDir)
+	if err != nil {
+		l.logger.Printf("[WARNING] Ignoring %v\n", err)
+	}
+	return port
+}
+
+// PortAvailable reports whether port can be listened on.
+func PortAvailable(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// FreePort returns the first free port in the portSearchRange ports after
+// the taken port preferred, or 0 if they are all taken.
+func FreePort(preferred int) int {
+	for port := preferred + 1; port <= preferred+portSearchRange && port <= 65535; port++ {
+		if PortAvailable(port) {
+			return port
+		}
+	}
+	return 0
+}
+
+// serverPort returns the port the server is started on.
+func (l *Launcher) serverPort() int {
+	if l.port == 0 {
+		l.port = l.configuredPort()
+	}
+	return l.port
+}
+
+// dashboardURL returns the dashboard address on port.
+func dashboardURL(port int) string {
+	return fmt.Sprintf("http://localhost:%d/dashboard.html", port)
+}
+
+// healthPorts returns the ports to probe for the started server: the one it
+// was given and, if different, the one it announced.
+func (l *Launcher) healthPorts() []int {
+	ports := []int{l.serverPort()}
+	if l.output != nil {
+		if announced := l.output.Port(); announced != 0 && announced != ports[0] {
+			ports = append(ports, announced)
+		}
+	}
+	return ports
+}
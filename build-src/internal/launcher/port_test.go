@@ -0,0 +1,114 @@
+package launcher
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfiguredPort(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string // "" for no .env
+		want    int
+		wantErr bool
+	}{
+		{name: "no .env", want: defaultPort},
+		{name: "no PORT", env: "NODE_ENV=production\n", want: defaultPort},
+		{name: "empty PORT", env: "PORT=\n", want: defaultPort},
+		{name: "PORT", env: "NODE_ENV=production\nPORT=8080\n", want: 8080},
+		{name: "quoted with comment", env: `PORT="4000" # dashboard` + "\n", want: 4000},
+		{name: "export", env: "export PORT=5000\n", want: 5000},
+		{name: "commented out", env: "# PORT=8080\n", want: defaultPort},
+		{name: "first assignment wins", env: "PORT=8080\nPORT=9090\n", want: 8080},
+		{name: "not a number", env: "PORT=abc\n", want: defaultPort, wantErr: true},
+		{name: "zero", env: "PORT=0\n", want: defaultPort, wantErr: true},
+		{name: "too large", env: "PORT=70000\n", want: defaultPort, wantErr: true},
+		{name: "negative", env: "PORT=-1\n", want: defaultPort, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appDir := t.TempDir()
+			if tt.env != "" {
+				if err := os.WriteFile(filepath.Join(appDir, ".env"), []byte(tt.env), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			port, err := ConfiguredPort(appDir)
+			if port != tt.want {
+				t.Errorf("port = %d, want %d", port, tt.want)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// listenRange occupies n consecutive ports and returns the first. It
+// retries a few times, since any of them may be taken by another process.
+func listenRange(t *testing.T, n int) int {
+	t.Helper()
+	for attempt := 0; attempt < 20; attempt++ {
+		first := closedPort(t)
+		if first+n > 65535 {
+			continue
+		}
+		var listeners []net.Listener
+		for port := first; port < first+n; port++ {
+			ln, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+			if err != nil {
+				break
+			}
+			listeners = append(listeners, ln)
+		}
+		if len(listeners) == n {
+			t.Cleanup(func() {
+				for _, ln := range listeners {
+					ln.Close()
+				}
+			})
+			return first
+		}
+		for _, ln := range listeners {
+			ln.Close()
+		}
+	}
+	t.Fatalf("no %d consecutive free ports", n)
+	return 0
+}
+
+func TestPortAvailable(t *testing.T) {
+	taken := listenRange(t, 1)
+	if PortAvailable(taken) {
+		t.Errorf("PortAvailable(%d) = true for a port in use", taken)
+	}
+	if free := closedPort(t); !PortAvailable(free) {
+		t.Errorf("PortAvailable(%d) = false for a free port", free)
+	}
+}
+
+func TestFreePort(t *testing.T) {
+	// The preferred port and the next two are taken
+	preferred := listenRange(t, 3)
+	port := FreePort(preferred)
+	if port <= preferred+2 || port > preferred+portSearchRange {
+		t.Errorf("FreePort(%d) = %d, want a port in %d-%d", preferred, port, preferred+3, preferred+portSearchRange)
+	}
+	if !PortAvailable(port) {
+		t.Errorf("FreePort(%d) = %d, which is in use", preferred, port)
+	}
+
+	// Every port in the search range is taken
+	preferred = listenRange(t, portSearchRange+1)
+	if port := FreePort(preferred); port != 0 {
+		t.Errorf("FreePort(%d) = %d with the whole range taken, want 0", preferred, port)
+	}
+
+	// The search does not go past the last port
+	if port := FreePort(65535); port != 0 {
+		t.Errorf("FreePort(65535) = %d, want 0", port)
+	}
+}
@@ -0,0 +1,269 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	initStatePath = "/api/init-state"
+	probeTimeout  = 1 * time.Second
+)
+
+// InitState is the JSON returned by the server's /api/init-state
+// (modules/initialization-state.js).
+type InitState struct {
+	ServerStarted      bool `json:"serverStarted"`
+	DatabaseReady      bool `json:"databaseReady"`
+	PluginsLoaded      bool `json:"pluginsLoaded"`
+	PluginsInitialized bool `json:"pluginsInitialized"`
+	PluginInjections   bool `json:"pluginInjections"`
+	SocketReady        bool `json:"socketReady"`
+	Ready              bool `json:"ready"`
+
+	PluginStates []struct {
+		ID          string `json:"id"`
+		Initialized bool   `json:"initialized"`
+	} `json:"pluginStates"`
+	Errors []struct {
+		Component string `json:"component"`
+		Message   string `json:"message"`
+	} `json:"errors"`
+}
+
+// initStage is one step the server goes through while starting. Only
+// required stages hold up the launcher; the rest finish in the background.
+type initStage struct {
+	label    string
+	done     bool
+	required bool
+}
+
+func (s InitState) stages() []initStage {
+	return []initStage{
+		{"Datenbank", s.DatabaseReady, true},
+		{"Plugins geladen", s.pluginsSettled(), true},
+		{"HTTP-Server", s.ServerStarted, true},
+		{"Plugins initialisiert", s.PluginsInitialized, false},
+		{"Plugin-Erweiterungen", s.PluginInjections, false},
+		{"Socket.IO", s.SocketReady, false},
+	}
+}
+
+// pluginsSettled reports whether plugin loading is over. The server only
+// starts listening after the loader has finished or failed, so a started
+// server with recorded errors has given up on its plugins.
+func (s InitState) pluginsSettled() bool {
+	return s.PluginsLoaded || (s.ServerStarted && len(s.Errors) > 0)
+}
+
+// Usable reports whether the dashboard can be opened. The server's own
+// ready flag also waits for the first Socket.IO client and for every plugin
+// to initialise, neither of which happens before the launcher opens the
+// browser.
+func (s InitState) Usable() bool {
+	for _, st := range s.stages() {
+		if st.required && !st.done {
+			return false
+		}
+	}
+	return true
+}
+
+// Fraction returns the share of completed required initialisation stages.
+func (s InitState) Fraction() float64 {
+	if s.Ready || s.Usable() {
+		return 1
+	}
+	done, total := 0, 0
+	for _, st := range s.stages() {
+		if !st.required {
+			continue
+		}
+		total++
+		if st.done {
+			done++
+		}
+	}
+	return float64(done) / float64(total)
+}
+
+// Pending lists the labels of the required stages that are not complete yet.
+func (s InitState) Pending() []string {
+	return s.pending(true)
+}
+
+// Background lists the labels of the optional stages that are still running.
+func (s InitState) Background() []string {
+	return s.pending(false)
+}
+
+func (s InitState) pending(required bool) []string {
+	var pending []string
+	for _, st := range s.stages() {
+		if st.required == required && !st.done {
+			pending = append(pending, st.label)
+		}
+	}
+	return pending
+}
+
+// httpError is returned for unexpected answers; the server is up but not
+// (yet) able to report its state.
+type httpError struct {
+	status int
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("%s: HTTP %d", initStatePath, e.status)
+}
+
+func isHTTPError(err error) bool {
+	var herr *httpError
+	return errors.As(err, &herr)
+}
+
+// errNoInitState means the server answered but has no init-state endpoint,
+// i.e. it is an older version.
+var errNoInitState = errors.New("server has no " + initStatePath)
+
+var probeClient = &http.Client{Timeout: probeTimeout}
+
+// probeReadiness fetches the init-state of the server on port.
+func probeReadiness(port int) (InitState, error) {
+	var state InitState
+
+	resp, err := probeClient.Get(fmt.Sprintf("http://localhost:%d%s", port, initStatePath))
+	if err != nil {
+		return state, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return state, errNoInitState
+	}
+	if resp.StatusCode != http.StatusOK {
+		return state, &httpError{status: resp.StatusCode}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return state, &httpError{status: resp.StatusCode}
+	}
+	return state, nil
+}
+
+// probePorts probes all ports at once and returns the first one, in the
+// given order, that reported its init-state; failing that the first one on
+// which any server answered. port is 0 if none did.
+func probePorts(ports []int) (port int, state InitState, err error) {
+	type result struct {
+		state InitState
+		err   error
+	}
+	results := make([]chan result, len(ports))
+	for i, p := range ports {
+		results[i] = make(chan result, 1)
+		go func(p int, ch chan<- result) {
+			st, err := probeReadiness(p)
+			ch <- result{st, err}
+		}(p, results[i])
+	}
+
+	answered := -1
+	var fallback result
+	for i, ch := range results {
+		r := <-ch
+		if r.err == nil {
+			return ports[i], r.state, nil
+		}
+		if answered < 0 && (errors.Is(r.err, errNoInitState) || isHTTPError(r.err)) {
+			answered, fallback = i, r
+		}
+	}
+	if answered < 0 {
+		return 0, InitState{}, nil
+	}
+	return ports[answered], fallback.state, fallback.err
+}
+
+// checkLiveness is the cheap check the supervisor runs after startup: the
+// server only has to answer, not to be fully initialised.
+func checkLiveness(port int) bool {
+	req, err := http.NewRequest(http.MethodHead, fmt.Sprintf("http://localhost:%d%s", port, initStatePath), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < http.StatusInternalServerError
+}
+
+// ServerStarted reports whether the server on port listens for requests,
+// whether or not it has finished initialising. Servers without the
+// init-state endpoint count once they answer.
+func ServerStarted(port int) bool {
+	state, err := probeReadiness(port)
+	if errors.Is(err, errNoInitState) {
+		return true
+	}
+	return err == nil && state.ServerStarted
+}
+
+// WaitReady polls the server on port until its init-state reports it usable;
+// servers without the endpoint count as ready once they answer. It fails
+// when exited is closed or timeout has passed. report, if not nil, is
+// called with every state read.
+func WaitReady(port int, timeout time.Duration, exited <-chan struct{}, report func(InitState)) error {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	var last InitState
+	answered := false
+	for {
+		select {
+		case <-exited:
+			return fmt.Errorf("Server wurde beendet, bevor er bereit war")
+		case <-deadline:
+			if !answered {
+				return fmt.Errorf("Server hat nach %v nicht geantwortet", timeout)
+			}
+			msg := fmt.Sprintf("Server war nach %v nicht bereit (ausstehend: %s)", timeout, strings.Join(last.Pending(), ", "))
+			for _, e := range last.Errors {
+				msg += fmt.Sprintf("; %s: %s", e.Component, e.Message)
+			}
+			return errors.New(msg)
+		case <-ticker.C:
+			state, err := probeReadiness(port)
+			if errors.Is(err, errNoInitState) {
+				return nil
+			}
+			if err != nil {
+				answered = answered || isHTTPError(err)
+				continue
+			}
+			answered, last = true, state
+			if report != nil {
+				report(state)
+			}
+			if state.Usable() {
+				return nil
+			}
+		}
+	}
+}
+
+// readinessStatus describes state for the splash page.
+func readinessStatus(state InitState) string {
+	pending := state.Pending()
+	if len(pending) == 0 {
+		return "Server initialisiert..."
+	}
+	return fmt.Sprintf("Server initialisiert... (ausstehend: %s)", strings.Join(pending, ", "))
+}
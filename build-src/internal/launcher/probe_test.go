@@ -0,0 +1,168 @@
+package launcher
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// initStateJSON is what modules/initialization-state.js reports once the
+// server listens, with no browser connected yet.
+const initStateJSON = `{
+	"serverStarted": true,
+	"databaseReady": true,
+	"pluginsLoaded": true,
+	"pluginsInitialized": false,
+	"pluginInjections": true,
+	"socketReady": false,
+	"ready": false,
+	"pluginStates": [{"id": "tts", "initialized": true}],
+	"errors": []
+}`
+
+func TestInitStateUsable(t *testing.T) {
+	started := InitState{ServerStarted: true, DatabaseReady: true, PluginsLoaded: true}
+	loaderFailed := InitState{ServerStarted: true, DatabaseReady: true}
+	loaderFailed.Errors = append(loaderFailed.Errors, struct {
+		Component string `json:"component"`
+		Message   string `json:"message"`
+	}{"plugin-loader", "Failed to load plugins"})
+
+	tests := []struct {
+		name    string
+		state   InitState
+		usable  bool
+		pending []string
+	}{
+		{"nothing yet", InitState{}, false, []string{"Datenbank", "Plugins geladen", "HTTP-Server"}},
+		{"database only", InitState{DatabaseReady: true}, false, []string{"Plugins geladen", "HTTP-Server"}},
+		{"plugins loading", InitState{DatabaseReady: true, PluginsLoaded: true}, false, []string{"HTTP-Server"}},
+		{"no browser and no plugins", started, true, nil},
+		{"plugin loader failed", loaderFailed, true, nil},
+		{"no errors, plugins never loaded", InitState{ServerStarted: true, DatabaseReady: true}, false, []string{"Plugins geladen"}},
+		{"fully ready", InitState{Ready: true, ServerStarted: true, DatabaseReady: true, PluginsLoaded: true, PluginsInitialized: true, PluginInjections: true, SocketReady: true}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.Usable(); got != tt.usable {
+				t.Errorf("Usable() = %v, want %v", got, tt.usable)
+			}
+			if got := tt.state.Pending(); strings.Join(got, ",") != strings.Join(tt.pending, ",") {
+				t.Errorf("Pending() = %v, want %v", got, tt.pending)
+			}
+			if tt.usable && tt.state.Fraction() != 1 {
+				t.Errorf("Fraction() = %v, want 1", tt.state.Fraction())
+			}
+		})
+	}
+}
+
+func TestInitStateBackground(t *testing.T) {
+	var state InitState
+	if err := json.Unmarshal([]byte(initStateJSON), &state); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(state.Background(), ","), "Plugins initialisiert,Socket.IO"; got != want {
+		t.Errorf("Background() = %q, want %q", got, want)
+	}
+}
+
+// fakeInitState serves the given init-state bodies in turn, repeating the
+// last one, and returns the port it listens on.
+func fakeInitState(t *testing.T, bodies ...string) int {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != initStatePath {
+			http.NotFound(w, r)
+			return
+		}
+		i := int(calls.Add(1)) - 1
+		if i >= len(bodies) {
+			i = len(bodies) - 1
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(bodies[i]))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.Listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestWaitReady(t *testing.T) {
+	const (
+		booting   = `{"databaseReady": true}`
+		noPlugins = `{"serverStarted": true, "databaseReady": true, "pluginsLoaded": true, "pluginInjections": true, "errors": []}`
+		pluginErr = `{"serverStarted": true, "databaseReady": true, "errors": [{"component": "plugin-loader", "message": "Failed to load plugins"}]}`
+	)
+
+	tests := []struct {
+		name   string
+		bodies []string
+	}{
+		{"no socket client", []string{booting, initStateJSON}},
+		{"no plugins", []string{noPlugins}},
+		{"plugin loader error", []string{booting, pluginErr}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := fakeInitState(t, tt.bodies...)
+			var reports int
+			err := WaitReady(port, 5*time.Second, nil, func(InitState) { reports++ })
+			if err != nil {
+				t.Fatalf("WaitReady: %v", err)
+			}
+			if reports != len(tt.bodies) {
+				t.Errorf("reported %d states, want %d", reports, len(tt.bodies))
+			}
+		})
+	}
+}
+
+func TestWaitReadyTimeout(t *testing.T) {
+	port := fakeInitState(t, `{"databaseReady": true, "pluginsLoaded": true}`)
+	err := WaitReady(port, 1200*time.Millisecond, nil, nil)
+	if err == nil {
+		t.Fatal("WaitReady succeeded for a server that never started")
+	}
+	if !strings.Contains(err.Error(), "HTTP-Server") {
+		t.Errorf("error %q does not name the pending stage", err)
+	}
+}
+
+func TestWaitReadyExited(t *testing.T) {
+	port := fakeInitState(t, `{}`)
+	exited := make(chan struct{})
+	close(exited)
+	if err := WaitReady(port, 5*time.Second, exited, nil); err == nil {
+		t.Fatal("WaitReady succeeded after the server exited")
+	}
+}
+
+func TestServerStarted(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"listening", `{"serverStarted": true}`, true},
+		{"still loading", `{"databaseReady": true}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ServerStarted(fakeInitState(t, tt.body)); got != tt.want {
+				t.Errorf("ServerStarted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	old := httptest.NewServer(http.NotFoundHandler())
+	defer old.Close()
+	if !ServerStarted(old.Listener.Addr().(*net.TCPAddr).Port) {
+		t.Error("server without init-state does not count as started")
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+
+package launcher
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in its own process group so that signals can
+// reach launch.js and the server.js it spawns together.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interruptTree sends SIGINT to the process group led by pid. server.js
+// shuts down cleanly on SIGINT.
+func interruptTree(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGINT)
+}
+
+// killTree kills every process left in the group led by pid.
+func killTree(pid int) error {
+	err := syscall.Kill(-pid, syscall.SIGKILL)
+	if err == syscall.ESRCH {
+		return nil
+	}
+	return err
+}
+
+// treeAlive reports whether any process of the group led by pid is running.
+func treeAlive(pid int) bool {
+	return syscall.Kill(-pid, 0) == nil
+}
+
+// processAlive reports whether a process with pid exists.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// processCommand returns the command line of pid, or "" if it is unknown.
+func processCommand(pid int) string {
+	out, err := exec.Command("ps", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
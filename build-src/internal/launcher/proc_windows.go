@@ -0,0 +1,77 @@
+package launcher
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	createNewProcessGroup = 0x00000200
+	ctrlBreakEvent        = 1
+	stillActive           = 259
+	processQueryLimited   = 0x1000
+)
+
+var procGenerateConsoleCtrlEvent = syscall.NewLazyDLL("kernel32.dll").NewProc("GenerateConsoleCtrlEvent")
+
+// setProcessGroup starts cmd in its own process group so that a console
+// control event reaches launch.js and server.js but not the launcher.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: createNewProcessGroup}
+}
+
+// interruptTree sends CTRL_BREAK to the process group led by pid. Windows
+// has no SIGINT for other processes; Node treats CTRL_BREAK as SIGBREAK.
+// It fails in a launcher without a console, such as the GUI build; stopTree
+// then falls back to requestShutdown.
+func interruptTree(pid int) error {
+	r, _, err := procGenerateConsoleCtrlEvent.Call(ctrlBreakEvent, uintptr(pid))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// killTree kills pid and all of its child processes.
+func killTree(pid int) error {
+	if !processAlive(pid) {
+		return nil
+	}
+	return exec.Command("taskkill", "/PID", strconv.Itoa(pid), "/T", "/F").Run()
+}
+
+// treeAlive reports whether pid is still running. Windows has no cheap way
+// to query a whole tree, so killTree takes care of the children.
+func treeAlive(pid int) bool {
+	return processAlive(pid)
+}
+
+// processAlive reports whether a process with pid is running.
+func processAlive(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimited, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// processCommand returns the image name of pid, or "" if it is unknown.
+func processCommand(pid int) string {
+	out, err := exec.Command("tasklist", "/FI", "PID eq "+strconv.Itoa(pid), "/FO", "CSV", "/NH").Output()
+	if err != nil {
+		return ""
+	}
+	fields := strings.Split(strings.TrimSpace(string(out)), ",")
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.Trim(fields[0], `"`)
+}
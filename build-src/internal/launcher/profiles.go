@@ -0,0 +1,171 @@
+package launcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+)
+
+// InstallProfile selects which of the app's optional dependencies are
+// installed.
+type InstallProfile string
+
+const (
+	// ProfileMinimal installs no optional dependencies (npm --omit=optional).
+	ProfileMinimal InstallProfile = "minimal"
+	// ProfileStandard installs the optional packages but skips the large
+	// downloads of features that need the full profile, such as browsers.
+	ProfileStandard InstallProfile = "standard"
+	// ProfileFull installs everything.
+	ProfileFull InstallProfile = "full"
+)
+
+func (p InstallProfile) validate() error {
+	switch p {
+	case ProfileMinimal, ProfileStandard, ProfileFull:
+		return nil
+	}
+	return fmt.Errorf("unbekanntes Installationsprofil %q (erlaubt: minimal, standard, full)", p)
+}
+
+func (p InstallProfile) rank() int {
+	switch p {
+	case ProfileMinimal:
+		return 0
+	case ProfileStandard:
+		return 1
+	}
+	return 2
+}
+
+// includes reports whether features that need profile need are installed
+// with p.
+func (p InstallProfile) includes(need InstallProfile) bool {
+	return p.rank() >= need.rank()
+}
+
+// installFeature is an entry of "installFeatures" in the app's package.json:
+// a plugin or module that depends on optional packages.
+type installFeature struct {
+	Title string `json:"title"`
+	// Profile is the smallest profile the feature works with.
+	Profile  InstallProfile `json:"profile"`
+	Packages []string       `json:"packages"`
+	// SkipEnv is set for npm while the feature is not installed, e.g.
+	// PUPPETEER_SKIP_DOWNLOAD=1; it lets the packages install without
+	// their downloads.
+	SkipEnv []string `json:"skipEnv"`
+}
+
+// profile returns the install profile chosen in launcher.json.
+func (l *Launcher) profile() InstallProfile {
+	if p := l.cfg.Settings.Install.Profile; p != "" {
+		return p
+	}
+	return ProfileStandard
+}
+
+// installFeatures returns the features declared in the app's package.json.
+func (l *Launcher) installFeatures() map[string]installFeature {
+	m, err := readPackageManifest(l.appDir)
+	if err != nil {
+		return nil
+	}
+	return m.InstallFeatures
+}
+
+// profileArgs are added to npm ci and npm install for the chosen profile.
+func (l *Launcher) profileArgs() []string {
+	if l.profile() == ProfileMinimal {
+		return []string{"--omit=optional"}
+	}
+	return nil
+}
+
+// profileEnv is the environment npm runs with for the chosen profile.
+func (l *Launcher) profileEnv() []string {
+	var env []string
+	for _, f := range l.installFeatures() {
+		if !l.profile().includes(f.Profile) {
+			env = append(env, f.SkipEnv...)
+		}
+	}
+	sort.Strings(env)
+	return env
+}
+
+// profileSwitch returns the npm commands that turn an installation for
+// profile from into one for the chosen profile without reinstalling.
+func (l *Launcher) profileSwitch(from InstallProfile) [][]string {
+	to := l.profile()
+	switch {
+	case to == ProfileMinimal:
+		return [][]string{{"prune", "--omit=optional"}}
+	case from == ProfileMinimal:
+		// Adds the missing optional packages; package-lock.json stays as is
+		return [][]string{append([]string{"install", "--no-save", "--cache", "false"}, l.npmExtraArgs...)}
+	case to == ProfileFull:
+		// The packages are there; their install scripts skipped the
+		// downloads
+		var pkgs []string
+		for _, f := range l.installFeatures() {
+			if !from.includes(f.Profile) && len(f.SkipEnv) > 0 {
+				pkgs = append(pkgs, f.Packages...)
+			}
+		}
+		if len(pkgs) == 0 {
+			return nil
+		}
+		sort.Strings(pkgs)
+		return [][]string{append([]string{"rebuild"}, pkgs...)}
+	}
+	// Downloads made for the full profile are left in place
+	return nil
+}
+
+// switchProfile is phaseDeps for a changed install profile.
+func (l *Launcher) switchProfile(p *PhaseProgress, from InstallProfile) error {
+	l.logAndSync("[INFO] Switching install profile %s -> %s", from, l.profile())
+	for _, args := range l.profileSwitch(from) {
+		if args[0] != "prune" {
+			l.preflightNativeBuild(p, nil)
+		}
+		if err := l.installDependencies(p, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportInstallProfile shows which features the chosen profile installs
+// and which profile the others need.
+func (l *Launcher) reportInstallProfile() {
+	features := l.installFeatures()
+	if len(features) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(features))
+	for id := range features {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	profile := events.InstallProfile{Profile: string(l.profile())}
+	var missing []string
+	for _, id := range ids {
+		f := features[id]
+		installed := l.profile().includes(f.Profile)
+		profile.Features = append(profile.Features, events.Feature{ID: id, Title: f.Title, Profile: string(f.Profile), Installed: installed})
+		if !installed {
+			missing = append(missing, fmt.Sprintf("%s (%s)", id, f.Profile))
+		}
+	}
+	if len(missing) > 0 {
+		l.logger.Printf("[INFO] Install profile %s; not installed: %s\n", l.profile(), strings.Join(missing, ", "))
+	} else {
+		l.logger.Printf("[INFO] Install profile %s; all features installed\n", l.profile())
+	}
+	emit(l.sink, events.TypeInstallProfile, profile)
+}
@@ -0,0 +1,55 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testFeaturesJSON = `{
+  "name": "app",
+  "installFeatures": {
+    "tts": {"title": "TTS", "profile": "standard", "packages": ["say"]},
+    "recorder": {"title": "Recorder", "profile": "full", "packages": ["puppeteer", "playwright"], "skipEnv": ["PUPPETEER_SKIP_DOWNLOAD=1"]},
+    "overlay": {"title": "Overlay", "profile": "full", "packages": ["sharp"]}
+  }
+}`
+
+func TestProfileSwitch(t *testing.T) {
+	appDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(appDir, "package.json"), []byte(testFeaturesJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to InstallProfile
+		want     [][]string
+	}{
+		{ProfileMinimal, ProfileFull, [][]string{{"install", "--no-save", "--cache", "false"}}},
+		{ProfileMinimal, ProfileStandard, [][]string{{"install", "--no-save", "--cache", "false"}}},
+		{ProfileFull, ProfileMinimal, [][]string{{"prune", "--omit=optional"}}},
+		{ProfileStandard, ProfileMinimal, [][]string{{"prune", "--omit=optional"}}},
+		// Only features whose downloads were skipped need their scripts run
+		{ProfileStandard, ProfileFull, [][]string{{"rebuild", "playwright", "puppeteer"}}},
+		{ProfileFull, ProfileStandard, nil},
+	}
+	for _, tt := range tests {
+		settings := DefaultSettings()
+		settings.Install.Profile = tt.to
+		l := New(Config{AppDir: appDir, Settings: settings}, discardSink{})
+		if got := l.profileSwitch(tt.from); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s -> %s: profileSwitch = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	// Remedies the user picked apply to the install as well
+	settings := DefaultSettings()
+	settings.Install.Profile = ProfileFull
+	l := New(Config{AppDir: appDir, Settings: settings}, discardSink{})
+	l.npmExtraArgs = []string{"--legacy-peer-deps"}
+	want := [][]string{{"install", "--no-save", "--cache", "false", "--legacy-peer-deps"}}
+	if got := l.profileSwitch(ProfileMinimal); !reflect.DeepEqual(got, want) {
+		t.Errorf("with a remedy: profileSwitch = %q, want %q", got, want)
+	}
+}
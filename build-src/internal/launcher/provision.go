@@ -0,0 +1,383 @@
+package launcher
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// runtimeArchive returns the file name of the Node.js release archive for
+// this platform, as published on nodejs.org/dist.
+func runtimeArchive(version string) (string, error) {
+	osName := map[string]string{"windows": "win", "linux": "linux", "darwin": "darwin"}[runtime.GOOS]
+	arch := map[string]string{"amd64": "x64", "arm64": "arm64", "386": "x86"}[runtime.GOARCH]
+	if osName == "" || arch == "" {
+		return "", fmt.Errorf("keine Node.js Version für %s/%s verfügbar", runtime.GOOS, runtime.GOARCH)
+	}
+	ext := "tar.gz"
+	if runtime.GOOS == "windows" {
+		ext = "zip"
+	}
+	return fmt.Sprintf("node-v%s-%s-%s.%s", version, osName, arch, ext), nil
+}
+
+// runtimeSource resolves name below the configured base URL. Besides
+// http(s) the base may be a file:// URL or a plain path, relative paths
+// being relative to the executable, so that a local mirror or an offline
+// bundle can be used.
+func runtimeSource(base, version, name string) (string, error) {
+	if u, err := url.Parse(base); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		u.Path = path.Join(u.Path, "v"+version, name)
+		return u.String(), nil
+	}
+
+	dir := base
+	if strings.HasPrefix(base, "file://") {
+		// Taken literally, so that file://./mirror stays relative
+		dir = filepath.FromSlash(strings.TrimPrefix(base, "file://"))
+		// file:///C:/mirror
+		if runtime.GOOS == "windows" && len(dir) > 2 && dir[0] == '\\' && dir[2] == ':' {
+			dir = dir[1:]
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		exeDir, _, err := Dirs()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(exeDir, dir)
+	}
+	return filepath.Join(dir, "v"+version, name), nil
+}
+
+var downloadClient = &http.Client{Timeout: 10 * time.Minute}
+
+// openRuntimeSource opens src for reading; size is -1 if unknown.
+func openRuntimeSource(src string) (rc io.ReadCloser, size int64, err error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, 0, err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, 0, err
+		}
+		return f, info.Size(), nil
+	}
+
+	resp, err := downloadClient.Get(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("%s: HTTP %d", src, resp.StatusCode)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// expectedChecksum looks name up in a SHASUMS256.txt.
+func expectedChecksum(shasums io.Reader, name string) (string, error) {
+	scanner := bufio.NewScanner(shasums)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s fehlt in SHASUMS256.txt", name)
+}
+
+// progressReader reports how much of a download has been read.
+type progressReader struct {
+	r      io.Reader
+	read   int64
+	size   int64
+	report func(read, size int64)
+	last   time.Time
+}
+
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	pr.read += int64(n)
+	if time.Since(pr.last) >= 250*time.Millisecond || err == io.EOF {
+		pr.last = time.Now()
+		pr.report(pr.read, pr.size)
+	}
+	return n, err
+}
+
+// provisionNode downloads the pinned Node.js release into the runtime
+// directory next to the executable, verifies it against SHASUMS256.txt and
+// makes it the Node.js (and npm) the launcher uses. If the download fails
+// while another Node.js is installed, the launcher goes on with that one.
+func (l *Launcher) provisionNode(p *PhaseProgress) error {
+	rs := l.cfg.Settings.Runtime
+	if err := l.downloadNodeRuntime(p, rs); err != nil {
+		if len(l.discoverNode()) > 0 {
+			// An installed Node.js outside the engines range may still do;
+			// the node phase checks it and asks whether to continue
+			l.logAndSync("[WARNING] Node.js provisioning failed, checking the installed Node.js: %v", err)
+			msg := fmt.Sprintf("Node.js v%s konnte nicht heruntergeladen werden - prüfe die installierte Version", rs.Version)
+			p.Status("⚠️ " + msg)
+			l.showWarning(msg)
+			return nil
+		}
+		l.logAndSync("[ERROR] Node.js provisioning failed: %v", err)
+		status := "FEHLER: Node.js konnte nicht heruntergeladen werden"
+		p.Status(status)
+		return &Error{
+			Status: status,
+			Hints: []string{
+				"Prüfe deine Internetverbindung",
+				fmt.Sprintf("Quelle: %s (runtime.baseUrl in %s)", rs.BaseURL, SettingsFile),
+				"Oder installiere Node.js von https://nodejs.org",
+			},
+			Err: err,
+		}
+	}
+
+	// Discover again; the new runtime is the first candidate
+	l.nodeCandidates = nil
+	p.Update(1, fmt.Sprintf("Node.js v%s installiert", rs.Version))
+	return nil
+}
+
+func (l *Launcher) downloadNodeRuntime(p *PhaseProgress, rs RuntimeSettings) error {
+	name, err := runtimeArchive(rs.Version)
+	if err != nil {
+		return err
+	}
+	exeDir, _, err := Dirs()
+	if err != nil {
+		return err
+	}
+	runtimeDir := filepath.Join(exeDir, RuntimeDir)
+	if err := os.MkdirAll(runtimeDir, 0755); err != nil {
+		return err
+	}
+
+	sumsSrc, err := runtimeSource(rs.BaseURL, rs.Version, "SHASUMS256.txt")
+	if err != nil {
+		return err
+	}
+	archiveSrc, err := runtimeSource(rs.BaseURL, rs.Version, name)
+	if err != nil {
+		return err
+	}
+	l.logAndSync("[INFO] Provisioning Node.js v%s from %s", rs.Version, archiveSrc)
+	p.Update(0, fmt.Sprintf("Lade Node.js v%s herunter...", rs.Version))
+
+	sums, _, err := openRuntimeSource(sumsSrc)
+	if err != nil {
+		return fmt.Errorf("SHASUMS256.txt: %v", err)
+	}
+	want, err := expectedChecksum(sums, name)
+	sums.Close()
+	if err != nil {
+		return err
+	}
+
+	// Download next to the target so that nothing half-written is ever used
+	archivePath := filepath.Join(runtimeDir, name+".partial")
+	defer os.Remove(archivePath)
+	if err := l.fetchVerified(p, archiveSrc, archivePath, want); err != nil {
+		return err
+	}
+	l.logger.Printf("[SUCCESS] %s matches SHASUMS256.txt (%s)\n", name, want)
+
+	p.Update(0.85, "Entpacke Node.js...")
+	staging, err := os.MkdirTemp(runtimeDir, ".node-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	if strings.HasSuffix(name, ".zip") {
+		err = extractZip(archivePath, staging)
+	} else {
+		err = extractTarGz(archivePath, staging)
+	}
+	if err != nil {
+		return fmt.Errorf("Entpacken fehlgeschlagen: %v", err)
+	}
+
+	// The archives contain a single node-v<version>-<os>-<arch> folder
+	root := filepath.Join(staging, strings.TrimSuffix(strings.TrimSuffix(name, ".zip"), ".tar.gz"))
+	if _, err := os.Stat(root); err != nil {
+		return fmt.Errorf("unerwarteter Aufbau von %s", name)
+	}
+
+	target := filepath.Join(runtimeDir, "node")
+	old := target + ".old"
+	os.RemoveAll(old)
+	if _, err := os.Stat(target); err == nil {
+		if err := os.Rename(target, old); err != nil {
+			return fmt.Errorf("alte Node.js Version kann nicht ersetzt werden: %v", err)
+		}
+	}
+	if err := os.Rename(root, target); err != nil {
+		os.Rename(old, target)
+		return err
+	}
+	os.RemoveAll(old)
+
+	l.logAndSync("[SUCCESS] Node.js v%s installed to %s", rs.Version, target)
+	return nil
+}
+
+// fetchVerified copies src to dst and checks its SHA-256.
+func (l *Launcher) fetchVerified(p *PhaseProgress, src, dst, want string) error {
+	rc, size, err := openRuntimeSource(src)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	hash := sha256.New()
+	pr := &progressReader{r: rc, size: size, report: func(read, size int64) {
+		if size > 0 {
+			p.Update(0.8*float64(read)/float64(size), fmt.Sprintf("Lade Node.js herunter... %d / %d MB", read>>20, size>>20))
+		} else {
+			p.Status(fmt.Sprintf("Lade Node.js herunter... %d MB", read>>20))
+		}
+	}}
+	_, err = io.Copy(io.MultiWriter(out, hash), pr)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("Download fehlgeschlagen: %v", err)
+	}
+
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		return fmt.Errorf("Prüfsumme von %s stimmt nicht (erwartet %s, erhalten %s)", filepath.Base(src), want, got)
+	}
+	return nil
+}
+
+// safeJoin joins an archive entry name to dir and rejects names that would
+// end up outside of it.
+func safeJoin(dir, name string) (string, error) {
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if target != dir && !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+		return "", fmt.Errorf("ungültiger Pfad im Archiv: %s", name)
+	}
+	return target, nil
+}
+
+func extractZip(archive, dir string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		target, err := safeJoin(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFile(target, rc, f.Mode().Perm()|0600)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractTarGz(archive, dir string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := safeJoin(dir, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+				err = writeFile(target, tr, hdr.FileInfo().Mode().Perm()|0600)
+			}
+		case tar.TypeSymlink:
+			// bin/npm and friends link into lib/; links must stay inside
+			if _, err = safeJoin(dir, path.Join(path.Dir(hdr.Name), hdr.Linkname)); err == nil && !path.IsAbs(hdr.Linkname) {
+				if err = os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+					err = os.Symlink(hdr.Linkname, target)
+				}
+			} else if err == nil {
+				err = fmt.Errorf("ungültiger Link im Archiv: %s", hdr.Name)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,310 @@
+package launcher
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const testNodeVersion = "22.12.0"
+
+// discardSink ignores all progress.
+type discardSink struct{}
+
+func (discardSink) Progress(int, string) {}
+func (discardSink) Redirect(string)      {}
+
+// tarEntry is a file, directory (name ending in /) or symlink (link set) of
+// a generated archive.
+type tarEntry struct {
+	name, body, link string
+}
+
+func makeTarGz(t *testing.T, entries ...tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0755}
+		switch {
+		case e.link != "":
+			hdr.Typeflag, hdr.Linkname = tar.TypeSymlink, e.link
+		case strings.HasSuffix(e.name, "/"):
+			hdr.Typeflag = tar.TypeDir
+		default:
+			hdr.Typeflag, hdr.Size = tar.TypeReg, int64(len(e.body))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// runtimeMirror lays out a nodejs.org/dist style mirror with archive under
+// name and the given SHASUMS256.txt, and returns its file:// URL.
+func runtimeMirror(t *testing.T, name string, archive []byte, shasums string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "v"+testNodeVersion)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "SHASUMS256.txt"), []byte(shasums), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return "file://" + filepath.ToSlash(filepath.Dir(dir))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fetchFromMirror looks name up in the mirror's SHASUMS256.txt and fetches
+// it into dir the way downloadNodeRuntime does.
+func fetchFromMirror(t *testing.T, base, name, dir string) (string, error) {
+	t.Helper()
+	sumsSrc, err := runtimeSource(base, testNodeVersion, "SHASUMS256.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := runtimeSource(base, testNodeVersion, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sums, _, err := openRuntimeSource(sumsSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := expectedChecksum(sums, name)
+	sums.Close()
+	if err != nil {
+		return "", err
+	}
+
+	l := New(Config{}, discardSink{})
+	p := &PhaseProgress{pl: NewPipeline(discardSink{}, t.Logf, Phase{Name: "node", Weight: 1})}
+	dst := filepath.Join(dir, name+".partial")
+	return dst, l.fetchVerified(p, src, dst, want)
+}
+
+func TestRuntimeSource(t *testing.T) {
+	abs := filepath.Join(t.TempDir(), "mirror")
+
+	tests := []struct {
+		base string
+		want string
+	}{
+		{"https://nodejs.org/dist", "https://nodejs.org/dist/v22.12.0/node.tar.gz"},
+		{"http://mirror.local/node/", "http://mirror.local/node/v22.12.0/node.tar.gz"},
+		{abs, filepath.Join(abs, "v22.12.0", "node.tar.gz")},
+		{"file://" + filepath.ToSlash(abs), filepath.Join(abs, "v22.12.0", "node.tar.gz")},
+	}
+	for _, tt := range tests {
+		got, err := runtimeSource(tt.base, testNodeVersion, "node.tar.gz")
+		if err != nil {
+			t.Errorf("runtimeSource(%q): %v", tt.base, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("runtimeSource(%q) = %q, want %q", tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestProvisionFromFileURL(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks in the archive need privileges on Windows")
+	}
+	root := "node-v" + testNodeVersion + "-linux-x64"
+	name := root + ".tar.gz"
+	archive := makeTarGz(t,
+		tarEntry{name: root + "/"},
+		tarEntry{name: root + "/bin/node", body: "#!/bin/sh\n"},
+		tarEntry{name: root + "/lib/node_modules/npm/bin/npm-cli.js", body: "// npm\n"},
+		tarEntry{name: root + "/bin/npm", link: "../lib/node_modules/npm/bin/npm-cli.js"},
+	)
+	shasums := fmt.Sprintf("%s  node-v%s-win-x64.zip\n%s  %s\n", strings.Repeat("0", 64), testNodeVersion, sha256Hex(archive), name)
+	base := runtimeMirror(t, name, archive, shasums)
+
+	dir := t.TempDir()
+	path, err := fetchFromMirror(t, base, name, dir)
+	if err != nil {
+		t.Fatalf("fetchVerified: %v", err)
+	}
+	staging := filepath.Join(dir, "staging")
+	if err := extractTarGz(path, staging); err != nil {
+		t.Fatalf("extractTarGz: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(staging, root, "bin", "npm"))
+	if err != nil || string(data) != "// npm\n" {
+		t.Errorf("bin/npm = %q, %v; want the linked npm-cli.js", data, err)
+	}
+}
+
+func TestProvisionChecksumMismatch(t *testing.T) {
+	name := "node-v" + testNodeVersion + "-linux-x64.tar.gz"
+	archive := makeTarGz(t, tarEntry{name: "node/bin/node", body: "tampered"})
+	base := runtimeMirror(t, name, archive, fmt.Sprintf("%s  %s\n", sha256Hex([]byte("original")), name))
+
+	_, err := fetchFromMirror(t, base, name, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "Prüfsumme") {
+		t.Fatalf("fetchVerified error = %v, want a checksum mismatch", err)
+	}
+}
+
+func TestProvisionMissingChecksum(t *testing.T) {
+	name := "node-v" + testNodeVersion + "-linux-x64.tar.gz"
+	archive := makeTarGz(t, tarEntry{name: "node/bin/node", body: "node"})
+	// Only a similarly named archive is listed
+	base := runtimeMirror(t, name, archive, fmt.Sprintf("%s  %s.sig\n", sha256Hex(archive), name))
+
+	_, err := fetchFromMirror(t, base, name, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "fehlt in SHASUMS256.txt") {
+		t.Fatalf("error = %v, want the missing SHASUMS entry", err)
+	}
+}
+
+func TestProvisionNode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake node is a shell script")
+	}
+	appDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(appDir, "package.json"), []byte(`{"engines": {"node": ">=20"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		provision bool
+		installed string
+		wantLast  string
+		wantErr   bool
+	}{
+		{"disabled", false, "", "phase-end runtime:skipped", false},
+		{"installed in range", true, "v22.3.0", "phase-end runtime:skipped", false},
+		{"download fails, older installed", true, "v18.19.0", "phase-end runtime", false},
+		{"download fails, none installed", true, "", "phase-end runtime:error", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dirs []string
+			if tt.installed != "" {
+				dirs = append(dirs, fakeNode(t, tt.installed))
+			}
+			isolateNode(t, dirs...)
+
+			settings := DefaultSettings()
+			settings.Runtime.Provision = tt.provision
+			// An empty mirror: every download fails
+			settings.Runtime.BaseURL = "file://" + filepath.ToSlash(t.TempDir())
+			sink := &recordingSink{}
+			err := New(Config{AppDir: appDir, Settings: settings}, sink).ProvisionNode()
+
+			var lerr *Error
+			if tt.wantErr != errors.As(err, &lerr) {
+				t.Fatalf("ProvisionNode() = %v, want an *Error: %v", err, tt.wantErr)
+			}
+			if got := sink.events[len(sink.events)-1]; got != tt.wantLast {
+				t.Errorf("events = %q, want them to end with %q", sink.events, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "staging")
+
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"node/bin/node", true},
+		{"node/../node/bin/node", true},
+		{".", true},
+		{"../evil", false},
+		{"node/../../evil", false},
+		{"../staging-evil/x", false},
+	}
+	for _, tt := range tests {
+		_, err := safeJoin(dir, tt.name)
+		if (err == nil) != tt.ok {
+			t.Errorf("safeJoin(%q) error = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestExtractRejectsTraversal(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry tarEntry
+	}{
+		{"parent entry", tarEntry{name: "../evil", body: "x"}},
+		{"nested parent entry", tarEntry{name: "node/../../evil", body: "x"}},
+		{"link out of the archive", tarEntry{name: "node/evil", link: "../../evil"}},
+		{"absolute link", tarEntry{name: "node/evil", link: "/etc/passwd"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			archive := filepath.Join(tmp, "node.tar.gz")
+			if err := os.WriteFile(archive, makeTarGz(t, tt.entry), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := extractTarGz(archive, filepath.Join(tmp, "staging")); err == nil {
+				t.Fatal("extractTarGz accepted the entry")
+			}
+			if _, err := os.Lstat(filepath.Join(tmp, "evil")); err == nil {
+				t.Error("entry was written outside the staging directory")
+			}
+		})
+	}
+
+	t.Run("zip", func(t *testing.T) {
+		tmp := t.TempDir()
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create("../evil")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("x"))
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		archive := filepath.Join(tmp, "node.zip")
+		if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := extractZip(archive, filepath.Join(tmp, "staging")); err == nil {
+			t.Fatal("extractZip accepted ../evil")
+		}
+		if _, err := os.Stat(filepath.Join(tmp, "evil")); err == nil {
+			t.Error("entry was written outside the staging directory")
+		}
+	})
+}
@@ -0,0 +1,311 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+const healthTimeout = 60 * time.Second
+
+func (l *Launcher) startTool() (*exec.Cmd, error) {
+	launchJS := filepath.Join(l.appDir, "launch.js")
+	cmd := exec.Command(l.nodePath, launchJS)
+	cmd.Dir = l.appDir
+	setProcessGroup(cmd)
+
+	// Set environment variable to disable automatic browser opening
+	// The launcher handles the redirect to dashboard after server is ready
+	// PORT overrides the value from .env (dotenv keeps existing variables),
+	// so the server listens where the launcher looks for it
+	port := l.serverPort()
+	env := append(l.nodeEnv(), "OPEN_BROWSER=false", fmt.Sprintf("PORT=%d", port), shutdownTokenEnv+"="+l.shutdownToken)
+	cmd.Env = append(env, l.cfg.Env...)
+
+	// Server output always goes to the log file; front-ends with a console
+	// see it there as well. The watcher picks up the port the server
+	// announces in case it listens somewhere else after all, and native
+	// modules that fail to load.
+	l.output = &outputWatcher{}
+	out := []io.Writer{l.output}
+	if l.logFile != nil {
+		out = append(out, l.logFile)
+	}
+	if l.cfg.Console != nil {
+		out = append(out, l.cfg.Console)
+	}
+	cmd.Stdout = io.MultiWriter(out...)
+	cmd.Stderr = cmd.Stdout
+	cmd.Stdin = l.cfg.Stdin
+
+	l.logAndSync("Starting Node.js server...")
+	l.logAndSync("Command: %s %s", l.nodePath, launchJS)
+	l.logAndSync("Working directory: %s", l.appDir)
+	l.logAndSync("OPEN_BROWSER environment variable set to: false")
+	l.logAndSync("PORT environment variable set to: %d", port)
+	l.logAndSync("--- Node.js Server Output Start ---")
+
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+// checkServerHealthOnPort checks if the server is responding on a specific port
+func (l *Launcher) checkServerHealthOnPort(port int) bool {
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+	}
+
+	url := fmt.Sprintf("http://localhost:%d/dashboard.html", port)
+	resp, err := client.Get(url)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == 200
+}
+
+// startServer starts the Node.js process and begins watching for its exit.
+func (l *Launcher) startServer(p *PhaseProgress) error {
+	if err := l.spawnServer(); err != nil {
+		l.logger.Printf("[ERROR] Failed to start server: %v\n", err)
+		status := fmt.Sprintf("FEHLER beim Starten: %v", err)
+		p.Status(status)
+		return &Error{
+			Status: status,
+			Hints:  []string{"Prüfe bitte die Log-Datei in app/logs/ für Details."},
+			Err:    err,
+		}
+	}
+	return nil
+}
+
+// StartServer starts launch.js with Config.NodePath on port, with the
+// environment Run gives it, including the shutdown token. It is meant for
+// front-ends that prepare the app and wait for the server themselves; like
+// the server started by Run it is stopped with Shutdown. Server.Done
+// receives the exit of the process.
+func (l *Launcher) StartServer(port int) (*Server, error) {
+	if l.nodePath == "" {
+		return nil, fmt.Errorf("Kein Node.js angegeben (Config.NodePath)")
+	}
+	l.port = port
+	if err := l.spawnServer(); err != nil {
+		l.logger.Printf("[ERROR] Failed to start server: %v\n", err)
+		return nil, err
+	}
+	l.procMu.Lock()
+	l.server = &Server{Cmd: l.cmd, URL: dashboardURL(port), Port: port, Done: l.processDied}
+	l.procMu.Unlock()
+	return l.server, nil
+}
+
+// spawnServer runs startTool, records the process in the PID file and
+// watches for its exit.
+func (l *Launcher) spawnServer() error {
+	cmd, err := l.startTool()
+	if err != nil {
+		return err
+	}
+
+	pid := cmd.Process.Pid
+	l.addPID(pid)
+
+	// Monitor if the process exits prematurely
+	processDied := make(chan error, 1)
+	exited := make(chan struct{})
+	go func() {
+		err := cmd.Wait()
+		l.removePID(pid)
+		close(exited)
+		processDied <- err
+	}()
+
+	l.procMu.Lock()
+	l.cmd = cmd
+	l.processDied = processDied
+	l.exited = exited
+	l.procMu.Unlock()
+	return nil
+}
+
+// restartAfterEnvFix is the health phase's retry hook: if the process died
+// right after autoFixEnvFile created .env the server is started once more.
+func (l *Launcher) restartAfterEnvFix(p *PhaseProgress, err error) bool {
+	if !errors.Is(err, errServerCrashed) || !l.envFileFixed {
+		return false
+	}
+
+	l.logAndSync("[AUTO-FIX] .env file was just created - attempting restart...")
+	p.Status("🔄 .env erstellt - starte Server neu...")
+	time.Sleep(3 * time.Second)
+
+	// Mark that we already tried the fix
+	l.envFileFixed = false
+
+	if err := l.startServer(p); err != nil {
+		l.logAndSync("[ERROR] Retry failed to start server: %v", err)
+		return false
+	}
+
+	p.Status("🔄 Server neugestartet - warte auf Antwort...")
+	l.logAndSync("[INFO] Server restarted after .env fix - waiting for health check...")
+	return true
+}
+
+// errServerCrashed is wrapped by waitForHealth when the process exited
+// before the server responded.
+var errServerCrashed = errors.New("Server crashed during startup")
+
+// waitForHealth waits up to healthTimeout for the started server to respond.
+func (l *Launcher) waitForHealth(p *PhaseProgress) error {
+	l.logger.Printf("[INFO] Waiting for server health check (%v timeout)...\n", healthTimeout)
+	l.logger.Printf("[INFO] Checking if server responds on http://localhost:%d...\n", l.serverPort())
+
+	// Check server health with process monitoring
+	started := time.Now()
+	healthCheckTimeout := time.After(healthTimeout)
+	healthCheckTicker := time.NewTicker(500 * time.Millisecond)
+	defer healthCheckTicker.Stop()
+
+	attemptCount := 0
+	lastLogTime := time.Now()
+	respondedPort := 0
+	errorsSeen := 0
+	var lastState InitState
+
+	for {
+		select {
+		case err := <-l.processDied:
+			// Process exited before server was ready
+			// Ensure log file is flushed to capture all server output
+			if l.logFile != nil {
+				l.logFile.Sync()
+				time.Sleep(100 * time.Millisecond) // Give a moment for any buffered writes
+			}
+
+			l.logAndSync("--- Node.js Server Output End ---")
+			l.logAndSync("[ERROR] ===========================================")
+			l.logAndSync("[ERROR] Node.js process exited prematurely: %v", err)
+			l.logAndSync("[ERROR] Server crashed during startup!")
+			l.logAndSync("[ERROR] Check the server output above for the actual error")
+			l.logAndSync("[ERROR] ===========================================")
+			l.logAndSync("[ERROR] Häufige Ursachen:")
+			l.logAndSync("[ERROR]  - Fehlende .env Datei (kopiere .env.example zu .env)")
+			l.logAndSync("[ERROR]  - Port %d bereits belegt", l.serverPort())
+			l.logAndSync("[ERROR]  - Fehlende Dependencies (führe 'npm install' aus)")
+			l.logAndSync("[ERROR]  - Syntax-Fehler im Code")
+			l.logAndSync("[ERROR] ===========================================")
+
+			status := "⚠️ Server konnte nicht starten!"
+			hints := []string{
+				"📋 Alle Auto-Fixes wurden versucht",
+				"💡 Prüfe app/logs/launcher_*.log für Details",
+				"💡 Oder führe manuell: cd app && npm install",
+				fmt.Sprintf("💡 Oder prüfe ob Port %d frei ist", l.serverPort()),
+			}
+			if abi, _ := l.output.ABIError(); abi {
+				status = "⚠️ Native Module passen nicht zu Node.js!"
+				hints = append([]string{"🔧 Native Module wurden für eine andere Node.js Version gebaut", "💡 Führe manuell aus: cd app && npm rebuild"}, hints[1:]...)
+			}
+			p.Status(status)
+			return &Error{
+				Status: status,
+				Hints:  hints,
+				Err:    fmt.Errorf("%w: %v", errServerCrashed, err),
+			}
+		case <-healthCheckTicker.C:
+			attemptCount++
+
+			port, state, err := probePorts(l.healthPorts())
+			if port == 0 {
+				// Nothing answers yet; the first 20% of the phase is waiting
+				// for the HTTP server to come up
+				if time.Since(lastLogTime) >= 5*time.Second {
+					l.logger.Printf("[INFO] Health check attempt %d (waiting for server to respond)...\n", attemptCount)
+					p.Update(0.2*float64(time.Since(started))/float64(healthTimeout), fmt.Sprintf("Warte auf Server... (Versuch %d)", attemptCount))
+					lastLogTime = time.Now()
+				}
+				continue
+			}
+
+			if port != respondedPort {
+				respondedPort = port
+				l.logger.Printf("[INFO] Server responded on port %d\n", port)
+				if port != l.serverPort() {
+					l.logger.Printf("[INFO] Note: Server is running on port %d instead of %d\n", port, l.serverPort())
+				}
+			}
+
+			if errors.Is(err, errNoInitState) {
+				// Older app versions: fall back to the dashboard page
+				if l.checkServerHealthOnPort(port) {
+					l.logger.Printf("[SUCCESS] Dashboard available on port %d (no %s)\n", port, initStatePath)
+					l.server = &Server{Cmd: l.cmd, URL: dashboardURL(port), Port: port, Done: l.processDied}
+					return nil
+				}
+				continue
+			}
+			if err != nil {
+				l.logger.Printf("[WARNING] Readiness probe on port %d failed: %v\n", port, err)
+				continue
+			}
+
+			for _, e := range state.Errors[errorsSeen:] {
+				l.logAndSync("[WARNING] Server init error in %s: %s", e.Component, e.Message)
+				l.showWarning(fmt.Sprintf("%s: %s", e.Component, e.Message))
+			}
+			errorsSeen = len(state.Errors)
+
+			if state.Usable() {
+				l.logger.Printf("[SUCCESS] Server on port %d reports initialisation complete!\n", port)
+				if pending := state.Background(); len(pending) > 0 {
+					l.logger.Printf("[INFO] Still initialising in the background: %v\n", pending)
+				}
+				l.server = &Server{Cmd: l.cmd, URL: dashboardURL(port), Port: port, Done: l.processDied}
+				return nil
+			}
+
+			if state.Fraction() != lastState.Fraction() || time.Since(lastLogTime) >= 5*time.Second {
+				l.logger.Printf("[INFO] Server initialising, pending: %v\n", state.Pending())
+				p.Update(0.2+0.8*state.Fraction(), readinessStatus(state))
+				lastLogTime = time.Now()
+			}
+			lastState = state
+		case <-healthCheckTimeout:
+			l.logger.Printf("[ERROR] Server health check timed out after %v\n", healthTimeout)
+			if respondedPort != 0 {
+				l.logger.Printf("[ERROR] Server on port %d did not finish initialising, pending: %v\n", respondedPort, lastState.Pending())
+			} else {
+				l.logger.Println("[ERROR] Server did not respond. Check the log above for error messages.")
+			}
+			l.logger.Println("[ERROR] ===========================================")
+			l.logger.Println("[ERROR] Mögliche Probleme:")
+			l.logger.Println("[ERROR]  - Server startet, aber hängt sich bei Initialisierung auf")
+			l.logger.Println("[ERROR]  - Dependencies werden geladen (kann lange dauern)")
+			l.logger.Println("[ERROR]  - Datenbank-Migration läuft")
+			l.logger.Printf("[ERROR]  - Port %d ist blockiert durch Firewall\n", l.serverPort())
+			l.logger.Println("[ERROR] ===========================================")
+
+			status := "⏱️ Server-Start Timeout (60s)"
+			p.Status(status)
+			return &Error{
+				Status: status,
+				Hints: []string{
+					"📋 Server antwortet nicht - prüfe app/logs/",
+					"💡 Server läuft evtl. noch im Hintergrund",
+					fmt.Sprintf("💡 Warte 2-3 Minuten und öffne localhost:%d", l.serverPort()),
+				},
+				Err: fmt.Errorf("Server did not start within %v", healthTimeout),
+			}
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SettingsFile is the name of the optional launcher configuration next to
+// the executable.
+const SettingsFile = "launcher.json"
+
+// Settings are the user-editable launcher options. Fields missing from
+// launcher.json keep their defaults.
+type Settings struct {
+	Restart   RestartSettings  `json:"restart"`
+	Shutdown  ShutdownSettings `json:"shutdown"`
+	Liveness  LivenessSettings `json:"liveness"`
+	Node      NodeSettings     `json:"node"`
+	Runtime   RuntimeSettings  `json:"runtime"`
+	Install   InstallSettings  `json:"install"`
+	Snapshots SnapshotSettings `json:"snapshots"`
+	Update    UpdateSettings   `json:"update"`
+}
+
+// RestartSettings control how the supervisor treats server exits.
+type RestartSettings struct {
+	// Policy is one of "never", "on-failure" or "always".
+	Policy RestartPolicy `json:"policy"`
+	// BackoffSeconds is the delay before the first restart; it doubles
+	// with every further crash up to MaxBackoffSeconds. 0 restarts
+	// immediately.
+	BackoffSeconds    int `json:"backoffSeconds"`
+	MaxBackoffSeconds int `json:"maxBackoffSeconds"`
+	// MaxRestarts within WindowSeconds; after that the supervisor gives up.
+	MaxRestarts   int `json:"maxRestarts"`
+	WindowSeconds int `json:"windowSeconds"`
+}
+
+// ShutdownSettings control how the Node.js server is stopped.
+type ShutdownSettings struct {
+	// GraceSeconds is how long the server may take to exit after the
+	// interrupt before its process tree is killed.
+	GraceSeconds int `json:"graceSeconds"`
+}
+
+// LivenessSettings control the check the supervisor runs while the server
+// is up. A server that stops answering is killed and handled like a crash.
+type LivenessSettings struct {
+	// IntervalSeconds between checks; 0 disables the check.
+	IntervalSeconds int `json:"intervalSeconds"`
+	// Failures in a row before the server counts as hung.
+	Failures int `json:"failures"`
+}
+
+// NodeSettings choose the Node.js installation.
+type NodeSettings struct {
+	// Path to a node executable. If empty, the newest installation that
+	// satisfies the app's engines range is used.
+	Path string `json:"path"`
+}
+
+// RuntimeSettings control the private Node.js the launcher downloads when
+// no suitable installation is found.
+type RuntimeSettings struct {
+	// Provision enables the download.
+	Provision bool `json:"provision"`
+	// Version is the pinned Node.js release, without the leading "v".
+	Version string `json:"version"`
+	// BaseURL has the layout of nodejs.org/dist: <base>/v<version>/ holds
+	// the archives and SHASUMS256.txt. file:// URLs and plain paths
+	// (relative to the executable) work as well.
+	BaseURL string `json:"baseUrl"`
+}
+
+// InstallSettings control which optional dependencies npm installs.
+type InstallSettings struct {
+	// Profile is "minimal", "standard" or "full". Changing it later adds
+	// or removes the optional packages on the next start.
+	Profile InstallProfile `json:"profile"`
+}
+
+// SnapshotSettings control the cache of node_modules snapshots that
+// replaces npm when the same dependencies are needed again.
+type SnapshotSettings struct {
+	Enabled bool `json:"enabled"`
+	// MaxSizeMB bounds the cache; the least recently used snapshots are
+	// removed first.
+	MaxSizeMB int `json:"maxSizeMB"`
+}
+
+// UpdateChannel selects which releases the cloud launcher (ltthgit)
+// installs.
+type UpdateChannel string
+
+const (
+	// ChannelStable installs the newest release that is not a prerelease.
+	ChannelStable UpdateChannel = "stable"
+	// ChannelBeta includes prereleases.
+	ChannelBeta UpdateChannel = "beta"
+	// ChannelNightly installs the rolling "nightly" release built from the
+	// main branch, checked against its SHA256SUMS.
+	ChannelNightly UpdateChannel = "nightly"
+)
+
+func (c UpdateChannel) validate() error {
+	switch c {
+	case ChannelStable, ChannelBeta, ChannelNightly:
+		return nil
+	}
+	return fmt.Errorf("unbekannter Update-Kanal %q (erlaubt: stable, beta, nightly)", c)
+}
+
+// UpdateSettings control which version of the app the cloud launcher
+// downloads.
+type UpdateSettings struct {
+	Channel UpdateChannel `json:"channel"`
+	// Version pins a release tag, e.g. "v1.2.0"; the channel is ignored.
+	Version string `json:"version"`
+	// Skip lists release tags that are never installed, e.g. a release
+	// known to be broken.
+	Skip []string `json:"skip"`
+	// APIBaseURL is the GitHub REST API, or a server with the same
+	// releases endpoints.
+	APIBaseURL string `json:"apiBaseUrl"`
+	// ReadySeconds is how long a newly installed version may take to
+	// report ready before the launcher switches back to the last version
+	// that worked.
+	ReadySeconds int `json:"readySeconds"`
+}
+
+// Skipped reports whether tag is on the skip list.
+func (u UpdateSettings) Skipped(tag string) bool {
+	for _, s := range u.Skip {
+		if s == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the channel and the timeout; LoadSettings calls it,
+// callers that change the settings afterwards call it again.
+func (u UpdateSettings) Validate() error {
+	if u.ReadySeconds <= 0 {
+		return fmt.Errorf("update.readySeconds muss größer als 0 sein")
+	}
+	return u.Channel.validate()
+}
+
+func (r RestartSettings) validate() error {
+	if err := r.Policy.validate(); err != nil {
+		return err
+	}
+	if r.BackoffSeconds < 0 {
+		return fmt.Errorf("restart.backoffSeconds darf nicht negativ sein")
+	}
+	if r.MaxBackoffSeconds < r.BackoffSeconds {
+		return fmt.Errorf("restart.maxBackoffSeconds muss mindestens restart.backoffSeconds sein")
+	}
+	if r.MaxRestarts <= 0 {
+		return fmt.Errorf("restart.maxRestarts muss größer als 0 sein")
+	}
+	if r.WindowSeconds <= 0 {
+		return fmt.Errorf("restart.windowSeconds muss größer als 0 sein")
+	}
+	return nil
+}
+
+func (s ShutdownSettings) validate() error {
+	if s.GraceSeconds < 0 {
+		return fmt.Errorf("shutdown.graceSeconds darf nicht negativ sein")
+	}
+	return nil
+}
+
+func (ls LivenessSettings) validate() error {
+	if ls.IntervalSeconds < 0 {
+		return fmt.Errorf("liveness.intervalSeconds darf nicht negativ sein (0 schaltet die Prüfung ab)")
+	}
+	if ls.Failures <= 0 {
+		return fmt.Errorf("liveness.failures muss größer als 0 sein")
+	}
+	return nil
+}
+
+func (r RestartSettings) backoff() time.Duration {
+	return time.Duration(r.BackoffSeconds) * time.Second
+}
+
+func (r RestartSettings) maxBackoff() time.Duration {
+	return time.Duration(r.MaxBackoffSeconds) * time.Second
+}
+
+func (r RestartSettings) window() time.Duration {
+	return time.Duration(r.WindowSeconds) * time.Second
+}
+
+// DefaultSettings returns the settings used without a launcher.json.
+func DefaultSettings() Settings {
+	return Settings{
+		Restart: RestartSettings{
+			Policy:            RestartOnFailure,
+			BackoffSeconds:    2,
+			MaxBackoffSeconds: 60,
+			MaxRestarts:       5,
+			WindowSeconds:     600,
+		},
+		Shutdown: ShutdownSettings{
+			GraceSeconds: 10,
+		},
+		Liveness: LivenessSettings{
+			IntervalSeconds: 15,
+			Failures:        4,
+		},
+		Runtime: RuntimeSettings{
+			Provision: true,
+			Version:   "22.12.0",
+			BaseURL:   "https://nodejs.org/dist",
+		},
+		Install: InstallSettings{
+			Profile: ProfileStandard,
+		},
+		Snapshots: SnapshotSettings{
+			Enabled:   true,
+			MaxSizeMB: 2048,
+		},
+		Update: UpdateSettings{
+			Channel:      ChannelStable,
+			APIBaseURL:   "https://api.github.com",
+			ReadySeconds: 120,
+		},
+	}
+}
+
+// LoadSettings reads launcher.json from dir. A missing file is not an error
+// and yields DefaultSettings.
+func LoadSettings(dir string) (Settings, error) {
+	settings := DefaultSettings()
+
+	data, err := os.ReadFile(filepath.Join(dir, SettingsFile))
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return DefaultSettings(), fmt.Errorf("%s ist ungültig: %v", SettingsFile, err)
+	}
+	if err := settings.Restart.validate(); err != nil {
+		return DefaultSettings(), err
+	}
+	if err := settings.Shutdown.validate(); err != nil {
+		return DefaultSettings(), err
+	}
+	if err := settings.Liveness.validate(); err != nil {
+		return DefaultSettings(), err
+	}
+	if err := settings.Install.Profile.validate(); err != nil {
+		return DefaultSettings(), err
+	}
+	if err := settings.Update.Validate(); err != nil {
+		return DefaultSettings(), err
+	}
+	return settings, nil
+}
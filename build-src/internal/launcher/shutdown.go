@@ -0,0 +1,237 @@
+package launcher
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// pidFile records the Node.js processes started by launchers so that a
+// later run can clean them up if their launcher died without stopping them.
+const pidFile = "launcher-node.pid"
+
+// exitInterrupted is the exit code after SIGINT/SIGTERM, as in shells.
+const exitInterrupted = 130
+
+// shutdownPath is the server.js endpoint that shuts the server down like
+// SIGINT. It only accepts requests from this machine that carry the token
+// the launcher passed in shutdownTokenEnv.
+const (
+	shutdownPath        = "/api/shutdown"
+	shutdownTokenEnv    = "LTTH_SHUTDOWN_TOKEN"
+	shutdownTokenHeader = "X-Shutdown-Token"
+)
+
+func newShutdownToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// Without a token server.js rejects every shutdown request
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+type pidRecord struct {
+	PID         int       `json:"pid"`
+	LauncherPID int       `json:"launcherPid"`
+	Node        string    `json:"node"`
+	Started     time.Time `json:"started"`
+}
+
+func (l *Launcher) pidFilePath() string {
+	return filepath.Join(l.appDir, pidFile)
+}
+
+func (l *Launcher) readPIDFile() []pidRecord {
+	data, err := os.ReadFile(l.pidFilePath())
+	if err != nil {
+		return nil
+	}
+	var recs []pidRecord
+	if err := json.Unmarshal(data, &recs); err != nil {
+		l.logger.Printf("[WARNING] Ignoring unreadable %s: %v\n", pidFile, err)
+		return nil
+	}
+	return recs
+}
+
+func (l *Launcher) writePIDFile(recs []pidRecord) {
+	if len(recs) == 0 {
+		os.Remove(l.pidFilePath())
+		return
+	}
+	data, err := json.MarshalIndent(recs, "", "  ")
+	if err == nil {
+		err = os.WriteFile(l.pidFilePath(), data, 0644)
+	}
+	if err != nil {
+		l.logger.Printf("[WARNING] Could not write %s: %v\n", pidFile, err)
+	}
+}
+
+// addPID records a started Node.js process in the PID file.
+func (l *Launcher) addPID(pid int) {
+	l.pidMu.Lock()
+	defer l.pidMu.Unlock()
+	l.writePIDFile(append(l.readPIDFile(), pidRecord{
+		PID:         pid,
+		LauncherPID: os.Getpid(),
+		Node:        l.nodePath,
+		Started:     time.Now(),
+	}))
+}
+
+// removePID drops pid from the PID file.
+func (l *Launcher) removePID(pid int) {
+	l.pidMu.Lock()
+	defer l.pidMu.Unlock()
+	var keep []pidRecord
+	for _, rec := range l.readPIDFile() {
+		if rec.PID != pid {
+			keep = append(keep, rec)
+		}
+	}
+	l.writePIDFile(keep)
+}
+
+// cleanupOrphans stops Node.js processes left behind by earlier launchers
+// that exited without shutting them down. Processes whose launcher is
+// still running are left alone.
+func (l *Launcher) cleanupOrphans() {
+	l.pidMu.Lock()
+	defer l.pidMu.Unlock()
+
+	var keep []pidRecord
+	for _, rec := range l.readPIDFile() {
+		if rec.LauncherPID != os.Getpid() && processAlive(rec.LauncherPID) {
+			l.logAndSync("[INFO] Node.js process %d belongs to running launcher %d - leaving it alone", rec.PID, rec.LauncherPID)
+			keep = append(keep, rec)
+			continue
+		}
+
+		// The PID may have been reused by an unrelated program since then
+		if rec.PID <= 0 || !processAlive(rec.PID) || !strings.Contains(strings.ToLower(processCommand(rec.PID)), "node") {
+			l.logger.Printf("[INFO] Stale PID %d removed from %s\n", rec.PID, pidFile)
+			continue
+		}
+
+		l.logAndSync("[AUTO-FIX] Stopping orphaned Node.js process %d (started %s)", rec.PID, rec.Started.Format(time.RFC3339))
+		l.showWarning("Alter Server-Prozess eines früheren Starts wird beendet")
+		l.stopTree(rec.PID, nil, nil)
+	}
+	l.writePIDFile(keep)
+}
+
+// stopTree interrupts the process tree of pid, waits for the grace period
+// and kills whatever is left. exited, if not nil, is closed once pid has
+// been reaped. If the interrupt cannot be sent, the server is asked to shut
+// down on one of ports instead.
+func (l *Launcher) stopTree(pid int, exited <-chan struct{}, ports []int) {
+	grace := time.Duration(l.cfg.Settings.Shutdown.GraceSeconds) * time.Second
+
+	if err := interruptTree(pid); err != nil {
+		l.logger.Printf("[WARNING] Could not interrupt process %d: %v\n", pid, err)
+		if len(ports) > 0 {
+			l.logAndSync("[INFO] Falling back to %s to stop process %d", shutdownPath, pid)
+			l.requestShutdown(ports)
+		}
+	}
+
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		if exited != nil {
+			select {
+			case <-exited:
+				exited = nil
+			default:
+			}
+		}
+		if exited == nil && !treeAlive(pid) {
+			l.logAndSync("[INFO] Node.js process %d exited cleanly", pid)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	l.logAndSync("[WARNING] Node.js process %d still running after %v - killing process tree", pid, grace)
+	if err := killTree(pid); err != nil {
+		l.logger.Printf("[ERROR] Could not kill process tree %d: %v\n", pid, err)
+	}
+}
+
+// requestShutdown asks the server on one of ports to shut down. It is the
+// fallback where no interrupt can be sent: a GUI launcher built with
+// -H windowsgui has no console for GenerateConsoleCtrlEvent.
+func (l *Launcher) requestShutdown(ports []int) {
+	client := &http.Client{Timeout: 2 * time.Second}
+	for _, port := range ports {
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost:%d%s", port, shutdownPath), nil)
+		if err != nil {
+			continue
+		}
+		req.Header.Set(shutdownTokenHeader, l.shutdownToken)
+		// server.js closes only once no connection is left open
+		req.Close = true
+
+		resp, err := client.Do(req)
+		if err != nil {
+			l.logger.Printf("[WARNING] %s on port %d failed: %v\n", shutdownPath, port, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusAccepted {
+			l.logAndSync("[INFO] Server on port %d is shutting down", port)
+			return
+		}
+		l.logger.Printf("[WARNING] %s on port %d answered %s\n", shutdownPath, port, resp.Status)
+	}
+}
+
+// Shutdown stops the Node.js server started by Run or StartServer: it
+// forwards an interrupt to the whole process tree, waits
+// Settings.Shutdown.GraceSeconds for a clean exit and then kills what is
+// left. The supervisor does not restart it afterwards.
+func (l *Launcher) Shutdown() {
+	l.stopping.Store(true)
+	l.shutdownOnce.Do(l.shutdown)
+}
+
+func (l *Launcher) shutdown() {
+	defer close(l.shutdownDone)
+
+	l.procMu.Lock()
+	cmd, exited := l.cmd, l.exited
+	l.procMu.Unlock()
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+
+	pid := cmd.Process.Pid
+	l.logAndSync("[INFO] Shutting down Node.js server (pid %d)...", pid)
+	l.stopTree(pid, exited, l.healthPorts())
+	l.removePID(pid)
+}
+
+// HandleSignals shuts the server down and exits when the launcher receives
+// SIGINT or SIGTERM (Ctrl+C or closing the console window).
+func (l *Launcher) HandleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		l.logAndSync("[INFO] Received %v", sig)
+		l.sink.Progress(100, "Launcher wird beendet...")
+		l.Shutdown()
+		l.Close()
+		os.Exit(exitInterrupted)
+	}()
+}
@@ -0,0 +1,163 @@
+package launcher
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeShutdown serves server.js's shutdown endpoint: it accepts requests
+// with token and counts the accepted ones.
+func fakeShutdown(t *testing.T, token string, accepted *atomic.Int32) int {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != shutdownPath {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get(shutdownTokenHeader) != token {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		accepted.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.Listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestRequestShutdown(t *testing.T) {
+	l := New(Config{AppDir: t.TempDir()}, discardSink{})
+	if len(l.shutdownToken) != 32 {
+		t.Fatalf("shutdownToken = %q, want 32 hex digits", l.shutdownToken)
+	}
+	if other := New(Config{}, discardSink{}); other.shutdownToken == l.shutdownToken {
+		t.Error("two launchers got the same shutdown token")
+	}
+
+	var accepted, rejected atomic.Int32
+	good := fakeShutdown(t, l.shutdownToken, &accepted)
+	wrongToken := fakeShutdown(t, "another launcher's token", &rejected)
+
+	// The server announced another port than it was given; the first one
+	// answers but refuses
+	l.requestShutdown([]int{wrongToken, good})
+	if accepted.Load() != 1 || rejected.Load() != 0 {
+		t.Errorf("accepted %d, rejected server accepted %d; want 1 and 0", accepted.Load(), rejected.Load())
+	}
+
+	// A port nobody listens on is skipped as well
+	l.requestShutdown([]int{closedPort(t), good})
+	if accepted.Load() != 2 {
+		t.Errorf("accepted %d requests, want 2", accepted.Load())
+	}
+}
+
+// closedPort returns a port that was free a moment ago.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return port
+}
+
+func TestStopTreeFallsBackToRequest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a process group that cannot be interrupted")
+	}
+	// A process that has been reaped cannot be signalled, like one without
+	// a console on Windows
+	cmd := exec.Command("true")
+	setProcessGroup(cmd)
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	l := New(Config{AppDir: t.TempDir()}, discardSink{})
+	var accepted atomic.Int32
+	port := fakeShutdown(t, l.shutdownToken, &accepted)
+	l.stopTree(cmd.Process.Pid, nil, []int{port})
+	if accepted.Load() != 1 {
+		t.Errorf("stopTree sent %d shutdown requests, want 1", accepted.Load())
+	}
+
+	// Orphans have no known port and are only interrupted
+	l.stopTree(cmd.Process.Pid, nil, nil)
+	if accepted.Load() != 1 {
+		t.Errorf("stopTree without ports sent a shutdown request")
+	}
+}
+
+// fakeServer writes a node that exits cleanly on SIGINT, after writing
+// "interrupted" to env.txt next to launch.js. It records its environment
+// there once it is ready for the signal.
+func fakeServer(t *testing.T) string {
+	t.Helper()
+	script := `#!/bin/sh
+dir=$(dirname "$1")
+trap 'echo interrupted >> "$dir/env.txt"; exit 0' INT
+echo "$` + shutdownTokenEnv + ` $PORT $OPEN_BROWSER" > "$dir/env.txt"
+while :; do sleep 0.1; done
+`
+	node := filepath.Join(t.TempDir(), "node")
+	if err := os.WriteFile(node, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func TestStartServerShutdown(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake node is a shell script")
+	}
+	appDir := t.TempDir()
+	l := New(Config{AppDir: appDir, NodePath: fakeServer(t)}, discardSink{})
+	srv, err := l.StartServer(4321)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recs := l.readPIDFile(); len(recs) != 1 || recs[0].PID != srv.Cmd.Process.Pid {
+		t.Errorf("PID file = %+v, want the started server", recs)
+	}
+
+	envFile := filepath.Join(appDir, "env.txt")
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if data, _ := os.ReadFile(envFile); strings.HasSuffix(string(data), "\n") {
+			break
+		}
+	}
+
+	l.Shutdown()
+	select {
+	case err := <-srv.Done:
+		if err != nil {
+			t.Errorf("server exited with %v, want a clean exit on the interrupt", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server still running after Shutdown")
+	}
+
+	data, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := l.shutdownToken + " 4321 false\ninterrupted\n"
+	if string(data) != want {
+		t.Errorf("server wrote %q, want %q", data, want)
+	}
+	if recs := l.readPIDFile(); len(recs) != 0 {
+		t.Errorf("PID file = %+v after Shutdown, want it removed", recs)
+	}
+}
@@ -0,0 +1,414 @@
+package launcher
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SnapshotDir is the folder next to the executable that holds the
+// node_modules snapshots.
+const SnapshotDir = "cache/node_modules"
+
+// snapshotIndexFile lists the snapshots in SnapshotDir.
+const snapshotIndexFile = "index.json"
+
+// snapshotEntry is one compressed node_modules in the cache.
+type snapshotEntry struct {
+	Key  string `json:"key"`
+	File string `json:"file"`
+	Size int64  `json:"size"`
+	// SHA256 of File, checked before every restore.
+	SHA256      string          `json:"sha256"`
+	Fingerprint depsFingerprint `json:"fingerprint"`
+	Created     time.Time       `json:"created"`
+	LastUsed    time.Time       `json:"lastUsed"`
+}
+
+// snapshotKey identifies the node_modules installed for fp: the same
+// lockfile, Node.js ABI, platform and install profile give the same tree.
+func snapshotKey(fp depsFingerprint) string {
+	hash := fp.LockHash
+	if len(hash) > 16 {
+		hash = hash[:16]
+	}
+	return fmt.Sprintf("%s-abi%s-%s-%s", hash, fp.NodeABI, fp.Platform, fp.profile())
+}
+
+// snapshotCache is the directory of snapshots with its index.
+type snapshotCache struct {
+	dir      string
+	maxBytes int64
+}
+
+// snapshots returns the cache, or nil if snapshots are turned off.
+func (l *Launcher) snapshots() *snapshotCache {
+	ss := l.cfg.Settings.Snapshots
+	if !ss.Enabled || ss.MaxSizeMB <= 0 {
+		return nil
+	}
+	exeDir, _, err := Dirs()
+	if err != nil {
+		return nil
+	}
+	return &snapshotCache{dir: filepath.Join(exeDir, filepath.FromSlash(SnapshotDir)), maxBytes: int64(ss.MaxSizeMB) << 20}
+}
+
+// load reads the index. A missing index is an empty cache; an unreadable
+// one is an error, so that its snapshots are not taken for leftovers.
+func (c *snapshotCache) load() ([]snapshotEntry, error) {
+	var entries []snapshotEntry
+	path := filepath.Join(c.dir, snapshotIndexFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s ist beschädigt: %v", path, err)
+	}
+	return entries, nil
+}
+
+func (c *snapshotCache) store(entries []snapshotEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(c.dir, snapshotIndexFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(c.dir, snapshotIndexFile))
+}
+
+// lookup returns the snapshot for key.
+func (c *snapshotCache) lookup(key string) (snapshotEntry, bool, error) {
+	entries, err := c.load()
+	if err != nil {
+		return snapshotEntry{}, false, err
+	}
+	for _, e := range entries {
+		if e.Key == key {
+			return e, true, nil
+		}
+	}
+	return snapshotEntry{}, false, nil
+}
+
+// update replaces or adds e and, if drop is set, removes it instead. It
+// then removes the least recently used snapshots until the cache fits
+// into maxBytes, and files the index no longer knows. It returns the keys
+// of the evicted snapshots. An unreadable index is left as it is, together
+// with all files, and its error returned.
+func (c *snapshotCache) update(e snapshotEntry, drop bool) ([]string, error) {
+	loaded, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	entries := []snapshotEntry{}
+	for _, old := range loaded {
+		if old.Key != e.Key {
+			entries = append(entries, old)
+		}
+	}
+	if !drop {
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LastUsed.After(entries[j].LastUsed) })
+	var total int64
+	var evicted []string
+	keep := entries[:0]
+	for _, entry := range entries {
+		if total+entry.Size > c.maxBytes {
+			evicted = append(evicted, entry.Key)
+			continue
+		}
+		total += entry.Size
+		keep = append(keep, entry)
+	}
+
+	if err := c.store(keep); err != nil {
+		return nil, err
+	}
+	known := map[string]bool{snapshotIndexFile: true}
+	for _, entry := range keep {
+		known[entry.File] = true
+	}
+	files, _ := os.ReadDir(c.dir)
+	for _, f := range files {
+		if !known[f.Name()] {
+			os.RemoveAll(filepath.Join(c.dir, f.Name()))
+		}
+	}
+	return evicted, nil
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// restoreSnapshot replaces node_modules with the snapshot for fp. It
+// reports false if there is none or it cannot be used; the caller then
+// installs with npm.
+func (l *Launcher) restoreSnapshot(p *PhaseProgress, fp depsFingerprint) bool {
+	c := l.snapshots()
+	if c == nil {
+		return false
+	}
+	return l.restoreFrom(c, p, fp)
+}
+
+func (l *Launcher) restoreFrom(c *snapshotCache, p *PhaseProgress, fp depsFingerprint) bool {
+	key := snapshotKey(fp)
+	e, ok, err := c.lookup(key)
+	if err != nil {
+		l.logAndSync("[WARNING] Cannot read the node_modules snapshot index: %v", err)
+		return false
+	}
+	if !ok {
+		l.logger.Printf("[INFO] No node_modules snapshot for %s (cached: %s)\n", key, c.describe())
+		return false
+	}
+
+	started := time.Now()
+	archive := filepath.Join(c.dir, e.File)
+	p.Update(0.1, "📦 Prüfe gespeicherten Stand von node_modules...")
+	sum, err := fileSHA256(archive)
+	if err == nil && sum != e.SHA256 {
+		err = fmt.Errorf("SHA-256 %s, expected %s", sum, e.SHA256)
+	}
+	if err != nil {
+		l.logAndSync("[WARNING] Snapshot %s is damaged (%v), discarding it", key, err)
+		l.dropSnapshot(c, e)
+		return false
+	}
+
+	p.Update(0.3, "📦 Stelle node_modules aus dem Snapshot wieder her...")
+	staging, err := os.MkdirTemp(l.appDir, ".node_modules-restore-")
+	if err != nil {
+		l.logger.Printf("[WARNING] Cannot restore snapshot %s: %v\n", key, err)
+		return false
+	}
+	defer os.RemoveAll(staging)
+	if err := extractTarGz(archive, staging); err != nil {
+		l.logAndSync("[WARNING] Snapshot %s cannot be extracted (%v), discarding it", key, err)
+		l.dropSnapshot(c, e)
+		return false
+	}
+
+	p.Update(0.9, "📦 Ersetze node_modules...")
+	if err := replaceDir(staging, l.nodeModulesDir()); err != nil {
+		l.logAndSync("[WARNING] Cannot replace node_modules with snapshot %s: %v", key, err)
+		return false
+	}
+
+	e.LastUsed = time.Now()
+	if _, err := c.update(e, false); err != nil {
+		l.logger.Printf("[WARNING] Could not update snapshot index: %v\n", err)
+	}
+	l.logAndSync("[SUCCESS] Restored node_modules from snapshot %s in %v", key, time.Since(started).Round(100*time.Millisecond))
+	return true
+}
+
+// dropSnapshot removes the damaged snapshot e from the cache.
+func (l *Launcher) dropSnapshot(c *snapshotCache, e snapshotEntry) {
+	if _, err := c.update(e, true); err != nil {
+		l.logger.Printf("[WARNING] Could not update snapshot index: %v\n", err)
+	}
+}
+
+// replaceDir moves src to dst, replacing what dst held only once src is in
+// place.
+func replaceDir(src, dst string) error {
+	old := dst + ".old"
+	os.RemoveAll(old)
+	if _, err := os.Stat(dst); err == nil {
+		if err := os.Rename(dst, old); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(src, dst); err != nil {
+		os.Rename(old, dst)
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// saveSnapshot is the snapshot phase: it stores node_modules as installed
+// in this run, under the key of its fingerprint.
+func (l *Launcher) saveSnapshot(p *PhaseProgress) error {
+	c := l.snapshots()
+	fp, ok := l.readDepsFingerprint()
+	if c == nil || !ok {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	started := time.Now()
+	key := snapshotKey(fp)
+	e := snapshotEntry{Key: key, File: key + ".tar.gz", Fingerprint: fp, Created: started, LastUsed: started}
+	partial := filepath.Join(c.dir, e.File+".partial")
+	defer os.Remove(partial)
+
+	size, sum, err := l.writeSnapshot(p, partial)
+	if err != nil {
+		return fmt.Errorf("Snapshot von node_modules fehlgeschlagen: %v", err)
+	}
+	if size > c.maxBytes {
+		l.logger.Printf("[INFO] node_modules snapshot (%d MB) exceeds the cache limit of %d MB - not kept\n", size>>20, c.maxBytes>>20)
+		return nil
+	}
+	if err := os.Rename(partial, filepath.Join(c.dir, e.File)); err != nil {
+		return err
+	}
+	e.Size, e.SHA256 = size, sum
+
+	evicted, err := c.update(e, false)
+	if err != nil {
+		return err
+	}
+	for _, old := range evicted {
+		l.logger.Printf("[INFO] Evicted node_modules snapshot %s\n", old)
+	}
+	l.logAndSync("[SUCCESS] Saved node_modules snapshot %s (%d MB) in %v", key, size>>20, time.Since(started).Round(100*time.Millisecond))
+	p.Update(1, "node_modules gesichert")
+	return nil
+}
+
+// writeSnapshot writes node_modules as a gzipped tar to path and returns
+// its size and SHA-256.
+func (l *Launcher) writeSnapshot(p *PhaseProgress, path string) (int64, string, error) {
+	root := l.nodeModulesDir()
+	var files int
+	filepath.WalkDir(root, func(string, fs.DirEntry, error) error {
+		files++
+		return nil
+	})
+
+	out, err := os.Create(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer out.Close()
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(out, hash)}
+	gz, _ := gzip.NewWriterLevel(counter, gzip.BestSpeed)
+	tw := tar.NewWriter(gz)
+
+	done := 0
+	last := time.Now()
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		done++
+		if time.Since(last) >= 500*time.Millisecond {
+			last = time.Now()
+			p.Update(float64(done)/float64(files), fmt.Sprintf("Sichere node_modules... %d/%d Dateien", done, files))
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, f)
+		f.Close()
+		return err
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	return counter.n, hex.EncodeToString(hash.Sum(nil)), out.Close()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// snapshotIsCurrent is the skip check of the snapshot phase: only
+// node_modules installed by npm in this run is saved.
+func (l *Launcher) snapshotIsCurrent() bool {
+	return !l.depsInstalled || l.snapshots() == nil
+}
+
+// describe lists the cached snapshots for the log.
+func (c *snapshotCache) describe() string {
+	entries, err := c.load()
+	if err != nil {
+		return err.Error()
+	}
+	var parts []string
+	for _, e := range entries {
+		parts = append(parts, fmt.Sprintf("%s (%d MB)", e.Key, e.Size>>20))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
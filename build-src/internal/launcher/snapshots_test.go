@@ -0,0 +1,220 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// testSnapshotCache returns a cache in a temporary directory holding a file
+// of the given size for each entry.
+func testSnapshotCache(t *testing.T, maxBytes int64, entries ...snapshotEntry) *snapshotCache {
+	t.Helper()
+	c := &snapshotCache{dir: t.TempDir(), maxBytes: maxBytes}
+	for i, e := range entries {
+		if e.File == "" {
+			entries[i].File = e.Key + ".tar.gz"
+		}
+		if err := os.WriteFile(filepath.Join(c.dir, entries[i].File), make([]byte, e.Size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.store(entries); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func cachedKeys(t *testing.T, c *snapshotCache) []string {
+	t.Helper()
+	entries, err := c.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func cachedFiles(t *testing.T, c *snapshotCache) []string {
+	t.Helper()
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	return names
+}
+
+func TestSnapshotUpdateEvictsLeastRecentlyUsed(t *testing.T) {
+	now := time.Now()
+	c := testSnapshotCache(t, 100,
+		snapshotEntry{Key: "old", Size: 40, LastUsed: now.Add(-3 * time.Hour)},
+		snapshotEntry{Key: "used", Size: 40, LastUsed: now.Add(-time.Minute)},
+		snapshotEntry{Key: "older", Size: 10, LastUsed: now.Add(-5 * time.Hour)},
+	)
+	// A file the index does not know, e.g. left by an interrupted save
+	if err := os.WriteFile(filepath.Join(c.dir, "stray.tar.gz"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := snapshotEntry{Key: "fresh", File: "fresh.tar.gz", Size: 50, LastUsed: now}
+	if err := os.WriteFile(filepath.Join(c.dir, fresh.File), make([]byte, 50), 0644); err != nil {
+		t.Fatal(err)
+	}
+	evicted, err := c.update(fresh, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fresh and used fill 90 of 100 bytes; old no longer fits, but the
+	// smaller older one still does
+	if want := []string{"old"}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("evicted = %v, want %v", evicted, want)
+	}
+	if got, want := cachedKeys(t, c), []string{"fresh", "older", "used"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cached = %v, want %v", got, want)
+	}
+	if got, want := cachedFiles(t, c), []string{"fresh.tar.gz", snapshotIndexFile, "older.tar.gz", "used.tar.gz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestSnapshotUpdateDrop(t *testing.T) {
+	c := testSnapshotCache(t, 100,
+		snapshotEntry{Key: "a", Size: 10},
+		snapshotEntry{Key: "b", Size: 10},
+	)
+	if _, err := c.update(snapshotEntry{Key: "a"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cachedKeys(t, c), []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cached = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(c.dir, "a.tar.gz")); !os.IsNotExist(err) {
+		t.Errorf("dropped snapshot file still exists: %v", err)
+	}
+}
+
+func TestSnapshotCorruptIndex(t *testing.T) {
+	c := testSnapshotCache(t, 100, snapshotEntry{Key: "a", Size: 10}, snapshotEntry{Key: "b", Size: 10})
+	index := filepath.Join(c.dir, snapshotIndexFile)
+	data, err := os.ReadFile(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := data[:len(data)/2]
+	if err := os.WriteFile(index, truncated, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := c.lookup("a"); err == nil {
+		t.Error("lookup did not report the truncated index")
+	}
+	if _, err := c.update(snapshotEntry{Key: "c", File: "c.tar.gz", Size: 10}, false); err == nil {
+		t.Error("update did not report the truncated index")
+	}
+	if got, want := cachedFiles(t, c), []string{"a.tar.gz", "b.tar.gz", snapshotIndexFile}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	if data, _ := os.ReadFile(index); string(data) != string(truncated) {
+		t.Error("the unreadable index was overwritten")
+	}
+}
+
+func TestSnapshotMissingIndex(t *testing.T) {
+	c := &snapshotCache{dir: t.TempDir(), maxBytes: 100}
+	if _, ok, err := c.lookup("a"); ok || err != nil {
+		t.Errorf("lookup = %v, %v; want a miss without error", ok, err)
+	}
+}
+
+func writeTestTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSnapshotSaveAndRestore(t *testing.T) {
+	appDir := t.TempDir()
+	files := map[string]string{
+		"express/package.json":    `{"name":"express"}`,
+		"express/index.js":        "module.exports = {}",
+		".bin/placeholder":        "",
+		"@scope/pkg/lib/index.js": "// scoped",
+	}
+	writeTestTree(t, filepath.Join(appDir, "node_modules"), files)
+
+	l := New(Config{AppDir: appDir}, discardSink{})
+	p := &PhaseProgress{pl: NewPipeline(discardSink{}, t.Logf, Phase{Name: "deps", Weight: 1})}
+	c := &snapshotCache{dir: t.TempDir(), maxBytes: 1 << 20}
+
+	fp := depsFingerprint{LockHash: "0123456789abcdef0123", NodeABI: "127", Platform: "linux-x64", Profile: ProfileStandard}
+	key := snapshotKey(fp)
+	e := snapshotEntry{Key: key, File: key + ".tar.gz", Fingerprint: fp, LastUsed: time.Now()}
+	size, sum, err := l.writeSnapshot(p, filepath.Join(c.dir, e.File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Size, e.SHA256 = size, sum
+	if _, err := c.update(e, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// A broken install that the snapshot replaces
+	if err := os.RemoveAll(filepath.Join(appDir, "node_modules")); err != nil {
+		t.Fatal(err)
+	}
+	writeTestTree(t, filepath.Join(appDir, "node_modules"), map[string]string{"broken/index.js": "x"})
+
+	if !l.restoreFrom(c, p, fp) {
+		t.Fatal("restoreFrom failed")
+	}
+	for name, body := range files {
+		data, err := os.ReadFile(filepath.Join(appDir, "node_modules", filepath.FromSlash(name)))
+		if err != nil || string(data) != body {
+			t.Errorf("%s = %q, %v; want %q", name, data, err, body)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(appDir, "node_modules", "broken")); !os.IsNotExist(err) {
+		t.Error("node_modules was merged instead of replaced")
+	}
+
+	other := fp
+	other.NodeABI = "115"
+	if l.restoreFrom(c, p, other) {
+		t.Error("restored a snapshot for a different Node.js ABI")
+	}
+}
+
+func TestSnapshotRestoreDamaged(t *testing.T) {
+	appDir := t.TempDir()
+	fp := depsFingerprint{LockHash: "abc", NodeABI: "127", Platform: "linux-x64"}
+	key := snapshotKey(fp)
+	c := testSnapshotCache(t, 100, snapshotEntry{Key: key, Size: 10, SHA256: "not the checksum"})
+
+	l := New(Config{AppDir: appDir}, discardSink{})
+	p := &PhaseProgress{pl: NewPipeline(discardSink{}, t.Logf, Phase{Name: "deps", Weight: 1})}
+	if l.restoreFrom(c, p, fp) {
+		t.Fatal("restored a snapshot with the wrong checksum")
+	}
+	if keys := cachedKeys(t, c); len(keys) != 0 {
+		t.Errorf("damaged snapshot was kept: %v", keys)
+	}
+}
@@ -0,0 +1,125 @@
+package launcher
+
+import (
+	"embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+)
+
+//go:embed assets/*
+var assets embed.FS
+
+// Splash serves the browser progress page and implements Sink and
+// EventSink by publishing events to all connected /events clients.
+type Splash struct {
+	bgImagePath string
+	stream      *events.Stream
+	actions     chan string // IDs posted to /action
+}
+
+// NewSplash creates a splash page using bgImagePath as background image.
+func NewSplash(bgImagePath string) *Splash {
+	return &Splash{
+		bgImagePath: bgImagePath,
+		stream:      events.NewStream(events.DefaultReplaySize, "Initialisiere..."),
+		actions:     make(chan string, 1),
+	}
+}
+
+// remedyTimeout is how long the splash waits for a remedy to be picked.
+const remedyTimeout = 10 * time.Minute
+
+// ChooseRemedy shows the npm failure with a button per remedy and waits
+// until one is clicked. It can be used as Config.ChooseRemedy.
+func (s *Splash) ChooseRemedy(f NpmFailure) Remedy {
+	actions := make([]events.Action, 0, len(f.Remedies)+1)
+	for _, r := range f.Remedies {
+		actions = append(actions, events.Action{ID: string(r), Label: r.Label()})
+	}
+	actions = append(actions, events.Action{ID: "", Label: "Abbrechen"})
+
+	// Forget clicks on buttons of an earlier failure
+	select {
+	case <-s.actions:
+	default:
+	}
+	s.stream.Publish(events.TypeError, events.Message{
+		Message: f.Status(),
+		Hints:   f.hintLines(),
+		Code:    f.Code,
+		Details: f.Excerpt,
+		Actions: actions,
+	})
+
+	select {
+	case id := <-s.actions:
+		for _, r := range f.Remedies {
+			if string(r) == id {
+				return r
+			}
+		}
+	case <-time.After(remedyTimeout):
+	}
+	return ""
+}
+
+func (s *Splash) Progress(value int, status string) {
+	s.stream.Progress(value, status)
+}
+
+func (s *Splash) Redirect(url string) {
+	s.stream.Publish(events.TypeRedirect, events.Redirect{URL: url})
+}
+
+func (s *Splash) Event(typ events.Type, data interface{}) {
+	s.stream.Publish(typ, data)
+}
+
+// Handler returns the HTTP handler serving the page, background and events.
+func (s *Splash) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		tmpl := template.Must(template.ParseFS(assets, "assets/splash.html"))
+		tmpl.Execute(w, nil)
+	})
+
+	mux.HandleFunc("/bg", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, s.bgImagePath)
+	})
+
+	mux.Handle("/events", s.stream)
+
+	mux.HandleFunc("/action", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// Other web pages cannot send JSON here without a CORS preflight,
+		// which is never answered
+		var body struct {
+			ID string `json:"id"`
+		}
+		if r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&body) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		select {
+		case s.actions <- body.ID:
+		default:
+			// A choice is already waiting to be picked up
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+// ListenAndServe serves the splash page on addr.
+func (s *Splash) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s.Handler())
+}
@@ -0,0 +1,275 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// RestartPolicy decides whether the supervisor restarts an exited server.
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "never"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartAlways    RestartPolicy = "always"
+)
+
+func (p RestartPolicy) validate() error {
+	switch p {
+	case RestartNever, RestartOnFailure, RestartAlways:
+		return nil
+	}
+	return fmt.Errorf("unbekannte Neustart-Richtlinie %q (erlaubt: never, on-failure, always)", p)
+}
+
+// shouldRestart reports whether a server that exited with err is restarted.
+func (p RestartPolicy) shouldRestart(err error) bool {
+	switch p {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	}
+	return false
+}
+
+// historyFile is kept in the app's logs directory.
+const (
+	historyFile       = "restart-history.json"
+	maxHistoryEntries = 200
+)
+
+// RestartRecord is one entry of the persisted restart history.
+type RestartRecord struct {
+	Time     time.Time `json:"time"`
+	Uptime   float64   `json:"uptimeSeconds"`
+	ExitCode int       `json:"exitCode"`
+	Error    string    `json:"error,omitempty"`
+	// Action is "restarted", "stopped" or "gave-up".
+	Action string  `json:"action"`
+	Delay  float64 `json:"delaySeconds,omitempty"`
+}
+
+// exitCode extracts the process exit code from a cmd.Wait error.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// Supervise keeps owning the server started by Run and restarts it after
+// it exits, according to Settings.Restart. It returns once the policy says
+// not to restart or too many restarts happened within the window; the
+// returned error is the last exit error of the server.
+func (l *Launcher) Supervise() error {
+	rs := l.cfg.Settings.Restart
+	l.logAndSync("[SUPERVISOR] Watching server (policy %s, max %d restarts per %v)", rs.Policy, rs.MaxRestarts, rs.window())
+
+	var restarts []time.Time
+	failures := 0
+	started := time.Now()
+	err := l.waitForExit()
+
+	for {
+		uptime := time.Since(started)
+		rec := RestartRecord{
+			Time:     time.Now(),
+			Uptime:   uptime.Round(time.Second).Seconds(),
+			ExitCode: exitCode(err),
+		}
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		l.logAndSync("--- Node.js Server Output End ---")
+		l.logAndSync("[SUPERVISOR] Server exited after %v: %v", uptime.Round(time.Second), err)
+
+		if l.stopping.Load() {
+			// Let Shutdown finish with the rest of the process tree
+			<-l.shutdownDone
+			rec.Action = "stopped"
+			l.recordRestart(rec)
+			return nil
+		}
+		if !rs.Policy.shouldRestart(err) {
+			rec.Action = "stopped"
+			l.recordRestart(rec)
+			return err
+		}
+
+		restarts = rs.recent(restarts, time.Now())
+		if rs.exhausted(restarts) {
+			rec.Action = "gave-up"
+			l.recordRestart(rec)
+			l.logAndSync("[SUPERVISOR] %d restarts within %v - giving up", len(restarts), rs.window())
+			l.showWarning(fmt.Sprintf("Server ist %d-mal abgestürzt - automatischer Neustart gestoppt", len(restarts)))
+			return err
+		}
+
+		// A server that ran longer than the window was healthy; start the
+		// backoff from the beginning
+		if uptime >= rs.window() {
+			failures = 0
+		}
+		delay := rs.delay(failures)
+		failures++
+
+		rec.Action = "restarted"
+		rec.Delay = delay.Seconds()
+		l.recordRestart(rec)
+		l.logAndSync("[SUPERVISOR] Restarting server in %v (restart %d/%d)", delay, len(restarts)+1, rs.MaxRestarts)
+		l.showWarning(fmt.Sprintf("Server beendet (%v) - Neustart in %v", err, delay))
+		time.Sleep(delay)
+
+		restarts = append(restarts, time.Now())
+		started = time.Now()
+		if rerr := l.restartServer(); rerr != nil {
+			l.logAndSync("[SUPERVISOR] Restart failed: %v", rerr)
+			err = rerr
+			continue
+		}
+		l.logAndSync("[SUPERVISOR] Server is running again")
+		err = l.waitForExit()
+	}
+}
+
+// recent returns the restarts that lie within the window before now; only
+// those count towards MaxRestarts.
+func (r RestartSettings) recent(restarts []time.Time, now time.Time) []time.Time {
+	kept := restarts[:0]
+	for _, t := range restarts {
+		if now.Sub(t) < r.window() {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// exhausted reports whether the supervisor gives up after the recent
+// restarts.
+func (r RestartSettings) exhausted(recent []time.Time) bool {
+	return len(recent) >= r.MaxRestarts
+}
+
+// delay returns how long to wait before the restart after failures earlier
+// crashes: the backoff, doubled per crash and capped at the maximum. A zero
+// backoff restarts immediately.
+func (r RestartSettings) delay(failures int) time.Duration {
+	d := r.backoff()
+	for i := 0; i < failures && d > 0 && d < r.maxBackoff(); i++ {
+		d *= 2
+	}
+	if d > r.maxBackoff() {
+		d = r.maxBackoff()
+	}
+	return d
+}
+
+// waitForExit waits for the current server to exit while checking that it
+// still answers.
+func (l *Launcher) waitForExit() error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go l.watchLiveness(l.server, stop)
+	return <-l.server.Done
+}
+
+// watchLiveness kills srv once it failed Settings.Liveness.Failures checks
+// in a row, so that the restart policy can bring it back.
+func (l *Launcher) watchLiveness(srv *Server, stop <-chan struct{}) {
+	ls := l.cfg.Settings.Liveness
+	if ls.IntervalSeconds <= 0 || srv.Port == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(ls.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if checkLiveness(srv.Port) {
+				failures = 0
+				continue
+			}
+			failures++
+			l.logAndSync("[SUPERVISOR] Liveness check on port %d failed (%d/%d)", srv.Port, failures, ls.Failures)
+			if failures >= ls.Failures {
+				l.logAndSync("[SUPERVISOR] Server is not responding - killing it")
+				l.showWarning("Server reagiert nicht mehr - wird neu gestartet")
+				killTree(srv.Cmd.Process.Pid)
+				return
+			}
+		}
+	}
+}
+
+// restartServer runs the start and health phases of the pipeline again.
+func (l *Launcher) restartServer() error {
+	var phases []Phase
+	for _, ph := range l.phases() {
+		if ph.Name == "start" || ph.Name == "health" {
+			phases = append(phases, ph)
+		}
+	}
+
+	l.server = nil
+	if err := NewPipeline(l.sink, l.logAndSync, phases...).Run(); err != nil {
+		// A server that never became healthy may still be running
+		l.procMu.Lock()
+		cmd, exited := l.cmd, l.exited
+		l.procMu.Unlock()
+		if cmd != nil && cmd.Process != nil {
+			killTree(cmd.Process.Pid)
+			select {
+			case <-exited:
+			case <-time.After(5 * time.Second):
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// recordRestart appends rec to the restart history in the logs directory.
+func (l *Launcher) recordRestart(rec RestartRecord) {
+	path := filepath.Join(l.appDir, "logs", historyFile)
+
+	var history []RestartRecord
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &history); err != nil {
+			l.logger.Printf("[WARNING] Ignoring unreadable %s: %v\n", historyFile, err)
+			history = nil
+		}
+	}
+
+	history = append(history, rec)
+	if len(history) > maxHistoryEntries {
+		history = history[len(history)-maxHistoryEntries:]
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		l.logger.Printf("[WARNING] Could not encode restart history: %v\n", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		l.logger.Printf("[WARNING] Could not write restart history: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		l.logger.Printf("[WARNING] Could not write restart history: %v\n", err)
+	}
+}
@@ -0,0 +1,132 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRestartDelay(t *testing.T) {
+	tests := []struct {
+		name            string
+		backoff, maxOff int
+		want            []time.Duration
+	}{
+		{"defaults", 2, 60, []time.Duration{2, 4, 8, 16, 32, 60, 60}},
+		{"zero backoff", 0, 60, []time.Duration{0, 0, 0}},
+		{"no growth", 5, 5, []time.Duration{5, 5, 5}},
+		{"all zero", 0, 0, []time.Duration{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := RestartSettings{BackoffSeconds: tt.backoff, MaxBackoffSeconds: tt.maxOff}
+			for failures, want := range tt.want {
+				if got := rs.delay(failures); got != want*time.Second {
+					t.Errorf("delay(%d) = %v, want %v", failures, got, want*time.Second)
+				}
+			}
+		})
+	}
+
+	rs := RestartSettings{BackoffSeconds: 1, MaxBackoffSeconds: 60}
+	if got := rs.delay(200); got != time.Minute {
+		t.Errorf("delay(200) = %v, want the maximum", got)
+	}
+}
+
+func TestRestartGiveUp(t *testing.T) {
+	rs := RestartSettings{MaxRestarts: 3, WindowSeconds: 60}
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		ago    []time.Duration
+		recent int
+		giveUp bool
+	}{
+		{"first crash", nil, 0, false},
+		{"below limit", []time.Duration{50 * time.Second, 10 * time.Second}, 2, false},
+		{"limit reached", []time.Duration{50 * time.Second, 30 * time.Second, 10 * time.Second}, 3, true},
+		{"old restarts expire", []time.Duration{2 * time.Minute, 61 * time.Second, 10 * time.Second}, 1, false},
+		{"window edge", []time.Duration{time.Minute, 30 * time.Second, time.Second}, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var restarts []time.Time
+			for _, d := range tt.ago {
+				restarts = append(restarts, now.Add(-d))
+			}
+			recent := rs.recent(restarts, now)
+			if len(recent) != tt.recent {
+				t.Errorf("recent = %d restarts, want %d", len(recent), tt.recent)
+			}
+			if got := rs.exhausted(recent); got != tt.giveUp {
+				t.Errorf("exhausted = %v, want %v", got, tt.giveUp)
+			}
+		})
+	}
+}
+
+func TestLoadSettingsRestart(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"defaults", `{}`, false},
+		{"immediate restart", `{"restart": {"backoffSeconds": 0}}`, false},
+		{"negative backoff", `{"restart": {"backoffSeconds": -1}}`, true},
+		{"zero max backoff", `{"restart": {"maxBackoffSeconds": 0}}`, true},
+		{"max below backoff", `{"restart": {"backoffSeconds": 10, "maxBackoffSeconds": 5}}`, true},
+		{"zero restarts", `{"restart": {"maxRestarts": 0}}`, true},
+		{"zero window", `{"restart": {"windowSeconds": 0}}`, true},
+		{"unknown policy", `{"restart": {"policy": "sometimes"}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, SettingsFile), []byte(tt.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+			settings, err := LoadSettings(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSettings error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && settings.Restart != DefaultSettings().Restart {
+				t.Errorf("invalid settings were not replaced by the defaults: %+v", settings.Restart)
+			}
+		})
+	}
+}
+
+func TestLoadSettingsShutdownLiveness(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"defaults", `{}`, false},
+		{"kill immediately", `{"shutdown": {"graceSeconds": 0}}`, false},
+		{"negative grace", `{"shutdown": {"graceSeconds": -5}}`, true},
+		{"liveness disabled", `{"liveness": {"intervalSeconds": 0}}`, false},
+		{"negative interval", `{"liveness": {"intervalSeconds": -15}}`, true},
+		{"zero failures", `{"liveness": {"failures": 0}}`, true},
+		{"negative failures", `{"liveness": {"failures": -1}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, SettingsFile), []byte(tt.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+			settings, err := LoadSettings(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSettings error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && (settings.Shutdown != DefaultSettings().Shutdown || settings.Liveness != DefaultSettings().Liveness) {
+				t.Errorf("invalid settings were not replaced by the defaults: %+v %+v", settings.Shutdown, settings.Liveness)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+)
+
+// buildTool is a program node-gyp needs to compile a native module.
+type buildTool struct {
+	Name string
+	// Path and Version are empty if the tool was not found.
+	Path    string
+	Version string
+	// Install tells the user how to get the tool.
+	Install string
+}
+
+func (t buildTool) found() bool {
+	return t.Path != ""
+}
+
+// toolchain is the result of detectToolchain.
+type toolchain []buildTool
+
+// missing returns the names of the tools that were not found.
+func (tc toolchain) missing() []string {
+	var names []string
+	for _, t := range tc {
+		if !t.found() {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// probeCommand runs name with args and returns its path and the first
+// line of its output, or empty strings if it is missing or fails.
+func probeCommand(name string, args ...string) (path, version string) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	if err != nil {
+		return "", ""
+	}
+	version, _, _ = strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return path, strings.TrimSpace(version)
+}
+
+// firstCommand probes the candidates in order and fills t with the first
+// one that works.
+func (t buildTool) firstCommand(candidates []string, args ...string) buildTool {
+	for _, name := range candidates {
+		if name == "" {
+			continue
+		}
+		if path, version := probeCommand(name, args...); path != "" {
+			t.Path, t.Version = path, version
+			break
+		}
+	}
+	return t
+}
+
+// detectToolchain looks for what node-gyp needs on this platform: Python 3
+// everywhere, make and a C++ compiler on Linux and macOS, Visual Studio
+// with the C++ workload on Windows.
+func detectToolchain() toolchain {
+	// node-gyp honours these before searching the PATH
+	pythons := []string{os.Getenv("npm_config_python"), os.Getenv("PYTHON"), "python3", "python"}
+	python := buildTool{Name: "Python 3"}.firstCommand(pythons, "--version")
+	if python.found() && !strings.HasPrefix(python.Version, "Python 3") {
+		python.Path = ""
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		python.Install = "https://www.python.org/downloads/ (bei der Installation 'Add to PATH' wählen)"
+		return toolchain{python, detectVisualStudio()}
+	case "darwin":
+		python.Install = "xcode-select --install"
+		clt := buildTool{Name: "Xcode Command Line Tools", Install: "xcode-select --install"}
+		if path, _ := probeCommand("xcode-select", "-p"); path != "" {
+			clt = clt.firstCommand([]string{"clang++"}, "--version")
+		}
+		makeTool := buildTool{Name: "make", Install: "xcode-select --install"}.firstCommand([]string{"make"}, "--version")
+		return toolchain{python, makeTool, clt}
+	}
+	python.Install = "sudo apt install python3"
+	makeTool := buildTool{Name: "make", Install: "sudo apt install build-essential"}.firstCommand([]string{"make"}, "--version")
+	cxx := buildTool{Name: "C++-Compiler", Install: "sudo apt install build-essential"}.firstCommand([]string{os.Getenv("CXX"), "g++", "c++", "clang++"}, "--version")
+	return toolchain{python, makeTool, cxx}
+}
+
+// detectVisualStudio asks vswhere for a Visual Studio installation with the
+// C++ build tools, which is what node-gyp looks for as well.
+func detectVisualStudio() buildTool {
+	vs := buildTool{
+		Name:    "Visual Studio C++ Build Tools",
+		Install: "https://visualstudio.microsoft.com/downloads/ ('Desktop development with C++')",
+	}
+	programFiles := os.Getenv("ProgramFiles(x86)")
+	if programFiles == "" {
+		programFiles = `C:\Program Files (x86)`
+	}
+	vswhere := filepath.Join(programFiles, "Microsoft Visual Studio", "Installer", "vswhere.exe")
+	if !fileExists(vswhere) {
+		return vs
+	}
+	output, err := exec.Command(vswhere, "-latest", "-products", "*", "-requires", "Microsoft.VisualStudio.Component.VC.Tools.x86.x64", "-format", "json").Output()
+	if err != nil {
+		return vs
+	}
+	var installs []struct {
+		InstallationPath string `json:"installationPath"`
+		DisplayName      string `json:"displayName"`
+	}
+	if json.Unmarshal(output, &installs) == nil && len(installs) > 0 {
+		vs.Path, vs.Version = installs[0].InstallationPath, installs[0].DisplayName
+	}
+	return vs
+}
+
+// nativeModuleSpec is an entry of "nativeModules" in the app's package.json.
+type nativeModuleSpec struct {
+	// Prebuilt is "bundled" for packages that ship Node-API binaries for
+	// every Node.js version, or the URL prebuild-install downloads from,
+	// with {version}, {abi}, {platform} and {arch} filled in.
+	Prebuilt string `json:"prebuilt"`
+	// Platforms limits "bundled" to these "<os>-<arch>" pairs.
+	Platforms []string `json:"platforms"`
+}
+
+type prebuiltStatus int
+
+const (
+	prebuiltUnknown prebuiltStatus = iota
+	prebuiltAvailable
+	prebuiltMissing
+)
+
+// nativeBuild is the outlook for one native module: whether npm can
+// download a binary for it or has to compile it.
+type nativeBuild struct {
+	Package string
+	Version string
+	Status  prebuiltStatus
+	// Detail explains Status to the user.
+	Detail string
+}
+
+var prebuiltClient = &http.Client{Timeout: 5 * time.Second}
+
+// checkPrebuilt decides whether a binary of pkg@version exists for the ABI
+// and platform of fp.
+func checkPrebuilt(pkg, version string, spec nativeModuleSpec, fp depsFingerprint) nativeBuild {
+	b := nativeBuild{Package: pkg, Version: version}
+	osName, arch, _ := strings.Cut(fp.Platform, "-")
+	switch {
+	case spec.Prebuilt == "bundled":
+		if len(spec.Platforms) > 0 && !containsString(spec.Platforms, fp.Platform) {
+			b.Status, b.Detail = prebuiltMissing, fmt.Sprintf("keine mitgelieferte Binärdatei für %s", fp.Platform)
+			break
+		}
+		b.Status, b.Detail = prebuiltAvailable, "Binärdatei im Paket enthalten (Node-API)"
+	case strings.HasPrefix(spec.Prebuilt, "http://") || strings.HasPrefix(spec.Prebuilt, "https://"):
+		url := strings.NewReplacer("{version}", version, "{abi}", fp.NodeABI, "{platform}", osName, "{arch}", arch).Replace(spec.Prebuilt)
+		resp, err := prebuiltClient.Head(url)
+		if err != nil {
+			b.Detail = fmt.Sprintf("nicht prüfbar (%v)", err)
+			break
+		}
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			b.Status, b.Detail = prebuiltAvailable, fmt.Sprintf("fertiges Modul für ABI %s verfügbar", fp.NodeABI)
+		case resp.StatusCode == http.StatusNotFound:
+			b.Status, b.Detail = prebuiltMissing, fmt.Sprintf("kein fertiges Modul für Node.js %s (ABI %s) und %s", fp.NodeVersion, fp.NodeABI, fp.Platform)
+		default:
+			b.Detail = fmt.Sprintf("nicht prüfbar (HTTP %d)", resp.StatusCode)
+		}
+	default:
+		b.Detail = "keine Angaben zu fertigen Modulen in package.json"
+	}
+	return b
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// nativeModuleSpecs returns the native modules declared in the app's
+// package.json.
+func (l *Launcher) nativeModuleSpecs() map[string]nativeModuleSpec {
+	m, err := readPackageManifest(l.appDir)
+	if err != nil {
+		return nil
+	}
+	return m.NativeModules
+}
+
+// plannedNativeBuilds checks the declared native modules that npm will
+// install or rebuild; only packages in pkgs if it is not empty.
+func (l *Launcher) plannedNativeBuilds(pkgs []string) []nativeBuild {
+	fp := l.depsPlan.current
+	versions := l.lockfileVersions(fp.Platform, l.profile() == ProfileMinimal)
+	var builds []nativeBuild
+	for name, spec := range l.nativeModuleSpecs() {
+		version, ok := versions[name]
+		if !ok || (len(pkgs) > 0 && !containsString(pkgs, name)) {
+			continue
+		}
+		builds = append(builds, checkPrebuilt(name, version, spec, fp))
+	}
+	sort.Slice(builds, func(i, j int) bool { return builds[i].Package < builds[j].Package })
+	return builds
+}
+
+// buildTools detects the build tools once per run.
+func (l *Launcher) buildTools() toolchain {
+	if l.tools == nil {
+		l.tools = detectToolchain()
+		for _, t := range l.tools {
+			if t.found() {
+				l.logger.Printf("[INFO] Build tool %s: %s (%s)\n", t.Name, t.Path, t.Version)
+			} else {
+				l.logger.Printf("[INFO] Build tool %s: not found\n", t.Name)
+			}
+		}
+	}
+	return l.tools
+}
+
+// preflightNativeBuild runs before npm installs or rebuilds native modules
+// (pkgs, or all declared ones if empty). It tells on the splash whether
+// they have to be compiled and whether the build tools for that are there.
+func (l *Launcher) preflightNativeBuild(p *PhaseProgress, pkgs []string) {
+	checked := strings.Join(pkgs, ",")
+	if len(l.nativeModuleSpecs()) == 0 || l.preflighted[checked] {
+		// Retries of a phase need not repeat the warnings
+		return
+	}
+	if l.preflighted == nil {
+		l.preflighted = make(map[string]bool)
+	}
+	l.preflighted[checked] = true
+	p.Status("🔍 Prüfe fertige Module und Build-Werkzeuge...")
+	started := time.Now()
+
+	var compile []string
+	for _, b := range l.plannedNativeBuilds(pkgs) {
+		l.logger.Printf("[INFO] Preflight %s@%s: %s\n", b.Package, b.Version, b.Detail)
+		emit(l.sink, events.TypeLogLine, events.LogLine{Source: "preflight", Line: fmt.Sprintf("%s %s: %s", b.Package, b.Version, b.Detail)})
+		if b.Status == prebuiltMissing {
+			compile = append(compile, b.Package)
+		}
+	}
+	l.logger.Printf("[INFO] Preflight finished in %v\n", time.Since(started).Round(time.Millisecond))
+	if len(compile) == 0 {
+		return
+	}
+
+	what := strings.Join(compile, ", ")
+	missing := l.buildTools().missing()
+	if len(missing) == 0 {
+		l.logAndSync("[INFO] Preflight: %s will be compiled from source; build tools found", what)
+		l.showWarning(fmt.Sprintf("%s wird aus dem Quellcode gebaut - das kann einige Minuten dauern", what))
+		return
+	}
+	l.logAndSync("[WARNING] Preflight: %s must be compiled from source, but missing: %s", what, strings.Join(missing, ", "))
+	l.showWarning(fmt.Sprintf("%s muss aus dem Quellcode gebaut werden, es fehlt aber: %s - die Installation wird voraussichtlich fehlschlagen", what, strings.Join(missing, ", ")))
+	for _, t := range l.buildTools() {
+		if !t.found() {
+			l.showWarning(fmt.Sprintf("%s installieren: %s", t.Name, t.Install))
+		}
+	}
+}
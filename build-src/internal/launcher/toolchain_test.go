@@ -0,0 +1,165 @@
+package launcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Loggableim/pupcidslittletiktokhelper/internal/events"
+)
+
+func TestCheckPrebuilt(t *testing.T) {
+	// Serves prebuilds for ABI 115 on linux-x64 only
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/v9.4.0/addon-v9.4.0-node-v115-linux-x64.tar.gz":
+		case "/v9.4.0/addon-v9.4.0-node-v999-linux-x64.tar.gz":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	template := srv.URL + "/v{version}/addon-v{version}-node-v{abi}-{platform}-{arch}.tar.gz"
+	fp := func(abi, platform string) depsFingerprint {
+		return depsFingerprint{NodeVersion: "v20.11.0", NodeABI: abi, Platform: platform}
+	}
+
+	tests := []struct {
+		name string
+		spec nativeModuleSpec
+		fp   depsFingerprint
+		want prebuiltStatus
+	}{
+		{"bundled", nativeModuleSpec{Prebuilt: "bundled"}, fp("115", "linux-x64"), prebuiltAvailable},
+		{"bundled for this platform", nativeModuleSpec{Prebuilt: "bundled", Platforms: []string{"win32-x64", "linux-x64"}}, fp("115", "linux-x64"), prebuiltAvailable},
+		{"bundled for other platforms", nativeModuleSpec{Prebuilt: "bundled", Platforms: []string{"win32-x64"}}, fp("115", "linux-arm64"), prebuiltMissing},
+		{"download available", nativeModuleSpec{Prebuilt: template}, fp("115", "linux-x64"), prebuiltAvailable},
+		{"no download for this ABI", nativeModuleSpec{Prebuilt: template}, fp("127", "linux-x64"), prebuiltMissing},
+		{"no download for this platform", nativeModuleSpec{Prebuilt: template}, fp("115", "linux-arm64"), prebuiltMissing},
+		{"server error", nativeModuleSpec{Prebuilt: template}, fp("999", "linux-x64"), prebuiltUnknown},
+		{"not declared", nativeModuleSpec{}, fp("115", "linux-x64"), prebuiltUnknown},
+	}
+	for _, tt := range tests {
+		b := checkPrebuilt("addon", "9.4.0", tt.spec, tt.fp)
+		if b.Status != tt.want {
+			t.Errorf("%s: status = %d (%s), want %d", tt.name, b.Status, b.Detail, tt.want)
+		}
+		if b.Package != "addon" || b.Version != "9.4.0" || b.Detail == "" {
+			t.Errorf("%s: got %+v", tt.name, b)
+		}
+	}
+	for _, r := range requested {
+		if !strings.HasPrefix(r, http.MethodHead+" ") {
+			t.Errorf("request %q, want only HEAD requests", r)
+		}
+	}
+
+	srv.Close()
+	if b := checkPrebuilt("addon", "9.4.0", nativeModuleSpec{Prebuilt: template}, fp("115", "linux-x64")); b.Status != prebuiltUnknown {
+		t.Errorf("server down: status = %d (%s), want unknown", b.Status, b.Detail)
+	}
+}
+
+// warningSink records the messages of warning events.
+type warningSink struct {
+	discardSink
+	warnings []string
+}
+
+func (s *warningSink) Event(typ events.Type, data interface{}) {
+	if m, ok := data.(events.Message); ok && typ == events.TypeWarning {
+		s.warnings = append(s.warnings, m.Message)
+	}
+}
+
+func TestPreflightNativeBuild(t *testing.T) {
+	appDir := t.TempDir()
+	for name, content := range map[string]string{
+		"package.json": `{"nativeModules": {
+			"bundled-addon": {"prebuilt": "bundled"},
+			"arm-addon": {"prebuilt": "bundled", "platforms": ["linux-arm64"]},
+			"optional-addon": {"prebuilt": "bundled", "platforms": ["linux-arm64"]}
+		}}`,
+		"package-lock.json": `{"packages": {
+			"": {"name": "app"},
+			"node_modules/bundled-addon": {"version": "1.0.0"},
+			"node_modules/arm-addon": {"version": "2.0.0"},
+			"node_modules/optional-addon": {"version": "3.0.0", "optional": true}
+		}}`,
+	} {
+		if err := os.WriteFile(filepath.Join(appDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		platform string
+		profile  InstallProfile
+		tools    toolchain
+		want     []string
+	}{
+		{
+			name:     "prebuilt available",
+			platform: "linux-arm64",
+			tools:    toolchain{{Name: "C++-Compiler"}},
+			want:     nil,
+		},
+		{
+			name:     "compiler found",
+			platform: "linux-x64",
+			profile:  ProfileMinimal,
+			tools:    toolchain{{Name: "C++-Compiler", Path: "/usr/bin/c++"}},
+			want:     []string{"arm-addon wird aus dem Quellcode gebaut"},
+		},
+		{
+			name:     "compiler missing",
+			platform: "linux-x64",
+			profile:  ProfileMinimal,
+			tools:    toolchain{{Name: "Python", Path: "/usr/bin/python3"}, {Name: "C++-Compiler", Install: "build-essential installieren"}},
+			want: []string{
+				"arm-addon muss aus dem Quellcode gebaut werden, es fehlt aber: C++-Compiler",
+				"C++-Compiler installieren: build-essential installieren",
+			},
+		},
+		{
+			name:     "optional modules count unless minimal",
+			platform: "linux-x64",
+			tools:    toolchain{{Name: "C++-Compiler", Path: "/usr/bin/c++"}},
+			want:     []string{"arm-addon, optional-addon wird aus dem Quellcode gebaut"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &warningSink{}
+			l := New(Config{AppDir: appDir}, sink)
+			l.cfg.Settings.Install.Profile = tt.profile
+			l.tools = tt.tools
+			l.depsPlan.current = depsFingerprint{NodeABI: "115", Platform: tt.platform}
+			pl := NewPipeline(sink, t.Logf, Phase{Name: "deps", Weight: 1, Run: func(p *PhaseProgress) error {
+				l.preflightNativeBuild(p, nil)
+				// A retry of the phase stays quiet
+				l.preflightNativeBuild(p, nil)
+				return nil
+			}})
+			if err := pl.Run(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(sink.warnings) != len(tt.want) {
+				t.Fatalf("warnings = %q, want %d starting with %q", sink.warnings, len(tt.want), tt.want)
+			}
+			for i, w := range tt.want {
+				if !strings.HasPrefix(sink.warnings[i], w) {
+					t.Errorf("warning %d = %q, want it to start with %q", i, sink.warnings[i], w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package manifest defines the signed manifest that is published with every
+// release archive. The manifest names the archive with its size and SHA-256
+// and is signed with one or more ed25519 keys; the cloud launcher only
+// installs an archive whose manifest carries a valid signature of a key it
+// has built in.
+package manifest
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// FileName is the release asset holding the manifest.
+	FileName = "ltth-manifest.json"
+	// SignatureName is the release asset holding the signatures of the
+	// manifest's exact bytes.
+	SignatureName = FileName + ".sig"
+)
+
+// maxClockSkew is how far in the future a manifest may be dated.
+const maxClockSkew = 24 * time.Hour
+
+// Manifest describes one release.
+type Manifest struct {
+	// Tag is the release the manifest was made for; the launcher refuses
+	// a manifest that is published with another release.
+	Tag     string    `json:"tag"`
+	Created time.Time `json:"created"`
+	Archive File      `json:"archive"`
+}
+
+// File is a release asset.
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Signature is one entry of the signature file. While keys are rotated a
+// manifest is signed with the old and the new key.
+type Signature struct {
+	KeyID     string `json:"keyId"`
+	Signature []byte `json:"signature"`
+}
+
+// Key is a trusted public key. It only verifies manifests created between
+// NotBefore and NotAfter; a zero NotAfter leaves the key valid.
+type Key struct {
+	ID        string            `json:"id"`
+	PublicKey ed25519.PublicKey `json:"publicKey"`
+	NotBefore time.Time         `json:"notBefore"`
+	NotAfter  time.Time         `json:"notAfter,omitzero"`
+}
+
+func (k Key) validAt(t time.Time) bool {
+	return !t.Before(k.NotBefore) && (k.NotAfter.IsZero() || t.Before(k.NotAfter))
+}
+
+// ParseKeys parses a JSON list of keys.
+func ParseKeys(data []byte) ([]Key, error) {
+	var keys []Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("Schlüsselliste ist ungültig: %v", err)
+	}
+	for _, k := range keys {
+		if len(k.PublicKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("Schlüssel %s: ungültige Länge %d", k.ID, len(k.PublicKey))
+		}
+	}
+	return keys, nil
+}
+
+// Sign signs the manifest bytes data with priv.
+func Sign(data []byte, keyID string, priv ed25519.PrivateKey) Signature {
+	return Signature{KeyID: keyID, Signature: ed25519.Sign(priv, data)}
+}
+
+// Verify checks the signatures sigData over the manifest bytes data and
+// returns the manifest if at least one of them was made by a key from keys
+// that was valid when the manifest was created, and the manifest was made
+// for the release tag.
+func Verify(data, sigData []byte, keys []Key, tag string, now time.Time) (*Manifest, error) {
+	var sigs []Signature
+	if err := json.Unmarshal(sigData, &sigs); err != nil {
+		return nil, fmt.Errorf("%s ist ungültig: %v", SignatureName, err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s ist ungültig: %v", FileName, err)
+	}
+	if m.Created.After(now.Add(maxClockSkew)) {
+		return nil, fmt.Errorf("Manifest ist auf %s datiert, liegt also in der Zukunft", m.Created.Format(time.RFC3339))
+	}
+
+	var problems []string
+	for _, sig := range sigs {
+		key, ok := findKey(keys, sig.KeyID)
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("unbekannter Schlüssel %s", sig.KeyID))
+		case !key.validAt(m.Created):
+			problems = append(problems, fmt.Sprintf("Schlüssel %s war am %s nicht gültig", sig.KeyID, m.Created.Format("2006-01-02")))
+		case !ed25519.Verify(key.PublicKey, data, sig.Signature):
+			problems = append(problems, fmt.Sprintf("Signatur von %s passt nicht", sig.KeyID))
+		default:
+			if m.Tag != tag {
+				// A correctly signed manifest of another release, e.g. an
+				// old one
+				return nil, fmt.Errorf("Manifest wurde für %s ausgestellt, nicht für %s", m.Tag, tag)
+			}
+			return &m, nil
+		}
+	}
+	if len(problems) == 0 {
+		problems = append(problems, "keine Signatur")
+	}
+	return nil, fmt.Errorf("Signatur ungültig: %s", strings.Join(problems, "; "))
+}
+
+func findKey(keys []Key, id string) (Key, bool) {
+	for _, k := range keys {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
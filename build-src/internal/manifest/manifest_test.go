@@ -0,0 +1,145 @@
+package manifest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var created = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+type testKey struct {
+	Key
+	priv ed25519.PrivateKey
+}
+
+func newKey(t *testing.T, id string, notBefore, notAfter time.Time) testKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{Key{ID: id, PublicKey: pub, NotBefore: notBefore, NotAfter: notAfter}, priv}
+}
+
+func manifestData(t *testing.T, tag string, created time.Time) []byte {
+	t.Helper()
+	data, err := json.Marshal(Manifest{
+		Tag:     tag,
+		Created: created,
+		Archive: File{Name: "ltth-" + tag + ".zip", Size: 42, SHA256: strings.Repeat("ab", 32)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func signatures(t *testing.T, sigs ...Signature) []byte {
+	t.Helper()
+	data, err := json.Marshal(sigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestVerify(t *testing.T) {
+	year := 365 * 24 * time.Hour
+	current := newKey(t, "2026-1", created.Add(-year), time.Time{})
+	expired := newKey(t, "2025-1", created.Add(-2*year), created.Add(-time.Hour))
+	future := newKey(t, "2027-1", created.Add(year), time.Time{})
+	stranger := newKey(t, "2026-1", created.Add(-year), time.Time{}) // same id, other key
+	unknown := newKey(t, "unknown", created.Add(-year), time.Time{})
+	trusted := []Key{current.Key, expired.Key, future.Key}
+
+	data := manifestData(t, "v1.2.0", created)
+	sign := func(k testKey) Signature { return Sign(data, k.ID, k.priv) }
+	now := created.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		sigs    []byte
+		tag     string
+		now     time.Time
+		wantErr string
+	}{
+		{name: "valid", sigs: signatures(t, sign(current))},
+		{name: "wrong key", sigs: signatures(t, sign(stranger)), wantErr: "Signatur von 2026-1 passt nicht"},
+		{name: "unknown key id", sigs: signatures(t, sign(unknown)), wantErr: "unbekannter Schlüssel unknown"},
+		{name: "after notAfter", sigs: signatures(t, sign(expired)), wantErr: "Schlüssel 2025-1 war am 2026-06-01 nicht gültig"},
+		{name: "before notBefore", sigs: signatures(t, sign(future)), wantErr: "Schlüssel 2027-1 war am 2026-06-01 nicht gültig"},
+		{name: "no signature", sigs: signatures(t), wantErr: "keine Signatur"},
+		{name: "rotation, old key expired", sigs: signatures(t, sign(expired), sign(current))},
+		{name: "rotation, new key unknown", sigs: signatures(t, sign(current), sign(unknown))},
+		{name: "rotation, all invalid", sigs: signatures(t, sign(expired), sign(unknown)), wantErr: "nicht gültig; unbekannter Schlüssel"},
+		{
+			name:    "modified manifest",
+			data:    manifestData(t, "v1.2.0", created.Add(time.Second)),
+			sigs:    signatures(t, sign(current)),
+			wantErr: "passt nicht",
+		},
+		{
+			name:    "future dated",
+			data:    manifestData(t, "v1.2.0", now.Add(48*time.Hour)),
+			sigs:    signatures(t, Sign(manifestData(t, "v1.2.0", now.Add(48*time.Hour)), current.ID, current.priv)),
+			wantErr: "in der Zukunft",
+		},
+		{name: "slightly ahead clock", now: created.Add(-time.Hour), sigs: signatures(t, sign(current))},
+		{name: "tag mismatch", tag: "v1.3.0", sigs: signatures(t, sign(current)), wantErr: "für v1.2.0 ausgestellt, nicht für v1.3.0"},
+		{name: "broken signature file", sigs: []byte("{"), wantErr: SignatureName},
+		{name: "broken manifest", data: []byte("{"), sigs: signatures(t, sign(current)), wantErr: FileName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.data == nil {
+				tt.data = data
+			}
+			if tt.tag == "" {
+				tt.tag = "v1.2.0"
+			}
+			if tt.now.IsZero() {
+				tt.now = now
+			}
+			m, err := Verify(tt.data, tt.sigs, trusted, tt.tag, tt.now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Tag != "v1.2.0" || m.Archive.Size != 42 {
+				t.Errorf("manifest = %+v", m)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	k := newKey(t, "2026-1", created, time.Time{})
+	data, err := json.Marshal([]Key{k.Key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ParseKeys(data)
+	if err != nil || len(keys) != 1 || !keys[0].PublicKey.Equal(k.PublicKey) {
+		t.Fatalf("ParseKeys = %v, %v", keys, err)
+	}
+	if !strings.Contains(string(data), `"notBefore"`) || strings.Contains(string(data), `"notAfter"`) {
+		t.Errorf("a key without notAfter is written as %s", data)
+	}
+
+	if _, err := ParseKeys([]byte(`[{"id":"short","publicKey":"AAAA"}]`)); err == nil {
+		t.Error("ParseKeys accepted a key of the wrong length")
+	}
+	if keys, err := ParseKeys([]byte(`[]`)); err != nil || len(keys) != 0 {
+		t.Errorf("ParseKeys([]) = %v, %v", keys, err)
+	}
+}
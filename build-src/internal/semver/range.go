@@ -0,0 +1,260 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Range is a version range in npm syntax: comparator sets joined by "||",
+// each a list of comparators that must all hold. Supported are the
+// operators <, <=, >, >=, =, caret (^), tilde (~), x-ranges ("18.x", "18",
+// "*") and hyphen ranges ("18 - 22"). As in npm, a prerelease only
+// satisfies a comparator set that names a prerelease of the same version.
+type Range struct {
+	raw  string
+	sets [][]comparator
+}
+
+type comparator struct {
+	op string // "<", "<=", ">", ">=" or "="
+	v  Version
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return cmp == 0
+}
+
+// operatorSpaceRe joins operators with the version that follows ("> = 18"
+// is not valid, ">= 18" is).
+var operatorSpaceRe = regexp.MustCompile(`(<=|>=|<|>|=|\^|~)\s+`)
+
+// ParseRange parses an npm version range such as ">=18.0.0 <25.0.0".
+func ParseRange(s string) (Range, error) {
+	r := Range{raw: strings.TrimSpace(s)}
+	for _, alt := range strings.Split(s, "||") {
+		alt = strings.TrimSpace(alt)
+		var set []comparator
+
+		if lo, hi, ok := strings.Cut(alt, " - "); ok {
+			from, err := lowerBound(">=", lo)
+			if err != nil {
+				return Range{}, err
+			}
+			to, err := upperBound("<=", hi)
+			if err != nil {
+				return Range{}, err
+			}
+			set = append(append(set, from...), to...)
+			r.sets = append(r.sets, set)
+			continue
+		}
+
+		for _, tok := range strings.Fields(operatorSpaceRe.ReplaceAllString(alt, "$1")) {
+			cs, err := parseComparator(tok)
+			if err != nil {
+				return Range{}, fmt.Errorf("ungültiger Versionsbereich %q: %v", s, err)
+			}
+			set = append(set, cs...)
+		}
+		r.sets = append(r.sets, set)
+	}
+	return r, nil
+}
+
+// parseComparator turns one token into plain comparators.
+func parseComparator(tok string) ([]comparator, error) {
+	for _, op := range []string{"<=", ">=", "<", ">", "^", "~", "="} {
+		if !strings.HasPrefix(tok, op) {
+			continue
+		}
+		rest := tok[len(op):]
+		switch op {
+		case "<", "<=":
+			return upperBound(op, rest)
+		case ">", ">=":
+			return lowerBound(op, rest)
+		case "^":
+			return caret(rest)
+		case "~":
+			return tilde(rest)
+		}
+		return xRange(rest)
+	}
+	return xRange(tok)
+}
+
+// next returns the lowest version above every version that starts with the
+// first n parts of v.
+func next(v Version, n int) Version {
+	switch n {
+	case 1:
+		return Version{Major: v.Major + 1}
+	case 2:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+func lowerBound(op, s string) ([]comparator, error) {
+	v, n, err := parsePartial(s)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case n == 0:
+		if op == ">" {
+			// Nothing is above every version
+			return []comparator{{"<", Version{}}}, nil
+		}
+		return nil, nil
+	case n < 3 && op == ">":
+		return []comparator{{">=", next(v, n)}}, nil
+	case n < 3:
+		return []comparator{{">=", v}}, nil
+	}
+	return []comparator{{op, v}}, nil
+}
+
+func upperBound(op, s string) ([]comparator, error) {
+	v, n, err := parsePartial(s)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case n == 0:
+		if op == "<" {
+			return []comparator{{"<", Version{}}}, nil
+		}
+		return nil, nil
+	case n < 3 && op == "<=":
+		return []comparator{{"<", next(v, n)}}, nil
+	case n < 3:
+		return []comparator{{"<", v}}, nil
+	}
+	return []comparator{{op, v}}, nil
+}
+
+func xRange(s string) ([]comparator, error) {
+	v, n, err := parsePartial(s)
+	if err != nil {
+		return nil, err
+	}
+	switch n {
+	case 0:
+		return nil, nil
+	case 3:
+		return []comparator{{"=", v}}, nil
+	}
+	return []comparator{{">=", v}, {"<", next(v, n)}}, nil
+}
+
+// caret allows changes that do not modify the left-most non-zero part.
+func caret(s string) ([]comparator, error) {
+	v, n, err := parsePartial(s)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	var upper Version
+	switch {
+	case v.Major > 0 || n == 1:
+		upper = next(v, 1)
+	case v.Minor > 0 || n == 2:
+		upper = next(v, 2)
+	default:
+		upper = next(v, 3)
+	}
+	return []comparator{{">=", v}, {"<", upper}}, nil
+}
+
+// tilde allows patch-level changes, or minor-level ones if only the major
+// version is given.
+func tilde(s string) ([]comparator, error) {
+	v, n, err := parsePartial(s)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	upper := next(v, 2)
+	if n == 1 {
+		upper = next(v, 1)
+	}
+	return []comparator{{">=", v}, {"<", upper}}, nil
+}
+
+func (r Range) String() string {
+	return r.raw
+}
+
+// Contains reports whether v satisfies the range.
+func (r Range) Contains(v Version) bool {
+	for _, set := range r.sets {
+		if setMatches(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func setMatches(set []comparator, v Version) bool {
+	allowed := len(v.Prerelease) == 0
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+		if len(c.v.Prerelease) > 0 && c.v.Major == v.Major && c.v.Minor == v.Minor && c.v.Patch == v.Patch {
+			allowed = true
+		}
+	}
+	return allowed
+}
+
+// Position tells where v lies relative to the range: -1 if it is below
+// every alternative, 1 if it is above every alternative and 0 if it is
+// contained or lies between alternatives.
+func (r Range) Position(v Version) int {
+	if r.Contains(v) {
+		return 0
+	}
+	// Excluded prereleases are placed like their release
+	v.Prerelease = nil
+	below, above, failed := true, true, false
+	for _, set := range r.sets {
+		for _, c := range set {
+			if c.matches(v) {
+				continue
+			}
+			failed = true
+			if c.op == ">" || c.op == ">=" || (c.op == "=" && v.Less(c.v)) {
+				above = false
+			} else {
+				below = false
+			}
+		}
+	}
+	switch {
+	case !failed:
+		return 0
+	case below:
+		return -1
+	case above:
+		return 1
+	}
+	return 0
+}
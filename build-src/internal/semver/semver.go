@@ -0,0 +1,130 @@
+// Package semver parses semantic versions and the npm range syntax used in
+// the engines field of package.json.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version. Build metadata is dropped when parsing.
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          []string
+}
+
+// Parse parses a full version such as "v20.10.0" or "25.0.0-nightly2025".
+// The leading "v" is optional.
+func Parse(s string) (Version, error) {
+	v, n, err := parsePartial(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if n < 3 {
+		return Version{}, fmt.Errorf("unvollständige Version %q", s)
+	}
+	return v, nil
+}
+
+// parsePartial parses a version that may leave out minor and patch, as
+// range comparators do (">=18"). n is the number of given parts; "x", "X"
+// and "*" count as missing.
+func parsePartial(s string) (v Version, n int, err error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "="), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if s[i+1:] == "" {
+			return v, 0, fmt.Errorf("ungültige Version %q", orig)
+		}
+		v.Prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, 0, fmt.Errorf("ungültige Version %q", orig)
+	}
+	nums := [3]*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		num, err := strconv.Atoi(part)
+		if err != nil || num < 0 {
+			return v, 0, fmt.Errorf("ungültige Version %q", orig)
+		}
+		*nums[i] = num
+		n++
+	}
+	if n < 3 && v.Prerelease != nil {
+		return v, 0, fmt.Errorf("ungültige Version %q", orig)
+	}
+	return v, n, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v is lower than, equal to or higher than w.
+func (v Version) Compare(w Version) int {
+	for _, d := range [3][2]int{{v.Major, w.Major}, {v.Minor, w.Minor}, {v.Patch, w.Patch}} {
+		if d[0] != d[1] {
+			return sign(d[0] - d[1])
+		}
+	}
+
+	// A prerelease sorts before the release itself
+	switch {
+	case len(v.Prerelease) == 0 && len(w.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(w.Prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Prerelease) && i < len(w.Prerelease); i++ {
+		if c := comparePrereleaseID(v.Prerelease[i], w.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(v.Prerelease) - len(w.Prerelease))
+}
+
+// Less reports whether v sorts before w.
+func (v Version) Less(w Version) bool {
+	return v.Compare(w) < 0
+}
+
+func comparePrereleaseID(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return sign(na - nb)
+	case errA == nil:
+		return -1 // numeric identifiers sort first
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
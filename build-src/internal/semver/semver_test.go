@@ -0,0 +1,163 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "20.10.0", want: "20.10.0"},
+		{in: "v24.11.1", want: "24.11.1"},
+		{in: " v18.0.0 ", want: "18.0.0"},
+		{in: "25.0.0-nightly2025", want: "25.0.0-nightly2025"},
+		{in: "1.0.0-rc.1+build.5", want: "1.0.0-rc.1"},
+		{in: "20.10", wantErr: true},
+		{in: "20", wantErr: true},
+		{in: "1.2.3.4", wantErr: true},
+		{in: "a.b.c", wantErr: true},
+		{in: "1.2.-3", wantErr: true},
+		{in: "1.2.3-", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		v, err := Parse(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %v, want an error", tt.in, v)
+			}
+			continue
+		}
+		if err != nil || v.String() != tt.want {
+			t.Errorf("Parse(%q) = %v, %v, want %s", tt.in, v, err, tt.want)
+		}
+	}
+}
+
+func TestPrereleaseOrder(t *testing.T) {
+	// The example from semver.org, lowest first
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1-0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, b := mustParse(t, ordered[i]), mustParse(t, ordered[j])
+			want := 0
+			switch {
+			case i < j:
+				want = -1
+			case i > j:
+				want = 1
+			}
+			if got := a.Compare(b); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+func mustParse(t *testing.T, s string) Version {
+	t.Helper()
+	v, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestRangeContains(t *testing.T) {
+	tests := []struct {
+		rng string
+		in  []string
+		out []string
+	}{
+		{
+			rng: ">=18.0.0 <25.0.0",
+			in:  []string{"18.0.0", "20.10.0", "24.99.99"},
+			out: []string{"17.9.9", "25.0.0", "25.0.0-nightly2025", "24.0.0-rc.1"},
+		},
+		{rng: ">= 18 < 25", in: []string{"18.0.0", "24.1.0"}, out: []string{"25.0.0"}},
+		{rng: "^20", in: []string{"20.0.0", "20.19.5"}, out: []string{"19.9.9", "21.0.0"}},
+		{rng: "^20.10.1", in: []string{"20.10.1", "20.99.0"}, out: []string{"20.10.0", "21.0.0"}},
+		{rng: "^0.2.3", in: []string{"0.2.3", "0.2.9"}, out: []string{"0.3.0"}},
+		{rng: "^0.0.3", in: []string{"0.0.3"}, out: []string{"0.0.4"}},
+		{rng: "~22.1", in: []string{"22.1.0", "22.1.9"}, out: []string{"22.0.9", "22.2.0"}},
+		{rng: "~22.1.2", in: []string{"22.1.2", "22.1.7"}, out: []string{"22.1.1", "22.2.0"}},
+		{rng: "~22", in: []string{"22.0.0", "22.9.0"}, out: []string{"23.0.0"}},
+		{rng: "18.x", in: []string{"18.0.0", "18.20.4"}, out: []string{"19.0.0", "17.0.0"}},
+		{rng: "18", in: []string{"18.5.0"}, out: []string{"19.0.0"}},
+		{rng: "=20.1.0", in: []string{"20.1.0"}, out: []string{"20.1.1"}},
+		{rng: "*", in: []string{"0.0.1", "99.0.0"}, out: []string{"1.0.0-beta"}},
+		{rng: "18 - 22", in: []string{"18.0.0", "22.9.9"}, out: []string{"17.9.9", "23.0.0"}},
+		{rng: "18.2 - 22.1", in: []string{"18.2.0", "22.1.9"}, out: []string{"18.1.9", "22.2.0"}},
+		{rng: ">20 <=22", in: []string{"21.0.0", "22.9.0"}, out: []string{"20.5.0", "23.0.0"}},
+		{rng: "^18 || ^20 || >=22", in: []string{"18.1.0", "20.0.0", "22.0.0", "30.0.0"}, out: []string{"19.0.0", "21.5.0", "17.0.0"}},
+		// A prerelease only matches a set naming a prerelease of its version
+		{rng: ">=25.0.0-rc.1 <26", in: []string{"25.0.0-rc.1", "25.0.0-rc.2", "25.0.0", "25.1.0"}, out: []string{"25.0.0-beta", "25.1.0-rc.1"}},
+	}
+	for _, tt := range tests {
+		r, err := ParseRange(tt.rng)
+		if err != nil {
+			t.Errorf("ParseRange(%q): %v", tt.rng, err)
+			continue
+		}
+		for _, s := range tt.in {
+			if !r.Contains(mustParse(t, s)) {
+				t.Errorf("%q does not contain %s", tt.rng, s)
+			}
+		}
+		for _, s := range tt.out {
+			if r.Contains(mustParse(t, s)) {
+				t.Errorf("%q contains %s", tt.rng, s)
+			}
+		}
+	}
+}
+
+func TestParseRangeErrors(t *testing.T) {
+	for _, s := range []string{">=abc", "^1.2.3.4", "~a.b", "18 - foo", ">=18 <1.2.3-"} {
+		if _, err := ParseRange(s); err == nil {
+			t.Errorf("ParseRange(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestRangePosition(t *testing.T) {
+	tests := []struct {
+		rng     string
+		version string
+		want    int
+	}{
+		{">=18.0.0 <25.0.0", "16.20.0", -1},
+		{">=18.0.0 <25.0.0", "20.0.0", 0},
+		{">=18.0.0 <25.0.0", "25.0.0", 1},
+		{">=18.0.0 <25.0.0", "25.0.0-nightly2025", 1},
+		{">=18.0.0 <25.0.0", "18.0.0-rc.1", 0}, // placed like 18.0.0
+		{"^18 || ^20", "17.0.0", -1},
+		{"^18 || ^20", "19.0.0", 0}, // between the alternatives
+		{"^18 || ^20", "22.0.0", 1},
+		{"=20.1.0", "20.0.0", -1},
+		{"=20.1.0", "21.0.0", 1},
+	}
+	for _, tt := range tests {
+		r, err := ParseRange(tt.rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Position(mustParse(t, tt.version)); got != tt.want {
+			t.Errorf("%q.Position(%s) = %d, want %d", tt.rng, tt.version, got, tt.want)
+		}
+	}
+}